@@ -12,6 +12,9 @@ var (
 	addPriority string
 	addNotes    string
 	addParent   string
+	addRepeat   string
+	addUntil    string
+	addOutput   string
 )
 
 var addCmd = &cobra.Command{
@@ -19,33 +22,45 @@ var addCmd = &cobra.Command{
 	Short: "Add a reminder",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		title := args[0]
-		if err := syncEngine.Sync(false); err != nil {
-			return err
-		}
-		result, err := w.AddReminder(title, addListName, addDue, addPriority, addNotes, addParent)
-		if err != nil {
+		if err := checkResultFormat(addOutput); err != nil {
 			return err
 		}
-		if errMsg, ok := result["error"].(string); ok {
-			return fmt.Errorf("%s", errMsg)
-		}
-		listStr := ""
-		if addListName != "" {
-			listStr = fmt.Sprintf(" → %s", addListName)
-		}
-		parentStr := ""
-		if addParent != "" {
-			parentStr = fmt.Sprintf(" (subtask of %s)", addParent)
-		}
-		fmt.Printf("✅ Added: '%s'%s%s\n", title, listStr, parentStr)
-		return nil
+		title := args[0]
+		return reportWrite(addOutput, "add", func() (*writeResult, error) {
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			result, err := w.AddReminder(title, addListName, addDue, addPriority, addNotes, addParent, addRepeat, addUntil)
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			listStr := ""
+			if addListName != "" {
+				listStr = fmt.Sprintf(" → %s", addListName)
+			}
+			parentStr := ""
+			if addParent != "" {
+				parentStr = fmt.Sprintf(" (subtask of %s)", addParent)
+			}
+			repeatStr := ""
+			if addRepeat != "" {
+				repeatStr = fmt.Sprintf(" 🔁 %s", addRepeat)
+			}
+			return &writeResult{
+				Reminders: changedEntries(resultIDs(result, "reminder_id")),
+				text:      func() { fmt.Printf("✅ Added: '%s'%s%s%s\n", title, listStr, parentStr, repeatStr) },
+			}, nil
+		})
 	},
 }
 
 var (
 	batchListName string
 	batchParent   string
+	batchOutput   string
 )
 
 var addBatchCmd = &cobra.Command{
@@ -53,49 +68,62 @@ var addBatchCmd = &cobra.Command{
 	Short: "Add multiple reminders at once",
 	Args:  cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := syncEngine.Sync(false); err != nil {
-			return err
-		}
-		result, err := w.AddRemindersBatch(args, batchListName, batchParent)
-		if err != nil {
+		if err := checkResultFormat(batchOutput); err != nil {
 			return err
 		}
-		if errMsg, ok := result["error"].(string); ok {
-			return fmt.Errorf("%s", errMsg)
-		}
-		count := len(args)
-		if c, ok := result["created_count"].(int); ok {
-			count = c
-		}
-		listStr := ""
-		if batchListName != "" {
-			listStr = fmt.Sprintf(" → %s", batchListName)
-		}
-		parentStr := ""
-		if batchParent != "" {
-			parentStr = fmt.Sprintf(" (subtasks of %s)", batchParent)
-		}
-		fmt.Printf("✅ Added %d reminders%s%s:\n", count, listStr, parentStr)
-		titles := args
-		if t, ok := result["titles"].([]string); ok {
-			titles = t
-		}
-		for _, t := range titles {
-			fmt.Printf("   • %s\n", t)
-		}
-		return nil
+		return reportWrite(batchOutput, "add-batch", func() (*writeResult, error) {
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			result, err := w.AddRemindersBatch(args, batchListName, batchParent)
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			count := len(args)
+			if c, ok := result["created_count"].(int); ok {
+				count = c
+			}
+			listStr := ""
+			if batchListName != "" {
+				listStr = fmt.Sprintf(" → %s", batchListName)
+			}
+			parentStr := ""
+			if batchParent != "" {
+				parentStr = fmt.Sprintf(" (subtasks of %s)", batchParent)
+			}
+			titles := args
+			if t, ok := result["titles"].([]string); ok {
+				titles = t
+			}
+			return &writeResult{
+				Reminders: changedEntries(resultIDs(result, "reminder_ids")),
+				text: func() {
+					fmt.Printf("✅ Added %d reminders%s%s:\n", count, listStr, parentStr)
+					for _, t := range titles {
+						fmt.Printf("   • %s\n", t)
+					}
+				},
+			}, nil
+		})
 	},
 }
 
 func init() {
 	addCmd.Flags().StringVarP(&addListName, "list", "l", "", "List name (required)")
-	addCmd.Flags().StringVarP(&addDue, "due", "d", "", "Due date (YYYY-MM-DD)")
+	addCmd.Flags().StringVarP(&addDue, "due", "d", "", "Due date (YYYY-MM-DD, \"YYYY-MM-DD HH:MM\", or relative: tomorrow, \"fri 9am\", +3d)")
 	addCmd.Flags().StringVarP(&addPriority, "priority", "p", "", "Priority (high, medium, low)")
 	addCmd.Flags().StringVarP(&addNotes, "notes", "n", "", "Notes")
 	addCmd.Flags().StringVar(&addParent, "parent", "", "Parent reminder ID (creates subtask)")
+	addCmd.Flags().StringVar(&addRepeat, "repeat", "", "Repeat: daily, weekly, monthly, yearly, or \"every N days|weeks|months|years\" (needs --due)")
+	addCmd.Flags().StringVar(&addUntil, "repeat-until", "", "Last date the reminder repeats (YYYY-MM-DD or relative, e.g. +6m)")
+	addResultFlag(addCmd, &addOutput)
 	_ = addCmd.MarkFlagRequired("list")
 
 	addBatchCmd.Flags().StringVarP(&batchListName, "list", "l", "", "List name (required)")
 	addBatchCmd.Flags().StringVar(&batchParent, "parent", "", "Parent reminder ID (creates subtasks)")
+	addResultFlag(addBatchCmd, &batchOutput)
 	_ = addBatchCmd.MarkFlagRequired("list")
-}
\ No newline at end of file
+}
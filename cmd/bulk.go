@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+
+	"icloud-reminders/internal/filter"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// bulkHelp documents the target selection shared by complete, delete and edit.
+const bulkHelp = `Pass one or more reminder IDs, "-" to read IDs from stdin (one per line,
+first word used), or --where to select reminders with a filter expression
+(see 'reminders help filters'). When more than one reminder may be affected,
+the matching reminders are listed first and you are asked to confirm;
+use --yes to skip the prompt (required when not on a terminal) or
+--dry-run to only show the preview. Changes are sent in batches and each
+reminder's result is reported.`
+
+// selectTargets resolves the reminders a write command acts on: the IDs in
+// args ("-" reads IDs from stdin) or the reminders matching where. bulk is
+// false only for a single ID on the command line, which keeps the
+// one-reminder behavior without preview or prompt.
+func selectTargets(args []string, where string) (targets []*models.Reminder, bulk, fromStdin bool, err error) {
+	f, err := filter.Parse(where)
+	if err != nil {
+		return nil, false, false, err
+	}
+	if f != nil && len(args) > 0 {
+		return nil, false, false, fmt.Errorf("use either reminder IDs or --where, not both")
+	}
+	if f == nil && len(args) == 0 {
+		return nil, false, false, fmt.Errorf("no reminders given — pass IDs, \"-\" for stdin, or --where")
+	}
+
+	if f != nil {
+		return f.Select(syncEngine.GetReminders(f.Uses("completed"))), true, false, nil
+	}
+
+	ids := args
+	if len(args) == 1 && args[0] == "-" {
+		fromStdin = true
+		if ids, err = readIDs(); err != nil {
+			return nil, false, false, err
+		}
+	}
+	byID := make(map[string]*models.Reminder)
+	for _, r := range syncEngine.GetReminders(true) {
+		byID[r.ID] = r
+	}
+	seen := make(map[string]bool)
+	var missing []string
+	for _, id := range ids {
+		fullID := syncEngine.FindReminderByID(id)
+		if fullID == "" {
+			missing = append(missing, id)
+			continue
+		}
+		if !seen[fullID] {
+			seen[fullID] = true
+			targets = append(targets, byID[fullID])
+		}
+	}
+	if len(missing) > 0 {
+		return nil, false, false, fmt.Errorf("reminder(s) not found: %s", strings.Join(missing, ", "))
+	}
+	return targets, len(args) > 1 || fromStdin, fromStdin, nil
+}
+
+// readIDs reads reminder IDs from stdin: the first word of each line,
+// skipping blank lines and lines starting with '#'.
+func readIDs() ([]string, error) {
+	var ids []string
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		ids = append(ids, fields[0])
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read IDs from stdin: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no reminder IDs on stdin")
+	}
+	return ids, nil
+}
+
+// confirmBulk previews the reminders a bulk command will change on out and
+// asks for confirmation. It returns false (and no error) for --dry-run or a
+// declined prompt.
+func confirmBulk(out io.Writer, action string, targets []*models.Reminder, yes, dryRun, fromStdin bool) (bool, error) {
+	if len(targets) == 0 {
+		return false, fmt.Errorf("no reminders match")
+	}
+	fmt.Fprintf(out, "\n%s %d reminder(s):\n", action, len(targets))
+	for _, r := range targets {
+		status := "•"
+		if r.Completed {
+			status = "✓"
+		}
+		due := ""
+		if r.Due != nil && *r.Due != "" {
+			due = fmt.Sprintf("  [due %s]", utils.DisplayDue(*r.Due))
+		}
+		fmt.Fprintf(out, "  %s %s%s  (%s) — %s\n", status, r.Title, due, r.ShortID(), r.ListName)
+	}
+	if dryRun {
+		fmt.Fprintln(out, "\nDry run — nothing changed.")
+		return false, nil
+	}
+	if yes {
+		return true, nil
+	}
+	if fromStdin || !term.IsTerminal(int(os.Stdin.Fd())) {
+		return false, fmt.Errorf("confirmation required — re-run with --yes to apply")
+	}
+	fmt.Fprint(out, "\nProceed? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+	fmt.Fprintln(out, "Aborted — nothing changed.")
+	return false, nil
+}
+
+// previewOut is where confirmBulk writes: stdout, or stderr when stdout is
+// reserved for a JSON result.
+func previewOut(format string) io.Writer {
+	if format == "json" {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// unconfirmed is the result of a bulk command that was not applied: the
+// reminders it would have changed for --dry-run, none for a declined prompt.
+func unconfirmed(targets []*models.Reminder, dryRun bool) *writeResult {
+	res := &writeResult{DryRun: dryRun}
+	if dryRun {
+		for _, r := range targets {
+			res.Reminders = append(res.Reminders, &resultEntry{Reminder: r})
+		}
+	}
+	return res
+}
+
+// targetIDs returns the short IDs of targets.
+func targetIDs(targets []*models.Reminder) []string {
+	ids := make([]string, len(targets))
+	for i, r := range targets {
+		ids[i] = r.ShortID()
+	}
+	return ids
+}
+
+// bulkResult turns a bulk writer result into a write result, one entry per
+// target. Changed reminders are reported as they are now; deleted and failed
+// ones as they were before. done labels the text summary ("Completed", ...).
+func bulkResult(done string, targets []*models.Reminder, result map[string]interface{}, deleted bool) (*writeResult, error) {
+	if errMsg, ok := result["error"].(string); ok {
+		return nil, fmt.Errorf("%s", errMsg)
+	}
+	byID := make(map[string]*models.Reminder, len(targets))
+	for _, r := range targets {
+		byID[r.ShortID()] = r
+	}
+
+	res := &writeResult{}
+	entries, _ := result["results"].([]map[string]interface{})
+	for _, e := range entries {
+		id, _ := e["id"].(string)
+		r := byID[id]
+		if r == nil {
+			r = &models.Reminder{ID: id}
+		}
+		entry := &resultEntry{Reminder: r, OK: e["ok"] == true}
+		if entry.OK && !deleted {
+			if now := syncEngine.GetReminder(r.ID); now != nil {
+				entry.Reminder = now
+			}
+		}
+		entry.Error, _ = e["error"].(string)
+		entry.NextDue, _ = e["next_due"].(string)
+		res.Reminders = append(res.Reminders, entry)
+	}
+
+	res.text = func() {
+		fmt.Println()
+		for _, e := range res.Reminders {
+			if e.OK {
+				next := ""
+				if e.NextDue != "" {
+					next = fmt.Sprintf(" — next due %s", e.NextDue)
+				}
+				fmt.Printf("  ✅ %s  (%s)%s\n", e.Title, e.ShortID(), next)
+			} else {
+				fmt.Printf("  ❌ %s  (%s): %s\n", e.Title, e.ShortID(), e.Error)
+			}
+		}
+		fmt.Printf("\n%s %d, failed %d\n", done, res.Succeeded, res.Failed)
+	}
+	return res, nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"icloud-reminders/internal/caldav"
+)
+
+var (
+	caldavListen       string
+	caldavSyncInterval time.Duration
+	caldavUser         string
+	caldavPassword     string
+)
+
+var caldavCmd = &cobra.Command{
+	Use:   "caldav",
+	Short: "Serve reminder lists to CalDAV task clients",
+	Long: `Serve every list as a CalDAV task calendar (VTODOs) until interrupted,
+for clients such as Thunderbird, Evolution or DAVx⁵ + jtx Board.
+
+Point the client at the server's address (e.g. http://127.0.0.1:5232/);
+calendars are discovered from there. Tasks created, edited, completed,
+moved or deleted in the client are written to iCloud; changes made
+elsewhere reach the client on its next sync (the cache is delta-synced
+every --sync-interval, and sync-collection asks iCloud directly).
+
+Lists themselves cannot be created, renamed or deleted over CalDAV; use
+'reminders lists'.
+
+With --password (or $REMINDERS_CALDAV_PASSWORD) set, clients must log in
+with --user and that password.
+
+Examples:
+  reminders caldav
+  reminders caldav --listen 127.0.0.1:5232 --sync-interval 30s
+  REMINDERS_CALDAV_PASSWORD=s3cret reminders caldav --listen 0.0.0.0:5232`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		password := caldavPassword
+		if password == "" {
+			password = os.Getenv("REMINDERS_CALDAV_PASSWORD")
+		}
+		if password == "" && !isLoopback(caldavListen) {
+			fmt.Fprintf(os.Stderr, "⚠️  Listening on %s without --password: anyone who can reach it can change your reminders.\n", caldavListen)
+		}
+
+		ln, err := net.Listen("tcp", caldavListen)
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Printf("CalDAV server on http://%s/\n", ln.Addr())
+		return caldav.New(syncEngine, w, caldavSyncInterval, caldavUser, password).Serve(ctx, ln)
+	},
+}
+
+func init() {
+	caldavCmd.Flags().StringVar(&caldavListen, "listen", "127.0.0.1:5232", "Address to listen on")
+	caldavCmd.Flags().DurationVar(&caldavSyncInterval, "sync-interval", time.Minute, "How often to delta-sync in the background (0 = only when clients sync or write)")
+	caldavCmd.Flags().StringVar(&caldavUser, "user", "reminders", "User name clients log in with when a password is set")
+	caldavCmd.Flags().StringVar(&caldavPassword, "password", "", "Require HTTP Basic authentication with this password (default $REMINDERS_CALDAV_PASSWORD)")
+}
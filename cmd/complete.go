@@ -6,22 +6,75 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	completeWhere  string
+	completeYes    bool
+	completeDryRun bool
+	completeOutput string
+)
+
 var completeCmd = &cobra.Command{
-	Use:   "complete <id>",
-	Short: "Mark a reminder as complete",
-	Args:  cobra.ExactArgs(1),
+	Use:   "complete <id>... | - | --where <filter>",
+	Short: "Mark reminders as complete",
+	Long: `Mark one or more reminders as complete. Repeating reminders roll
+forward to their next due date instead.
+
+` + bulkHelp + `
+
+Examples:
+  reminders complete ABC123
+  reminders complete ABC123 DEF456
+  reminders complete --where 'list=Groceries and not completed'
+  reminders complete --where 'due<today' --dry-run`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := syncEngine.Sync(false); err != nil {
+		if err := checkResultFormat(completeOutput); err != nil {
 			return err
 		}
-		result, err := w.CompleteReminder(args[0])
-		if err != nil {
-			return err
-		}
-		if errMsg, ok := result["error"].(string); ok {
-			return fmt.Errorf("%s", errMsg)
-		}
-		fmt.Printf("✅ Completed: %s\n", args[0])
-		return nil
+		return reportWrite(completeOutput, "complete", func() (*writeResult, error) {
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			targets, bulk, fromStdin, err := selectTargets(args, completeWhere)
+			if err != nil {
+				return nil, err
+			}
+			if bulk {
+				ok, err := confirmBulk(previewOut(completeOutput), "Complete", targets, completeYes, completeDryRun, fromStdin)
+				if err != nil || !ok {
+					return unconfirmed(targets, completeDryRun), err
+				}
+				result, err := w.CompleteReminders(targetIDs(targets))
+				if err != nil {
+					return nil, err
+				}
+				return bulkResult("Completed", targets, result, false)
+			}
+
+			result, err := w.CompleteReminder(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			res := &writeResult{Reminders: changedEntries([]string{targets[0].ID})}
+			next, _ := result["next_due"].(string)
+			res.Reminders[0].NextDue = next
+			res.text = func() {
+				if next != "" {
+					fmt.Printf("🔁 Completed occurrence: %s — next due %s\n", args[0], next)
+					return
+				}
+				fmt.Printf("✅ Completed: %s\n", args[0])
+			}
+			return res, nil
+		})
 	},
-}
\ No newline at end of file
+}
+
+func init() {
+	completeCmd.Flags().StringVarP(&completeWhere, "where", "w", "", "Complete every reminder matching a filter expression")
+	completeCmd.Flags().BoolVarP(&completeYes, "yes", "y", false, "Apply bulk changes without asking")
+	completeCmd.Flags().BoolVar(&completeDryRun, "dry-run", false, "Only list the reminders that would be completed")
+	addResultFlag(completeCmd, &completeOutput)
+}
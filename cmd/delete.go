@@ -6,22 +6,66 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var (
+	deleteWhere  string
+	deleteYes    bool
+	deleteDryRun bool
+	deleteOutput string
+)
+
 var deleteCmd = &cobra.Command{
-	Use:   "delete <id>",
-	Short: "Delete a reminder",
-	Args:  cobra.ExactArgs(1),
+	Use:   "delete <id>... | - | --where <filter>",
+	Short: "Delete reminders",
+	Long: `Delete one or more reminders.
+
+` + bulkHelp + `
+
+Examples:
+  reminders delete ABC123
+  reminders delete --where 'completed and list=Groceries' --yes
+  reminders delete - --yes < ids.txt`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := syncEngine.Sync(false); err != nil {
+		if err := checkResultFormat(deleteOutput); err != nil {
 			return err
 		}
-		result, err := w.DeleteReminder(args[0])
-		if err != nil {
-			return err
-		}
-		if errMsg, ok := result["error"].(string); ok {
-			return fmt.Errorf("%s", errMsg)
-		}
-		fmt.Printf("✅ Deleted: %s\n", args[0])
-		return nil
+		return reportWrite(deleteOutput, "delete", func() (*writeResult, error) {
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			targets, bulk, fromStdin, err := selectTargets(args, deleteWhere)
+			if err != nil {
+				return nil, err
+			}
+			if bulk {
+				ok, err := confirmBulk(previewOut(deleteOutput), "Delete", targets, deleteYes, deleteDryRun, fromStdin)
+				if err != nil || !ok {
+					return unconfirmed(targets, deleteDryRun), err
+				}
+				result, err := w.DeleteReminders(targetIDs(targets))
+				if err != nil {
+					return nil, err
+				}
+				return bulkResult("Deleted", targets, result, true)
+			}
+
+			result, err := w.DeleteReminder(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			return &writeResult{
+				Reminders: []*resultEntry{{Reminder: targets[0], OK: true}},
+				text:      func() { fmt.Printf("✅ Deleted: %s\n", args[0]) },
+			}, nil
+		})
 	},
-}
\ No newline at end of file
+}
+
+func init() {
+	deleteCmd.Flags().StringVarP(&deleteWhere, "where", "w", "", "Delete every reminder matching a filter expression")
+	deleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Apply bulk changes without asking")
+	deleteCmd.Flags().BoolVar(&deleteDryRun, "dry-run", false, "Only list the reminders that would be deleted")
+	addResultFlag(deleteCmd, &deleteOutput)
+}
@@ -4,48 +4,126 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+
+	"icloud-reminders/internal/writer"
 )
 
 var (
-	editTitle    string
-	editDue      string
-	editNotes    string
-	editPriority string
+	editTitle      string
+	editDue        string
+	editNotes      string
+	editPriority   string
+	editRepeat     string
+	editUntil      string
+	editParent     string
+	editNoParent   bool
+	editClearDue   bool
+	editClearNotes bool
+	editWhere      string
+	editYes        bool
+	editDryRun     bool
+	editOutput     string
 )
 
 var editCmd = &cobra.Command{
-	Use:   "edit <id>",
-	Short: "Edit a reminder (title, due date, notes, or priority)",
-	Long: `Update one or more fields on an existing reminder.
+	Use:   "edit <id>... | - | --where <filter>",
+	Short: "Edit reminders (title, due date, notes, priority, repeat, or parent)",
+	Long: `Update one or more fields on existing reminders.
+
+At least one field flag must be provided. Only specified fields are changed;
+unspecified fields are left unchanged. Use --clear-due and --clear-notes
+to remove a due date or notes.
 
-At least one flag must be provided. Only specified fields are changed;
-unspecified fields are left unchanged.
+` + bulkHelp + `
 
 Examples:
   reminders edit ABC123 --title "New title"
   reminders edit ABC123 --due 2026-03-01 --priority high
+  reminders edit ABC123 --due "2026-03-01 09:30" --tz Europe/Berlin
+  reminders edit ABC123 --due "next friday 9am"
   reminders edit ABC123 --notes "Updated notes"
-  reminders edit ABC123 --priority none`,
-	Args: cobra.ExactArgs(1),
+  reminders edit ABC123 --clear-due --clear-notes
+  reminders edit ABC123 --clear-due --repeat none   # stop repeating too
+  reminders edit ABC123 --priority none
+  reminders edit ABC123 --repeat "every 2 weeks" --repeat-until 2026-12-31
+  reminders edit ABC123 --repeat none
+  reminders edit ABC123 --parent DEF456   # make it a subtask of DEF456
+  reminders edit ABC123 --no-parent       # promote a subtask to top level
+  reminders edit --where 'list=Work and due<today' --due tomorrow`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		if err := syncEngine.Sync(false); err != nil {
+		if err := checkResultFormat(editOutput); err != nil {
 			return err
 		}
-		result, err := w.EditReminder(args[0], editTitle, editDue, editNotes, editPriority)
-		if err != nil {
-			return err
+		parent := editParent
+		if editNoParent {
+			parent = "none"
 		}
-		if errMsg, ok := result["error"].(string); ok {
-			return fmt.Errorf("%s", errMsg)
+		fields := writer.EditFields{
+			Title:       editTitle,
+			Due:         editDue,
+			Notes:       editNotes,
+			Priority:    editPriority,
+			Repeat:      editRepeat,
+			RepeatUntil: editUntil,
+			Parent:      parent,
+			ClearDue:    editClearDue,
+			ClearNotes:  editClearNotes,
 		}
-		fmt.Printf("✅ Updated: %s\n", args[0])
-		return nil
+
+		return reportWrite(editOutput, "edit", func() (*writeResult, error) {
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			targets, bulk, fromStdin, err := selectTargets(args, editWhere)
+			if err != nil {
+				return nil, err
+			}
+			if bulk {
+				if fields == (writer.EditFields{}) {
+					return nil, fmt.Errorf("no changes specified — use --title, --due, --notes, --priority, --repeat, --parent, --clear-due or --clear-notes")
+				}
+				ok, err := confirmBulk(previewOut(editOutput), "Edit", targets, editYes, editDryRun, fromStdin)
+				if err != nil || !ok {
+					return unconfirmed(targets, editDryRun), err
+				}
+				result, err := w.EditReminders(targetIDs(targets), fields)
+				if err != nil {
+					return nil, err
+				}
+				return bulkResult("Updated", targets, result, false)
+			}
+
+			result, err := w.EditReminder(args[0], fields)
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			return &writeResult{
+				Reminders: changedEntries([]string{targets[0].ID}),
+				text:      func() { fmt.Printf("✅ Updated: %s\n", args[0]) },
+			}, nil
+		})
 	},
 }
 
 func init() {
 	editCmd.Flags().StringVar(&editTitle, "title", "", "New title")
-	editCmd.Flags().StringVarP(&editDue, "due", "d", "", "New due date (YYYY-MM-DD)")
+	editCmd.Flags().StringVarP(&editDue, "due", "d", "", "New due date (YYYY-MM-DD, \"YYYY-MM-DD HH:MM\", or relative: tomorrow, \"fri 9am\", +3d)")
 	editCmd.Flags().StringVarP(&editNotes, "notes", "n", "", "New notes")
 	editCmd.Flags().StringVarP(&editPriority, "priority", "p", "", "New priority (high, medium, low, none)")
+	editCmd.Flags().StringVar(&editRepeat, "repeat", "", "New repeat rule (daily, weekly, monthly, yearly, \"every N weeks\", none)")
+	editCmd.Flags().StringVar(&editUntil, "repeat-until", "", "New last repeat date (YYYY-MM-DD or relative, e.g. +6m)")
+	editCmd.Flags().StringVar(&editParent, "parent", "", "Make this a subtask of another reminder (same list)")
+	editCmd.Flags().BoolVar(&editNoParent, "no-parent", false, "Promote a subtask to a top-level reminder")
+	editCmd.Flags().BoolVar(&editClearDue, "clear-due", false, "Remove the due date")
+	editCmd.Flags().BoolVar(&editClearNotes, "clear-notes", false, "Remove the notes")
+	editCmd.Flags().StringVarP(&editWhere, "where", "w", "", "Edit every reminder matching a filter expression")
+	editCmd.Flags().BoolVarP(&editYes, "yes", "y", false, "Apply bulk changes without asking")
+	editCmd.Flags().BoolVar(&editDryRun, "dry-run", false, "Only list the reminders that would be edited")
+	addResultFlag(editCmd, &editOutput)
+	editCmd.MarkFlagsMutuallyExclusive("parent", "no-parent")
+	editCmd.MarkFlagsMutuallyExclusive("due", "clear-due")
+	editCmd.MarkFlagsMutuallyExclusive("notes", "clear-notes")
 }
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"icloud-reminders/internal/filter"
+	"icloud-reminders/internal/ical"
+	"icloud-reminders/internal/plaintext"
+	"icloud-reminders/pkg/models"
+)
+
+// exporter writes reminders in a file format. listName is the exported
+// list's name, or "" when several lists are exported.
+type exporter func(w io.Writer, reminders []*models.Reminder, listName string) error
+
+// exportFormats is the registry behind export --format.
+var exportFormats = map[string]exporter{
+	"ics":       ical.Encode,
+	"todotxt":   plaintext.EncodeTodoTxt,
+	"taskpaper": plaintext.EncodeTaskPaper,
+	"markdown":  plaintext.EncodeMarkdown,
+}
+
+var (
+	exportFormat string
+	exportList   string
+	exportWhere  string
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export --format <format>",
+	Short: "Export reminders to a file format (ics, todotxt, taskpaper, markdown)",
+	Long: `Export reminders, including completed ones, to stdout.
+
+Formats:
+  ics        iCalendar (RFC 5545) with one VTODO per reminder. Due dates,
+             priority, completion, notes, repeat rules and subtasks
+             (RELATED-TO;RELTYPE=PARENT) are kept; the list name is
+             written as CATEGORIES.
+  todotxt    One todo.txt line per reminder: x (done), (A)-(C) priority,
+             +List, due:, rec:/until:, note:, id: and parent: tags. A
+             title that would be misread (a +word, a word like due:x,
+             runs of spaces) is written as a percent-encoded title: tag.
+  taskpaper  TaskPaper, one "List:" project per list with subtasks
+             indented under their parents and notes below each task. A
+             note line that would read as a task or project is written
+             with a leading \.
+  markdown   A "- [ ]" checklist with a heading per list and nested
+             subtasks, in the Obsidian Tasks emoji format (⏫ 🔼 🔽,
+             📅 due day, ✅ done date, 🔁 repeat, 🆔 reminder ID).
+
+The todotxt and taskpaper formats read back with 'reminders import' to
+the same reminders, except that TaskPaper drops whitespace at the ends of
+titles and note lines and reads tags it knows (@due(...), @done, ...)
+written in a title as fields. Importing a markdown checklist creates new items and completes
+the reminders of items ticked off.
+
+Examples:
+  reminders export --format ics > reminders.ics
+  reminders export --format ics --list Groceries
+  reminders export --format ics --where 'not completed'
+  reminders export --format todotxt > todo.txt
+  reminders export --format taskpaper --list Work > work.taskpaper
+  reminders export --format markdown --list Work > ~/vault/Work.md`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		export, ok := exportFormats[exportFormat]
+		if !ok {
+			return fmt.Errorf("unknown export format %q (use: %s)", exportFormat, strings.Join(exportFormatNames(), ", "))
+		}
+		where, err := filter.Parse(exportWhere)
+		if err != nil {
+			return err
+		}
+		if err := syncEngine.Sync(false); err != nil {
+			return err
+		}
+
+		listID, listName := "", ""
+		if exportList != "" {
+			if listID = syncEngine.FindList(exportList); listID == "" {
+				return fmt.Errorf("list '%s' not found", exportList)
+			}
+			listName = syncEngine.Cache.Lists[listID]
+		}
+		var reminders []*models.Reminder
+		for _, r := range where.Select(syncEngine.GetReminders(true)) {
+			if listID == "" || (r.ListRef != nil && *r.ListRef == listID) {
+				reminders = append(reminders, r)
+			}
+		}
+		sortForExport(reminders)
+		return export(os.Stdout, reminders, listName)
+	},
+}
+
+// exportFormatNames returns the registered export formats, sorted.
+func exportFormatNames() []string {
+	names := make([]string, 0, len(exportFormats))
+	for name := range exportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// sortForExport orders reminders by list, then top-level reminders before
+// subtasks, then by title, so parents precede their subtasks.
+func sortForExport(reminders []*models.Reminder) {
+	sort.SliceStable(reminders, func(i, j int) bool {
+		a, b := reminders[i], reminders[j]
+		if a.ListName != b.ListName {
+			return a.ListName < b.ListName
+		}
+		if sa, sb := a.ParentRef != nil, b.ParentRef != nil; sa != sb {
+			return !sa
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportFormat, "format", "f", "", "Export format ("+strings.Join(exportFormatNames(), ", ")+")")
+	exportCmd.Flags().StringVarP(&exportList, "list", "l", "", "Only export this list (name or ID)")
+	exportCmd.Flags().StringVarP(&exportWhere, "where", "w", "", "Only export reminders matching a filter expression (see 'reminders help filters')")
+	_ = exportCmd.MarkFlagRequired("format")
+}
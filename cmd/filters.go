@@ -0,0 +1,36 @@
+package cmd
+
+import "github.com/spf13/cobra"
+
+// filtersHelpCmd is a help topic ('reminders help filters'), not a runnable command.
+var filtersHelpCmd = &cobra.Command{
+	Use:   "filters",
+	Short: "Filter expression syntax for --where",
+	Long: `Commands that take --where (list, search, json) accept a filter expression:
+
+  list=Work and due<=+3d and priority>=medium and not completed
+
+Combine comparisons with and, or, not and parentheses. Quote values that
+contain spaces: list='Shopping List', due<'fri 9am'.
+
+Fields:
+  title, notes, list   = and != (case-insensitive), ~ (contains)
+  id                   = and != (ID prefix)
+  due                  = != < <= > >= against a date (2026-10-17, today,
+                       +3d, "fri 9am", ...) or none
+  priority             none < low < medium < high
+  completed            true/false
+  repeating            true/false (has a repeat rule)
+  subtask              true/false (has a parent)
+
+A field on its own tests that it is set: 'due and not repeating'.
+Dates without a time compare whole days, so due<=today includes
+reminders due later today. Filters that mention completed include
+completed reminders without --all.
+
+Examples:
+  reminders list --where 'due<today and not completed'
+  reminders list --where 'list=Work and (priority=high or due<=tomorrow)'
+  reminders search milk --where 'notes~organic'
+  reminders json --where 'repeating'`,
+}
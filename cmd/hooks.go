@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"icloud-reminders/internal/hooks"
+)
+
+var hooksFile string
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Check and show the configured change hooks",
+	Long: `Validate a hooks file and show its hooks. Hooks run when 'reminders watch
+--hooks' sees a matching change.
+
+The file (default ~/.config/icloud-reminders/hooks.json) holds a list of
+hooks; each has a name, a command (argv) or a url to POST to, and optional
+filters and limits:
+
+  {"hooks": [
+    {"name": "ops-intake", "events": ["created"], "where": "list=Ops",
+     "command": ["/usr/local/bin/ops-intake"]},
+    {"name": "done-high", "events": ["completed"], "where": "priority=high",
+     "url": "http://127.0.0.1:8000/reminders", "timeout": "5s", "retries": 2}
+  ]}
+
+  events   change types to fire for: created, updated, completed, deleted,
+           list_renamed (default: all)
+  where    filter expression on the reminder after the change (before it,
+           for deletions); see 'reminders help filters'
+  timeout  how long one attempt may take (default 10s)
+  retries  extra attempts after a failure, with exponential backoff
+
+The change is the JSON object 'reminders watch' prints: sent on stdin to a
+command (with $REMINDERS_EVENT_TYPE and $REMINDERS_EVENT_ID set), or as the
+body of the POST. A non-zero exit or a non-2xx response is a failure.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := hooks.Load(hooksFile)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\n🪝 Hooks (%d) in %s\n", len(cfg.Hooks), hooksFile)
+		for _, h := range cfg.Hooks {
+			events := "all events"
+			if len(h.Events) > 0 {
+				events = strings.Join(h.Events, ", ")
+			}
+			target := "POST " + h.URL
+			if len(h.Command) > 0 {
+				target = strings.Join(h.Command, " ")
+			}
+			fmt.Printf("  • %s: %s", h.Name, events)
+			if h.Where != "" {
+				fmt.Printf(" where %s", h.Where)
+			}
+			fmt.Printf(" → %s\n", target)
+		}
+		return nil
+	},
+}
+
+func init() {
+	hooksCmd.Flags().StringVarP(&hooksFile, "file", "f", hooks.DefaultFile, "Hooks file")
+}
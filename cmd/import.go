@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"icloud-reminders/internal/ical"
+	"icloud-reminders/internal/plaintext"
+	"icloud-reminders/internal/writer"
+	"icloud-reminders/pkg/models"
+)
+
+// importer reads the reminders of a file format.
+type importer func(r io.Reader) ([]writer.ImportItem, error)
+
+// importFormats is the registry behind import --format. Without --format
+// the file extension picks the format.
+var importFormats = map[string]importer{
+	"ics":       icsItems,
+	"todotxt":   taskItems(plaintext.DecodeTodoTxt, false),
+	"taskpaper": taskItems(plaintext.DecodeTaskPaper, false),
+	"markdown":  taskItems(plaintext.DecodeMarkdown, true),
+	"csv":       taskItems(plaintext.DecodeCSV, false),
+}
+
+// importExtensions maps file extensions that are not format names.
+var importExtensions = map[string]string{
+	"txt": "todotxt",
+	"md":  "markdown",
+}
+
+var (
+	importList   string
+	importFormat string
+	importDryRun bool
+	importOutput string
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <file | ->",
+	Short: "Import reminders from a file (ics, todotxt, taskpaper, markdown, csv)",
+	Long: `Create reminders from a file ("-" reads stdin).
+
+Formats (from --format, else the file extension; .txt is todotxt, .md
+markdown):
+  ics        iCalendar VTODOs. SUMMARY, DESCRIPTION, DUE, PRIORITY,
+             STATUS:COMPLETED/COMPLETED, RRULE (FREQ, INTERVAL, UNTIL) and
+             RELATED-TO parents are imported.
+  todotxt    todo.txt lines: x (done), (A)-(C) priority, +List, due:,
+             rec:/until:, note:, id: and parent:.
+  taskpaper  TaskPaper: "List:" projects, "- " tasks, indented subtasks and
+             notes, @priority(), @due(), @repeat(), @done(), @list().
+  markdown   "- [ ]" / "- [x]" checklists (Obsidian Tasks style): headings
+             name lists, nested items are subtasks, ⏫ 🔼 🔽 priority,
+             📅 due, ✅ done date, 🔁 repeat and 🆔 reminder ID. Items
+             without an ID match reminders of the same title in the list.
+  csv        A header row naming the columns, in any order: title
+             (required), list, due, priority, notes and parent (the
+             parent's title, in the file or the list). All rows are checked
+             first; invalid ones are reported by row number and nothing is
+             imported.
+
+Items go into the list the file names (+List, a TaskPaper project, a
+Markdown heading), or into --list if it does not name one or names a list
+that does not exist; a todo.txt "_" matches a space in list names.
+
+Parents are created before their subtasks, in batches. Each item's UID is
+remembered, so importing the same file again skips what is already there;
+files written by 'reminders export' are recognized by their reminder IDs.
+An item marked done whose reminder is still open completes it, so a
+checklist ticked off elsewhere can be synced back. Use --dry-run to preview.
+
+Examples:
+  reminders import tasks.ics --list Work --dry-run
+  reminders import tasks.ics --list Work
+  reminders import todo.txt --list Inbox
+  reminders export --format taskpaper > all.taskpaper && reminders import all.taskpaper
+  reminders import ~/vault/Projects/Launch.md --list Work
+  reminders import planning.csv --list Work --dry-run
+  curl -s https://example.com/tasks.ics | reminders import - --list Work --format ics`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkResultFormat(importOutput); err != nil {
+			return err
+		}
+		path := args[0]
+		format := importFormat
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+			if f, ok := importExtensions[format]; ok {
+				format = f
+			}
+		}
+		read, ok := importFormats[format]
+		if !ok {
+			if importFormat == "" {
+				return fmt.Errorf("cannot tell the format of %q — use --format (%s)", path, strings.Join(importFormatNames(), ", "))
+			}
+			return fmt.Errorf("unknown import format %q (use: %s)", format, strings.Join(importFormatNames(), ", "))
+		}
+
+		return reportWrite(importOutput, "import", func() (*writeResult, error) {
+			items, err := readImportFile(path, read)
+			if err != nil {
+				return nil, err
+			}
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			result, err := w.ImportReminders(items, importList, importDryRun)
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			return importResult(result, importDryRun), nil
+		})
+	},
+}
+
+// readImportFile reads path ("-" for stdin) with read.
+func readImportFile(path string, read importer) ([]writer.ImportItem, error) {
+	if path == "-" {
+		return read(os.Stdin)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	items, err := read(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return items, nil
+}
+
+// icsItems reads the VTODOs of an iCalendar file.
+func icsItems(r io.Reader) ([]writer.ImportItem, error) {
+	todos, err := ical.Decode(r)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]writer.ImportItem, len(todos))
+	for i, t := range todos {
+		items[i] = writer.ImportItem{
+			UID:            t.UID,
+			Title:          t.Summary,
+			Notes:          t.Description,
+			Due:            t.Due,
+			Priority:       t.Priority,
+			Completed:      t.Completed,
+			CompletionDate: t.CompletedAt,
+			Parent:         t.ParentUID,
+			Recurrence:     t.Recurrence,
+		}
+	}
+	return items, nil
+}
+
+// taskItems adapts a plain-text task file reader. Parents in the file are
+// referred to by position, since tasks need not have IDs; with matchTitle,
+// tasks without an ID match existing reminders by title.
+func taskItems(decode func(io.Reader) ([]*plaintext.Task, error), matchTitle bool) importer {
+	return func(r io.Reader) ([]writer.ImportItem, error) {
+		tasks, err := decode(r)
+		if err != nil {
+			return nil, err
+		}
+		index := make(map[*plaintext.Task]int, len(tasks))
+		items := make([]writer.ImportItem, len(tasks))
+		for i, t := range tasks {
+			index[t] = i + 1
+			items[i] = writer.ImportItem{
+				UID:            t.ID,
+				MatchTitle:     matchTitle,
+				Title:          t.Title,
+				Notes:          t.Notes,
+				List:           t.List,
+				Due:            t.Due,
+				Priority:       t.Priority,
+				Completed:      t.Completed,
+				CompletionDate: t.CompletionDate,
+				Parent:         t.ParentID,
+				ParentIndex:    index[t.Parent],
+				ParentTitle:    t.ParentTitle,
+				Recurrence:     t.Recurrence,
+				Line:           t.Line,
+			}
+		}
+		return items, nil
+	}
+}
+
+// importFormatNames returns the registered import formats, sorted.
+func importFormatNames() []string {
+	names := make([]string, 0, len(importFormats))
+	for name := range importFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// importResult turns an import writer result into a write result. Skipped
+// items are only counted.
+func importResult(result map[string]interface{}, dryRun bool) *writeResult {
+	res := &writeResult{DryRun: dryRun}
+	entries, _ := result["results"].([]map[string]interface{})
+	for _, e := range entries {
+		status, _ := e["status"].(string)
+		id, _ := e["id"].(string)
+		title, _ := e["title"].(string)
+		switch status {
+		case writer.ImportCreated, writer.ImportCompleted:
+			res.Reminders = append(res.Reminders, changedEntries([]string{id})...)
+		case writer.ImportPlanned:
+			if r := syncEngine.GetReminder(id); r != nil {
+				res.Reminders = append(res.Reminders, &resultEntry{Reminder: r})
+				continue
+			}
+			res.Reminders = append(res.Reminders, &resultEntry{Reminder: &models.Reminder{ID: id, Title: title}})
+		case writer.ImportSkipped:
+			res.Skipped++
+		case writer.ImportFailed:
+			errMsg, _ := e["error"].(string)
+			res.Reminders = append(res.Reminders, &resultEntry{Reminder: &models.Reminder{Title: title}, Error: errMsg})
+		}
+	}
+
+	res.text = func() {
+		fmt.Println()
+		for _, e := range entries {
+			status, _ := e["status"].(string)
+			title, _ := e["title"].(string)
+			indent := ""
+			if _, ok := e["parent"]; ok {
+				indent = "  "
+			}
+			switch status {
+			case writer.ImportCreated:
+				fmt.Printf("  %s✅ %s  (%s)\n", indent, title, shortID(e["id"].(string)))
+			case writer.ImportCompleted:
+				fmt.Printf("  %s☑️  %s  (completed)\n", indent, title)
+			case writer.ImportPlanned:
+				if e["action"] == "complete" {
+					fmt.Printf("  %s✓ %s  (would complete)\n", indent, title)
+				} else {
+					fmt.Printf("  %s+ %s\n", indent, title)
+				}
+			case writer.ImportSkipped:
+				fmt.Printf("  %s= %s  (already imported)\n", indent, title)
+			case writer.ImportFailed:
+				if line, ok := e["line"].(int); ok {
+					title = fmt.Sprintf("%s (line %d)", title, line)
+				}
+				fmt.Printf("  %s❌ %s: %s\n", indent, title, e["error"])
+			}
+			if warning, ok := e["warning"].(string); ok {
+				fmt.Printf("  %s   ⚠️  %s\n", indent, warning)
+			}
+		}
+		created, _ := result["created"].(int)
+		completed, _ := result["completed"].(int)
+		if dryRun {
+			fmt.Printf("\nDry run — would import %d, complete %d, skip %d, fail %d; nothing changed.\n",
+				created, completed, res.Skipped, res.Failed)
+			return
+		}
+		fmt.Printf("\nImported %d, completed %d, skipped %d, failed %d\n", created, completed, res.Skipped, res.Failed)
+	}
+	return res
+}
+
+func init() {
+	importCmd.Flags().StringVarP(&importList, "list", "l", "", "List for items the file does not assign to one")
+	importCmd.Flags().StringVarP(&importFormat, "format", "f", "", "Input format ("+strings.Join(importFormatNames(), ", ")+"); default from the file extension")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Only show what would be imported")
+	addResultFlag(importCmd, &importOutput)
+}
@@ -3,23 +3,48 @@ package cmd
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/spf13/cobra"
 
+	"icloud-reminders/internal/filter"
 	"icloud-reminders/pkg/models"
 )
 
+var (
+	jsonWhere  string
+	jsonOutput string
+)
+
 var jsonCmd = &cobra.Command{
 	Use:   "json",
 	Short: "Output reminders as JSON",
+	Long: `Output all lists and reminders, completed ones included, as one JSON
+document with "lists", "active" and "completed".
+
+--where narrows the reminders with a filter expression; see 'reminders help
+filters'. --output picks another format for the reminders alone, as for
+'reminders list'.
+
+Examples:
+  reminders json
+  reminders json --where 'repeating'
+  reminders json --where 'list=Work' -o csv`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFormat(jsonOutput); err != nil {
+			return err
+		}
+		where, err := filter.Parse(jsonWhere)
+		if err != nil {
+			return err
+		}
 		if err := syncEngine.Sync(false); err != nil {
 			return err
 		}
-		reminders := syncEngine.GetReminders(true)
+		reminders := where.Select(syncEngine.GetReminders(true))
 		lists := syncEngine.GetLists()
 
-		type output struct {
+		type document struct {
 			Lists     []*models.ReminderList `json:"lists"`
 			Active    []*models.Reminder     `json:"active"`
 			Completed []*models.Reminder     `json:"completed"`
@@ -33,16 +58,26 @@ var jsonCmd = &cobra.Command{
 			}
 		}
 
-		out := output{
-			Lists:     lists,
-			Active:    active,
-			Completed: completed,
-		}
-		data, err := json.MarshalIndent(out, "", "  ")
-		if err != nil {
-			return err
-		}
-		fmt.Println(string(data))
-		return nil
+		return render(jsonOutput, &output{
+			items:   reminderItems(append(append([]*models.Reminder{}, active...), completed...)),
+			columns: reminderColumns(asReminder),
+			text: func(w io.Writer) error {
+				data, err := json.MarshalIndent(document{
+					Lists:     lists,
+					Active:    active,
+					Completed: completed,
+				}, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintln(w, string(data))
+				return err
+			},
+		})
 	},
 }
+
+func init() {
+	jsonCmd.Flags().StringVarP(&jsonWhere, "where", "w", "", "Only output reminders matching a filter expression (see 'reminders help filters')")
+	addOutputFlag(jsonCmd, &jsonOutput)
+}
@@ -0,0 +1,75 @@
+package cmd_test
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"icloud-reminders/internal/cloudkit/cktest"
+)
+
+func TestJSON(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	work := srv.AddList("Work")
+	home := srv.AddList("Home")
+	srv.AddReminder("Call Bob", work)
+	srv.AddReminder("Pay rent", home, map[string]interface{}{"Completed": map[string]interface{}{"value": 1}})
+	newCLI(t, srv)
+
+	// The default document keeps its shape.
+	out, err := run(t, "json")
+	if err != nil {
+		t.Fatalf("json: %v", err)
+	}
+	var doc struct {
+		Lists     []struct{ Name string }
+		Active    []listed
+		Completed []listed
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("json: %v\n%s", err, out)
+	}
+	if len(doc.Lists) != 2 || len(doc.Active) != 1 || len(doc.Completed) != 1 {
+		t.Fatalf("json = %+v", doc)
+	}
+	if doc.Active[0].Title != "Call Bob" || doc.Completed[0].Title != "Pay rent" {
+		t.Errorf("json active = %+v, completed = %+v", doc.Active, doc.Completed)
+	}
+
+	// Other formats go through the formatter registry.
+	out, err = run(t, "json", "-o", "csv")
+	if err != nil {
+		t.Fatalf("json -o csv: %v", err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("json -o csv: %v\n%s", err, out)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("json -o csv printed %d rows, want 3:\n%s", len(rows), out)
+	}
+	if got := strings.Join(rows[0], ","); got != "id,title,list,due,priority,completed,repeat,parent,notes" {
+		t.Errorf("csv header = %s", got)
+	}
+	for i, want := range [][3]string{{"Call Bob", "Work", "false"}, {"Pay rent", "Home", "true"}} {
+		row := rows[i+1]
+		if row[1] != want[0] || row[2] != want[1] || row[5] != want[2] {
+			t.Errorf("csv row %d = %v, want title, list, completed %v", i+1, row, want)
+		}
+	}
+
+	out, err = run(t, "json", "-w", "list=Work", "-o", "ndjson")
+	if err != nil {
+		t.Fatalf("json -o ndjson: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 1 || !strings.Contains(lines[0], `"title":"Call Bob"`) {
+		t.Errorf("json -w list=Work -o ndjson = %q", out)
+	}
+
+	if _, err := run(t, "json", "-o", "xml"); err == nil || !strings.Contains(err.Error(), `unknown output format "xml"`) {
+		t.Errorf("json -o xml: err = %v", err)
+	}
+}
@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"sort"
 
 	"github.com/spf13/cobra"
 
+	"icloud-reminders/internal/filter"
+	"icloud-reminders/internal/utils"
 	"icloud-reminders/pkg/models"
 )
 
@@ -13,16 +16,33 @@ var (
 	listFilter       string
 	listParentFilter string
 	listAll          bool
+	listWhere        string
+	listOutput       string
 )
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List reminders",
+	Long: `List reminders grouped by list, with subtasks indented under their parent.
+
+--where narrows the output with a filter expression; see 'reminders help filters'.
+
+Examples:
+  reminders list -l Work
+  reminders list --where 'list=Work and due<=+3d and priority>=medium'
+  reminders list --where 'completed and due>=-7d'`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFormat(listOutput); err != nil {
+			return err
+		}
+		where, err := filter.Parse(listWhere)
+		if err != nil {
+			return err
+		}
 		if err := syncEngine.Sync(false); err != nil {
 			return err
 		}
-		reminders := syncEngine.GetReminders(listAll)
+		reminders := where.Select(syncEngine.GetReminders(listAll || where.Uses("completed")))
 
 		// --parent: show only children of a named parent reminder
 		if listParentFilter != "" {
@@ -32,56 +52,76 @@ var listCmd = &cobra.Command{
 		// Build lookup maps
 		byList := make(map[string][]*models.Reminder)
 		childrenByParent := make(map[string][]*models.Reminder)
+		// With --where, subtasks whose parent was filtered out are shown at top level.
+		shown := make(map[string]bool)
+		for _, r := range reminders {
+			shown[r.ID] = true
+		}
 
 		for _, r := range reminders {
 			if listFilter != "" && toLowerStr(r.ListName) != toLowerStr(listFilter) {
 				continue
 			}
-			if r.ParentRef != nil && *r.ParentRef != "" {
+			if r.ParentRef != nil && *r.ParentRef != "" && (where == nil || shown[*r.ParentRef]) {
 				childrenByParent[*r.ParentRef] = append(childrenByParent[*r.ParentRef], r)
 			} else {
 				byList[r.ListName] = append(byList[r.ListName], r)
 			}
 		}
 
-		active := 0
-		for _, r := range reminders {
-			if !r.Completed {
-				active++
-			}
-		}
-		fmt.Printf("\n✅ Reminders: %d (%d active)\n", len(reminders), active)
-
 		listNames := make([]string, 0, len(byList))
 		for name := range byList {
 			listNames = append(listNames, name)
 		}
 		sort.Strings(listNames)
+		for _, items := range byList {
+			sortByModified(items)
+		}
+		for _, children := range childrenByParent {
+			sortByModified(children)
+		}
 
+		// Machine-readable formats get the reminders flattened in display order.
+		var ordered []*models.Reminder
+		var walk func(r *models.Reminder)
+		walk = func(r *models.Reminder) {
+			ordered = append(ordered, r)
+			for _, child := range childrenByParent[r.ID] {
+				walk(child)
+			}
+		}
 		for _, listName := range listNames {
-			items := byList[listName]
-			total := len(items)
-			for _, r := range items {
-				total += len(childrenByParent[r.ID])
+			for _, r := range byList[listName] {
+				walk(r)
 			}
-			fmt.Printf("\n📋 %s (%d)\n", listName, total)
+		}
 
-			sort.Slice(items, func(i, j int) bool {
-				ti, tj := int64(0), int64(0)
-				if items[i].ModifiedTS != nil {
-					ti = *items[i].ModifiedTS
+		return render(listOutput, &output{
+			items:   reminderItems(ordered),
+			columns: reminderColumns(asReminder),
+			text: func(w io.Writer) error {
+				active := 0
+				for _, r := range reminders {
+					if !r.Completed {
+						active++
+					}
 				}
-				if items[j].ModifiedTS != nil {
-					tj = *items[j].ModifiedTS
+				fmt.Fprintf(w, "\n✅ Reminders: %d (%d active)\n", len(reminders), active)
+
+				for _, listName := range listNames {
+					items := byList[listName]
+					total := len(items)
+					for _, r := range items {
+						total += len(childrenByParent[r.ID])
+					}
+					fmt.Fprintf(w, "\n📋 %s (%d)\n", listName, total)
+					for _, r := range items {
+						printReminder(w, r, 2, childrenByParent)
+					}
 				}
-				return ti > tj
-			})
-
-			for _, r := range items {
-				printReminder(r, 2, childrenByParent)
-			}
-		}
-		return nil
+				return nil
+			},
+		})
 	},
 }
 
@@ -121,68 +161,61 @@ func runListByParent(reminders []*models.Reminder, parentFilter string) error {
 			children = append(children, r)
 		}
 	}
-
-	sort.Slice(children, func(i, j int) bool {
-		ti, tj := int64(0), int64(0)
-		if children[i].ModifiedTS != nil {
-			ti = *children[i].ModifiedTS
-		}
-		if children[j].ModifiedTS != nil {
-			tj = *children[j].ModifiedTS
-		}
-		return ti > tj
+	sortByModified(children)
+
+	return render(listOutput, &output{
+		items:   reminderItems(children),
+		columns: reminderColumns(asReminder),
+		text: func(w io.Writer) error {
+			fmt.Fprintf(w, "\n📋 %s (%d items)\n", parentTitle, len(children))
+			for _, r := range children {
+				fmt.Fprintf(w, "  %s\n", reminderLine(r))
+			}
+			return nil
+		},
 	})
-
-	fmt.Printf("\n📋 %s (%d items)\n", parentTitle, len(children))
-	for _, r := range children {
-		status := "•"
-		if r.Completed {
-			status = "✓"
-		}
-		due := ""
-		if r.Due != nil && *r.Due != "" {
-			due = fmt.Sprintf("  [due %s]", *r.Due)
-		}
-		prio := ""
-		if r.PriorityLabel() != "" {
-			prio = fmt.Sprintf("  [%s]", r.PriorityLabel())
-		}
-		fmt.Printf("  %s %s%s%s  (%s)\n", status, r.Title, due, prio, r.ShortID())
-	}
-	return nil
 }
 
-func printReminder(r *models.Reminder, indent int, childrenByParent map[string][]*models.Reminder) {
-	prefix := spaces(indent)
+// reminderLine formats a reminder as "• Title  [due …]  [high]  (ID)".
+func reminderLine(r *models.Reminder) string {
 	status := "•"
 	if r.Completed {
 		status = "✓"
 	}
 	due := ""
 	if r.Due != nil && *r.Due != "" {
-		due = fmt.Sprintf("  [due %s]", *r.Due)
+		due = fmt.Sprintf("  [due %s]", utils.DisplayDue(*r.Due))
 	}
 	prio := ""
 	if r.PriorityLabel() != "" {
 		prio = fmt.Sprintf("  [%s]", r.PriorityLabel())
 	}
-	fmt.Printf("%s%s %s%s%s  (%s)\n", prefix, status, r.Title, due, prio, r.ShortID())
+	if r.Recurrence != nil {
+		prio += fmt.Sprintf("  [🔁 %s]", r.Recurrence)
+	}
+	return fmt.Sprintf("%s %s%s%s  (%s)", status, r.Title, due, prio, r.ShortID())
+}
 
-	// Print children recursively
-	children := childrenByParent[r.ID]
-	sort.Slice(children, func(i, j int) bool {
+// printReminder prints r and, indented below it, its subtasks.
+func printReminder(w io.Writer, r *models.Reminder, indent int, childrenByParent map[string][]*models.Reminder) {
+	fmt.Fprintf(w, "%s%s\n", spaces(indent), reminderLine(r))
+	for _, child := range childrenByParent[r.ID] {
+		printReminder(w, child, indent+2, childrenByParent)
+	}
+}
+
+// sortByModified sorts reminders most recently modified first.
+func sortByModified(items []*models.Reminder) {
+	sort.Slice(items, func(i, j int) bool {
 		ti, tj := int64(0), int64(0)
-		if children[i].ModifiedTS != nil {
-			ti = *children[i].ModifiedTS
+		if items[i].ModifiedTS != nil {
+			ti = *items[i].ModifiedTS
 		}
-		if children[j].ModifiedTS != nil {
-			tj = *children[j].ModifiedTS
+		if items[j].ModifiedTS != nil {
+			tj = *items[j].ModifiedTS
 		}
 		return ti > tj
 	})
-	for _, child := range children {
-		printReminder(child, indent+2, childrenByParent)
-	}
 }
 
 func spaces(n int) string {
@@ -209,4 +242,6 @@ func init() {
 	listCmd.Flags().StringVarP(&listFilter, "list", "l", "", "Filter by list name")
 	listCmd.Flags().StringVar(&listParentFilter, "parent", "", "Show only children of this parent reminder (name or ID)")
 	listCmd.Flags().BoolVarP(&listAll, "all", "a", false, "Include completed reminders")
+	listCmd.Flags().StringVarP(&listWhere, "where", "w", "", "Filter expression, e.g. 'due<=+3d and priority>=medium' (see 'reminders help filters')")
+	addOutputFlag(listCmd, &listOutput)
 }
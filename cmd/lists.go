@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"sort"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -13,6 +15,9 @@ var listsCmd = &cobra.Command{
 	Use:   "lists",
 	Short: "Show all reminder lists",
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkFormat(listsOutput); err != nil {
+			return err
+		}
 		if err := syncEngine.Sync(false); err != nil {
 			return err
 		}
@@ -22,12 +27,202 @@ var listsCmd = &cobra.Command{
 			return lists[i].Name < lists[j].Name
 		})
 
-		fmt.Printf("\n📋 Lists (%d)\n", len(lists))
-		for _, lst := range lists {
-			count := activeCountForList(lst)
-			fmt.Printf("  • %s (%d active)  [%s]\n", lst.Name, count, shortID(lst.ID))
+		items := make([]interface{}, len(lists))
+		for i, lst := range lists {
+			items[i] = &listInfo{ReminderList: lst, Active: activeCountForList(lst)}
+		}
+		get := func(item interface{}) *listInfo { return item.(*listInfo) }
+		return render(listsOutput, &output{
+			items: items,
+			columns: []column{
+				{"id", func(item interface{}) string { return shortID(get(item).ID) }},
+				{"name", func(item interface{}) string { return get(item).Name }},
+				{"color", func(item interface{}) string { return get(item).Color }},
+				{"active", func(item interface{}) string { return strconv.Itoa(get(item).Active) }},
+			},
+			text: func(w io.Writer) error {
+				fmt.Fprintf(w, "\n📋 Lists (%d)\n", len(lists))
+				for _, item := range items {
+					lst := get(item)
+					color := ""
+					if lst.Color != "" {
+						color = fmt.Sprintf("  (%s)", lst.Color)
+					}
+					fmt.Fprintf(w, "  • %s (%d active)%s  [%s]\n", lst.Name, lst.Active, color, shortID(lst.ID))
+				}
+				return nil
+			},
+		})
+	},
+}
+
+// listInfo is a list with its number of active reminders.
+type listInfo struct {
+	*models.ReminderList
+	Active int `json:"active"`
+}
+
+var listsOutput string
+
+var (
+	listsCreateColor  string
+	listsCreateOutput string
+	listsRenameOutput string
+	listsColorOutput  string
+)
+
+var listsCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create a new list",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkResultFormat(listsCreateOutput); err != nil {
+			return err
+		}
+		return reportWrite(listsCreateOutput, "lists-create", func() (*writeResult, error) {
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			result, err := w.CreateList(args[0], listsCreateColor)
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			id, _ := result["list_id"].(string)
+			return &writeResult{
+				List: cachedList(id),
+				text: func() { fmt.Printf("✅ Created list: '%s'  [%s]\n", args[0], shortID(id)) },
+			}, nil
+		})
+	},
+}
+
+var listsRenameCmd = &cobra.Command{
+	Use:   "rename <id|name> <new-name>",
+	Short: "Rename a list",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkResultFormat(listsRenameOutput); err != nil {
+			return err
 		}
-		return nil
+		return reportWrite(listsRenameOutput, "lists-rename", func() (*writeResult, error) {
+			lst, err := updateList(args[0], args[1], "")
+			if err != nil {
+				return nil, err
+			}
+			return &writeResult{
+				List: lst,
+				text: func() { fmt.Printf("✅ Renamed list: '%s' → '%s'\n", args[0], args[1]) },
+			}, nil
+		})
+	},
+}
+
+var listsColorCmd = &cobra.Command{
+	Use:   "color <id|name> <color>",
+	Short: "Set a list's color (red, orange, yellow, green, blue, purple, brown or #RRGGBB)",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkResultFormat(listsColorOutput); err != nil {
+			return err
+		}
+		return reportWrite(listsColorOutput, "lists-color", func() (*writeResult, error) {
+			lst, err := updateList(args[0], "", args[1])
+			if err != nil {
+				return nil, err
+			}
+			return &writeResult{
+				List: lst,
+				text: func() { fmt.Printf("✅ Recolored list: '%s' → %s\n", args[0], args[1]) },
+			}, nil
+		})
+	},
+}
+
+// updateList renames or recolors a list and returns it as it is now.
+func updateList(nameOrID, newName, color string) (*models.ReminderList, error) {
+	if err := syncEngine.Sync(false); err != nil {
+		return nil, err
+	}
+	id := syncEngine.FindList(nameOrID)
+	result, err := w.UpdateList(nameOrID, newName, color)
+	if err != nil {
+		return nil, err
+	}
+	if errMsg, ok := result["error"].(string); ok {
+		return nil, fmt.Errorf("%s", errMsg)
+	}
+	return cachedList(id), nil
+}
+
+// cachedList returns the cached list with the given record name.
+func cachedList(id string) *models.ReminderList {
+	return &models.ReminderList{ID: id, Name: syncEngine.Cache.Lists[id], Color: syncEngine.Cache.ListColors[id]}
+}
+
+var (
+	listsDeleteMoveTo string
+	listsDeleteForce  bool
+	listsDeleteOutput string
+)
+
+var listsDeleteCmd = &cobra.Command{
+	Use:   "delete <id|name>",
+	Short: "Delete a list",
+	Long: `Delete a list.
+
+Reminders in the list are moved to --move-to when given. Without it, a
+non-empty list is only deleted with --force, which deletes its reminders too.
+
+Examples:
+  reminders lists delete "Old Project" --move-to Inbox
+  reminders lists delete "Scratch" --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkResultFormat(listsDeleteOutput); err != nil {
+			return err
+		}
+		return reportWrite(listsDeleteOutput, "lists-delete", func() (*writeResult, error) {
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			lst := cachedList(syncEngine.FindList(args[0]))
+			before := make(map[string]*models.Reminder)
+			for _, r := range syncEngine.GetReminders(true) {
+				if r.ListRef != nil && *r.ListRef == lst.ID {
+					before[r.ID] = r
+				}
+			}
+			result, err := w.DeleteList(args[0], listsDeleteMoveTo, listsDeleteForce)
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			ids := resultIDs(result, "reminder_ids")
+			if n, ok := result["moved_count"].(int); ok {
+				return &writeResult{
+					List:      lst,
+					Reminders: changedEntries(ids),
+					text: func() {
+						fmt.Printf("✅ Deleted list: '%s' (%d reminders moved to '%s')\n", args[0], n, listsDeleteMoveTo)
+					},
+				}, nil
+			}
+			entries := make([]*resultEntry, 0, len(ids))
+			for _, id := range ids {
+				entries = append(entries, &resultEntry{Reminder: before[id], OK: true})
+			}
+			n, _ := result["deleted_count"].(int)
+			return &writeResult{
+				List:      lst,
+				Reminders: entries,
+				text:      func() { fmt.Printf("✅ Deleted list: '%s' (%d reminders deleted)\n", args[0], n) },
+			}, nil
+		})
 	},
 }
 
@@ -50,3 +245,17 @@ func shortID(id string) string {
 	}
 	return id
 }
+
+func init() {
+	listsCreateCmd.Flags().StringVar(&listsCreateColor, "color", "", "List color (red, orange, yellow, green, blue, purple, brown or #RRGGBB)")
+	listsDeleteCmd.Flags().StringVar(&listsDeleteMoveTo, "move-to", "", "Move the list's reminders to this list")
+	listsDeleteCmd.Flags().BoolVar(&listsDeleteForce, "force", false, "Delete the list's reminders along with it")
+	addResultFlag(listsCreateCmd, &listsCreateOutput)
+	addResultFlag(listsRenameCmd, &listsRenameOutput)
+	addResultFlag(listsColorCmd, &listsColorOutput)
+	addResultFlag(listsDeleteCmd, &listsDeleteOutput)
+
+	addOutputFlag(listsCmd, &listsOutput)
+
+	listsCmd.AddCommand(listsCreateCmd, listsRenameCmd, listsColorCmd, listsDeleteCmd)
+}
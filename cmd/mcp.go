@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"icloud-reminders/internal/mcp"
+)
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Serve reminders to AI agents over MCP (stdio)",
+	Long: `Speak the Model Context Protocol on stdin and stdout, for AI agents and
+the apps that host them. Each message is one line of JSON-RPC 2.0.
+
+Tools: lists, list, search, add, add-batch, complete, edit, delete. Their
+input and output schemas describe reminders as in --output json; failures
+come back as tool results with isError set and "ok": false.
+
+Run 'reminders auth' first: the server cannot ask for a password or 2FA
+code on stdin.
+
+Example client configuration:
+  {"mcpServers": {"reminders": {"command": "reminders", "args": ["mcp"]}}}`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return mcp.New(syncEngine, w, version).Serve(os.Stdin, os.Stdout)
+	},
+}
@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	moveListName string
+	moveOutput   string
+)
+
+var moveCmd = &cobra.Command{
+	Use:   "move <id>",
+	Short: "Move a reminder and its subtasks to another list",
+	Long: `Move a reminder, together with all of its subtasks, to another list.
+
+A subtask moved on its own becomes a top-level reminder in the target list.
+
+Examples:
+  reminders move ABC123 --list "Work"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkResultFormat(moveOutput); err != nil {
+			return err
+		}
+		return reportWrite(moveOutput, "move", func() (*writeResult, error) {
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			result, err := w.MoveReminder(args[0], moveListName)
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			subtasks := ""
+			if n, ok := result["moved_count"].(int); ok && n > 1 {
+				subtasks = fmt.Sprintf(" (with %d subtasks)", n-1)
+			}
+			return &writeResult{
+				Reminders: changedEntries(resultIDs(result, "reminder_ids")),
+				text:      func() { fmt.Printf("✅ Moved: %s → %s%s\n", args[0], moveListName, subtasks) },
+			}, nil
+		})
+	},
+}
+
+func init() {
+	moveCmd.Flags().StringVarP(&moveListName, "list", "l", "", "Target list name (required)")
+	addResultFlag(moveCmd, &moveOutput)
+	_ = moveCmd.MarkFlagRequired("list")
+}
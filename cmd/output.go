@@ -0,0 +1,388 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// output is what a read command hands to a formatter: the records it found,
+// the columns used by tabular formats and its human-readable rendering.
+type output struct {
+	items   []interface{}
+	columns []column
+	text    func(w io.Writer) error
+}
+
+// column is one field of a tabular format (csv, tsv, markdown).
+type column struct {
+	name  string
+	value func(item interface{}) string
+}
+
+// formatter renders an output to w.
+type formatter func(w io.Writer, out *output) error
+
+// formatters is the registry behind --output. Structured formats (json,
+// ndjson, yaml) serialize the items; tabular ones use the columns.
+var formatters = map[string]formatter{
+	"text":     func(w io.Writer, out *output) error { return out.text(w) },
+	"json":     writeJSONOutput,
+	"ndjson":   writeNDJSON,
+	"csv":      func(w io.Writer, out *output) error { return writeDelimited(w, out, ',') },
+	"tsv":      writeTSV,
+	"markdown": writeMarkdown,
+	"yaml":     writeYAML,
+}
+
+// formatNames returns the registered format names, sorted.
+func formatNames() []string {
+	names := make([]string, 0, len(formatters))
+	for name := range formatters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// addOutputFlag registers --output/-o on a read command.
+func addOutputFlag(cmd *cobra.Command, target *string) {
+	cmd.Flags().StringVarP(target, "output", "o", "text", "Output format ("+strings.Join(formatNames(), ", ")+")")
+}
+
+// checkFormat validates an --output value before any work is done.
+func checkFormat(format string) error {
+	if _, ok := formatters[format]; !ok {
+		return fmt.Errorf("unknown output format %q (use: %s)", format, strings.Join(formatNames(), ", "))
+	}
+	return nil
+}
+
+// render writes out to stdout in format.
+func render(format string, out *output) error {
+	if err := checkFormat(format); err != nil {
+		return err
+	}
+	return formatters[format](os.Stdout, out)
+}
+
+func writeJSONOutput(w io.Writer, out *output) error {
+	items := out.items
+	if items == nil {
+		items = []interface{}{}
+	}
+	data, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+func writeNDJSON(w io.Writer, out *output) error {
+	enc := json.NewEncoder(w)
+	for _, item := range out.items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeDelimited(w io.Writer, out *output, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	header := make([]string, len(out.columns))
+	for i, c := range out.columns {
+		header[i] = c.name
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, item := range out.items {
+		row := make([]string, len(out.columns))
+		for i, c := range out.columns {
+			row[i] = c.value(item)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTSV writes tab-separated values without quoting; tabs and line
+// breaks inside values are replaced by spaces.
+func writeTSV(w io.Writer, out *output) error {
+	clean := strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
+	writeRow := func(cells []string) error {
+		for i := range cells {
+			cells[i] = clean.Replace(cells[i])
+		}
+		_, err := fmt.Fprintln(w, strings.Join(cells, "\t"))
+		return err
+	}
+	header := make([]string, len(out.columns))
+	for i, c := range out.columns {
+		header[i] = c.name
+	}
+	if err := writeRow(header); err != nil {
+		return err
+	}
+	for _, item := range out.items {
+		row := make([]string, len(out.columns))
+		for i, c := range out.columns {
+			row[i] = c.value(item)
+		}
+		if err := writeRow(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeMarkdown(w io.Writer, out *output) error {
+	clean := strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>")
+	var b strings.Builder
+	b.WriteString("|")
+	for _, c := range out.columns {
+		b.WriteString(" " + c.name + " |")
+	}
+	b.WriteString("\n|")
+	for range out.columns {
+		b.WriteString(" --- |")
+	}
+	b.WriteString("\n")
+	for _, item := range out.items {
+		b.WriteString("|")
+		for _, c := range out.columns {
+			b.WriteString(" " + clean.Replace(c.value(item)) + " |")
+		}
+		b.WriteString("\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeYAML writes the items as a YAML sequence. Values go through their
+// JSON encoding, so field names and order match the json format.
+func writeYAML(w io.Writer, out *output) error {
+	items := out.items
+	if items == nil {
+		items = []interface{}{}
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	writeYAMLValue(&b, v, 0, false)
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+// yamlMap is a JSON object with its key order preserved.
+type yamlMap struct {
+	keys   []string
+	values []interface{}
+}
+
+// decodeOrdered decodes the next JSON value, keeping object key order.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			m := &yamlMap{}
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				v, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				m.keys = append(m.keys, keyTok.(string))
+				m.values = append(m.values, v)
+			}
+			_, err := dec.Token() // '}'
+			return m, err
+		case '[':
+			list := []interface{}{}
+			for dec.More() {
+				v, err := decodeOrdered(dec)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, v)
+			}
+			_, err := dec.Token() // ']'
+			return list, err
+		}
+	}
+	return tok, nil
+}
+
+// writeYAMLValue writes v at the given indent. inline is set when v follows
+// a "- " or "key:" on the same line.
+func writeYAMLValue(b *strings.Builder, v interface{}, indent int, inline bool) {
+	pad := strings.Repeat("  ", indent)
+	switch t := v.(type) {
+	case *yamlMap:
+		if len(t.keys) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		for i, k := range t.keys {
+			if i > 0 || !inline {
+				b.WriteString(pad)
+			}
+			b.WriteString(yamlKey(k) + ":")
+			writeYAMLChild(b, t.values[i], indent+1)
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			if inline {
+				b.WriteString(" []\n")
+			} else {
+				b.WriteString(pad + "[]\n")
+			}
+			return
+		}
+		for i, item := range t {
+			if i > 0 || !inline {
+				b.WriteString(pad)
+			}
+			b.WriteString("-")
+			if m, ok := item.(*yamlMap); ok && len(m.keys) > 0 {
+				b.WriteString(" ")
+				writeYAMLValue(b, m, indent+1, true)
+				continue
+			}
+			writeYAMLChild(b, item, indent+1)
+		}
+	default:
+		b.WriteString(pad + yamlScalar(v) + "\n")
+	}
+}
+
+// writeYAMLChild writes the value after "key:" or "-".
+func writeYAMLChild(b *strings.Builder, v interface{}, indent int) {
+	switch t := v.(type) {
+	case *yamlMap:
+		if len(t.keys) == 0 {
+			b.WriteString(" {}\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAMLValue(b, t, indent, false)
+	case []interface{}:
+		if len(t) == 0 {
+			b.WriteString(" []\n")
+			return
+		}
+		b.WriteString("\n")
+		writeYAMLValue(b, t, indent, false)
+	default:
+		b.WriteString(" " + yamlScalar(v) + "\n")
+	}
+}
+
+func yamlScalar(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(t)
+	case json.Number:
+		return t.String()
+	case string:
+		// JSON string syntax is a valid YAML double-quoted scalar.
+		data, _ := json.Marshal(t)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+func yamlKey(k string) string {
+	for i, r := range k {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return yamlScalar(k)
+		}
+	}
+	if k == "" {
+		return `""`
+	}
+	return k
+}
+
+// reminderColumns are the tabular columns for reminders; get extracts the
+// reminder from an output item.
+func reminderColumns(get func(item interface{}) *models.Reminder) []column {
+	str := func(f func(r *models.Reminder) string) func(interface{}) string {
+		return func(item interface{}) string { return f(get(item)) }
+	}
+	return []column{
+		{"id", str(func(r *models.Reminder) string { return r.ShortID() })},
+		{"title", str(func(r *models.Reminder) string { return r.Title })},
+		{"list", str(func(r *models.Reminder) string { return r.ListName })},
+		{"due", str(func(r *models.Reminder) string {
+			if r.Due == nil {
+				return ""
+			}
+			return utils.DisplayDue(*r.Due)
+		})},
+		{"priority", str(func(r *models.Reminder) string { return r.PriorityLabel() })},
+		{"completed", str(func(r *models.Reminder) string { return strconv.FormatBool(r.Completed) })},
+		{"repeat", str(func(r *models.Reminder) string { return r.Recurrence.String() })},
+		{"parent", str(func(r *models.Reminder) string {
+			if r.ParentRef == nil {
+				return ""
+			}
+			return shortID(*r.ParentRef)
+		})},
+		{"notes", str(func(r *models.Reminder) string {
+			if r.Notes == nil {
+				return ""
+			}
+			return *r.Notes
+		})},
+	}
+}
+
+// reminderItems wraps reminders as output items.
+func reminderItems(reminders []*models.Reminder) []interface{} {
+	items := make([]interface{}, len(reminders))
+	for i, r := range reminders {
+		items[i] = r
+	}
+	return items
+}
+
+// asReminder is the reminderColumns getter for items that are reminders.
+func asReminder(item interface{}) *models.Reminder {
+	return item.(*models.Reminder)
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	reopenRecursive bool
+	reopenOutput    string
+)
+
+var reopenCmd = &cobra.Command{
+	Use:   "reopen <id>",
+	Short: "Mark a completed reminder as not completed",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := checkResultFormat(reopenOutput); err != nil {
+			return err
+		}
+		return reportWrite(reopenOutput, "reopen", func() (*writeResult, error) {
+			if err := syncEngine.Sync(false); err != nil {
+				return nil, err
+			}
+			result, err := w.ReopenReminder(args[0], reopenRecursive)
+			if err != nil {
+				return nil, err
+			}
+			if errMsg, ok := result["error"].(string); ok {
+				return nil, fmt.Errorf("%s", errMsg)
+			}
+			subtasks := ""
+			if n, ok := result["reopened_count"].(int); ok && n > 1 {
+				subtasks = fmt.Sprintf(" (%d reminders)", n)
+			}
+			return &writeResult{
+				Reminders: changedEntries(resultIDs(result, "reminder_ids")),
+				text:      func() { fmt.Printf("↩️  Reopened: %s%s\n", args[0], subtasks) },
+			}, nil
+		})
+	},
+}
+
+func init() {
+	reopenCmd.Flags().BoolVarP(&reopenRecursive, "recursive", "r", false, "Also reopen completed subtasks")
+	addResultFlag(reopenCmd, &reopenOutput)
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"icloud-reminders/pkg/models"
+)
+
+// Exit codes. Any failure exits non-zero; a bulk command that changed some
+// reminders but not all exits with exitPartial so scripts can tell the two
+// apart.
+const (
+	exitFailure = 1
+	exitPartial = 2
+)
+
+// exitError is an error with a specific process exit code.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the process exit code for an error returned by
+// RootCmd.Execute: 0 for nil, exitPartial for a partially applied bulk
+// change and exitFailure otherwise.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	return exitFailure
+}
+
+// addResultFlag registers --output/-o on a write command.
+func addResultFlag(cmd *cobra.Command, target *string) {
+	cmd.Flags().StringVarP(target, "output", "o", "text", "Output format (text, json)")
+}
+
+// checkResultFormat validates a write command's --output value.
+func checkResultFormat(format string) error {
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown output format %q (use: text, json)", format)
+	}
+	return nil
+}
+
+// writeResult is what a write command reports. With --output json it is
+// printed as a single JSON object; otherwise text prints the usual messages.
+type writeResult struct {
+	OK        bool                 `json:"ok"`
+	Action    string               `json:"action"`
+	DryRun    bool                 `json:"dry_run,omitempty"`
+	Error     string               `json:"error,omitempty"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Skipped   int                  `json:"skipped,omitempty"` // import: already imported
+	List      *models.ReminderList `json:"list,omitempty"`    // lists subcommands; as it was, for delete
+	Reminders []*resultEntry       `json:"reminders"`
+
+	text func()
+}
+
+// resultEntry is one reminder a write command touched: its full record as
+// it is after the change (before it, for deletions) and whether the change
+// was applied.
+type resultEntry struct {
+	*models.Reminder
+	OK      bool   `json:"ok"`
+	Error   string `json:"error,omitempty"`
+	NextDue string `json:"next_due,omitempty"`
+}
+
+// reportWrite runs a write command and reports its result in format. In
+// json mode a failure is reported as a result document too, so stdout always
+// holds exactly one JSON object.
+func reportWrite(format, action string, run func() (*writeResult, error)) error {
+	res, err := run()
+	if err != nil {
+		if format == "json" {
+			_ = printResultJSON(&writeResult{Action: action, Error: err.Error(), Reminders: []*resultEntry{}})
+		}
+		return err
+	}
+
+	res.Action = action
+	if res.Reminders == nil {
+		res.Reminders = []*resultEntry{}
+	}
+	for _, e := range res.Reminders {
+		if e.OK {
+			res.Succeeded++
+		} else if e.Error != "" {
+			res.Failed++
+		}
+	}
+	res.OK = res.Failed == 0
+
+	if format == "json" {
+		if err := printResultJSON(res); err != nil {
+			return err
+		}
+	} else if res.text != nil {
+		res.text()
+	}
+
+	if res.Failed == 0 {
+		return nil
+	}
+	err = fmt.Errorf("%d of %d reminder(s) failed", res.Failed, res.Succeeded+res.Failed)
+	if res.Succeeded > 0 {
+		return &exitError{code: exitPartial, err: err}
+	}
+	return err
+}
+
+func printResultJSON(res *writeResult) error {
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(os.Stdout, string(data))
+	return err
+}
+
+// changedEntries returns successful result entries for the reminders with
+// the given record names, as they are now in the cache.
+func changedEntries(ids []string) []*resultEntry {
+	entries := make([]*resultEntry, 0, len(ids))
+	for _, id := range ids {
+		r := syncEngine.GetReminder(id)
+		if r == nil {
+			r = &models.Reminder{ID: id}
+		}
+		entries = append(entries, &resultEntry{Reminder: r, OK: true})
+	}
+	return entries
+}
+
+// resultIDs returns the record names a writer result lists under key
+// ("reminder_id" or "reminder_ids").
+func resultIDs(result map[string]interface{}, key string) []string {
+	switch v := result[key].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	}
+	return nil
+}
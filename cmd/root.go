@@ -3,6 +3,7 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 
@@ -11,12 +12,16 @@ import (
 	"icloud-reminders/internal/cloudkit"
 	"icloud-reminders/internal/logger"
 	"icloud-reminders/internal/sync"
+	"icloud-reminders/internal/utils"
 	"icloud-reminders/internal/writer"
 )
 
 // verbosity is incremented once per -v flag: -v=1 (info), -vv=2 (debug).
 var verbosity int
 
+// timeZone is the IANA zone for timed due dates (--tz, else $REMINDERS_TZ).
+var timeZone string
+
 // shared per-invocation state (set in PersistentPreRunE)
 var (
 	ckClient   *cloudkit.Client
@@ -31,9 +36,17 @@ var RootCmd = &cobra.Command{
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		logger.SetLevel(verbosity)
 
+		tz := timeZone
+		if tz == "" {
+			tz = os.Getenv("REMINDERS_TZ")
+		}
+		if err := utils.SetLocation(tz); err != nil {
+			return err
+		}
+
 		// Commands that handle their own auth (or none)
 		switch cmd.Name() {
-		case "auth", "export-session", "import-session":
+		case "auth", "export-session", "import-session", "help", "hooks":
 			return nil
 		}
 
@@ -63,6 +76,7 @@ func loadSession(forceReauth bool) (*auth.SessionData, error) {
 func init() {
 	// CountP increments verbosity each time -v is passed: -v=1, -vv=2
 	RootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Verbosity: -v info, -vv debug")
+	RootCmd.PersistentFlags().StringVar(&timeZone, "tz", "", "Time zone for timed due dates (default $REMINDERS_TZ or system zone)")
 
 	RootCmd.AddCommand(
 		authCmd,
@@ -72,11 +86,21 @@ func init() {
 		addCmd,
 		addBatchCmd,
 		completeCmd,
+		reopenCmd,
 		deleteCmd,
 		editCmd,
+		moveCmd,
 		jsonCmd,
+		exportCmd,
+		importCmd,
+		serveCmd,
+		caldavCmd,
+		mcpCmd,
+		watchCmd,
+		hooksCmd,
 		syncCmd,
 		exportSessionCmd,
 		importSessionCmd,
+		filtersHelpCmd,
 	)
 }
@@ -0,0 +1,170 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"icloud-reminders/cmd"
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/cloudkit/cktest"
+	"icloud-reminders/internal/utils"
+)
+
+// newCLI points the session and cache files at a temporary directory and
+// writes a session for srv there, so commands run against the fake.
+func newCLI(t *testing.T, srv *cktest.Server) {
+	t.Helper()
+	dir := t.TempDir()
+	oldDir, oldCache, oldSession := cache.ConfigDir, cache.CacheFile, cache.SessionFile
+	oldLoc := utils.Location
+	cache.ConfigDir = dir
+	cache.CacheFile = filepath.Join(dir, "ck_cache.json")
+	cache.SessionFile = filepath.Join(dir, "session.json")
+	t.Cleanup(func() {
+		cache.ConfigDir, cache.CacheFile, cache.SessionFile = oldDir, oldCache, oldSession
+		utils.Location = oldLoc
+	})
+	t.Setenv("REMINDERS_TZ", "UTC")
+	if err := srv.WriteSessionFile(cache.SessionFile); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// run executes the CLI with args and returns what it printed to stdout.
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	old := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = old }()
+	// Flags are package variables, so reset them for the next run.
+	defer resetFlags(cmd.RootCmd)
+
+	cmd.RootCmd.SetArgs(args)
+	cmd.RootCmd.SetErr(io.Discard)
+	runErr := cmd.RootCmd.Execute()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), runErr
+}
+
+// resetFlags returns the flags of c and its subcommands to their defaults.
+func resetFlags(c *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace(nil)
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	c.Flags().VisitAll(reset)
+	c.PersistentFlags().VisitAll(reset)
+	for _, sub := range c.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// listed is a reminder as `list -o json` prints it.
+type listed struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	ListName  string `json:"list_name"`
+	Due       string `json:"due"`
+	Completed bool   `json:"completed"`
+}
+
+func listJSON(t *testing.T, args ...string) []listed {
+	t.Helper()
+	out, err := run(t, append([]string{"list", "-o", "json"}, args...)...)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var got []listed
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("list -o json: %v\n%s", err, out)
+	}
+	return got
+}
+
+func TestAddListComplete(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	work := srv.AddList("Work")
+	srv.AddReminder("Existing", work)
+	newCLI(t, srv)
+
+	out, err := run(t, "add", "Call Bob", "-l", "Work", "--due", "2026-10-20")
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if !strings.Contains(out, "Added: 'Call Bob' → Work") {
+		t.Errorf("add printed %q", out)
+	}
+	if n := len(srv.RecordNames("Reminder")); n != 2 {
+		t.Fatalf("server has %d reminders, want 2", n)
+	}
+
+	got := listJSON(t)
+	if len(got) != 2 {
+		t.Fatalf("list returned %d reminders, want 2: %+v", len(got), got)
+	}
+	var added *listed
+	for i := range got {
+		if got[i].Title == "Call Bob" {
+			added = &got[i]
+		}
+	}
+	if added == nil {
+		t.Fatalf("added reminder not listed: %+v", got)
+	}
+	if added.ListName != "Work" || added.Due != "2026-10-20" {
+		t.Errorf("added reminder = %+v", *added)
+	}
+
+	if _, err := run(t, "complete", added.ID); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if got := listJSON(t); len(got) != 1 || got[0].Title != "Existing" {
+		t.Errorf("active reminders after complete = %+v", got)
+	}
+	all := listJSON(t, "--all")
+	if len(all) != 2 {
+		t.Fatalf("list --all returned %d reminders, want 2", len(all))
+	}
+	for _, r := range all {
+		if r.Completed != (r.Title == "Call Bob") {
+			t.Errorf("%q completed = %v", r.Title, r.Completed)
+		}
+	}
+}
+
+func TestUnknownOutputFormat(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	newCLI(t, srv)
+
+	_, err := run(t, "list", "-o", "xml")
+	if err == nil || !strings.Contains(err.Error(), `unknown output format "xml"`) {
+		t.Errorf("list -o xml: err = %v", err)
+	}
+	if n := srv.Requests("changes/zone"); n != 0 {
+		t.Errorf("list -o xml made %d sync requests, want none", n)
+	}
+}
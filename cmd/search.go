@@ -2,64 +2,121 @@ package cmd
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"icloud-reminders/internal/filter"
+	"icloud-reminders/internal/search"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
 )
 
-var searchAll bool
+var (
+	searchAll    bool
+	searchWhere  string
+	searchRegex  bool
+	searchExact  bool
+	searchOutput string
+)
 
 var searchCmd = &cobra.Command{
 	Use:   "search <query>",
-	Short: "Search reminders by title",
-	Args:  cobra.ExactArgs(1),
+	Short: "Search reminders by title and notes",
+	Long: `Search reminder titles and notes. Every term must match; results are
+ranked with title matches first, and matching notes text is shown as a
+highlighted snippet.
+
+Scope a term with title: or notes:, and quote phrases ("late fee").
+Terms that do not occur literally still match words with a typo or two
+unless --exact is given. With --regex each term is a regular expression.
+
+Examples:
+  reminders search milk
+  reminders search notes:invoice
+  reminders search 'title:"tax return" 2025'
+  reminders search --regex 'inv-\d+'
+  reminders search groceris          # finds "groceries"`,
+	Args: cobra.MinimumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		query := args[0]
-		if err := syncEngine.Sync(false); err != nil {
+		if err := checkFormat(searchOutput); err != nil {
 			return err
 		}
-		reminders := syncEngine.GetReminders(searchAll)
-
-		queryLower := strings.ToLower(query)
-		var matches []*struct {
-			title    string
-			due      string
-			shortID  string
-			listName string
-			done     bool
+		query := strings.Join(args, " ")
+		q, err := search.Parse(query, search.Options{Regex: searchRegex, Exact: searchExact})
+		if err != nil {
+			return err
 		}
-		for _, r := range reminders {
-			if strings.Contains(strings.ToLower(r.Title), queryLower) {
-				due := ""
-				if r.Due != nil {
-					due = *r.Due
-				}
-				matches = append(matches, &struct {
-					title    string
-					due      string
-					shortID  string
-					listName string
-					done     bool
-				}{r.Title, due, r.ShortID(), r.ListName, r.Completed})
-			}
+		where, err := filter.Parse(searchWhere)
+		if err != nil {
+			return err
 		}
+		if err := syncEngine.Sync(false); err != nil {
+			return err
+		}
+		reminders := where.Select(syncEngine.GetReminders(searchAll || where.Uses("completed")))
+		results := q.Search(reminders)
 
-		fmt.Printf("\n🔍 Search: '%s' → %d matches\n", query, len(matches))
-		for _, m := range matches {
-			status := "•"
-			if m.done {
-				status = "✓"
+		// Structured formats carry plain snippets; text output highlights matches.
+		hits := make([]interface{}, len(results))
+		for i, res := range results {
+			hit := &searchHit{Reminder: res.Reminder, Score: res.Score}
+			if len(res.NotesSpans) > 0 {
+				hit.Snippet = search.Snippet(*res.Reminder.Notes, res.NotesSpans, 60, func(s string) string { return s })
 			}
-			due := ""
-			if m.due != "" {
-				due = fmt.Sprintf("  [due %s]", m.due)
-			}
-			fmt.Printf("  %s %s%s  (%s) — %s\n", status, m.title, due, m.shortID, m.listName)
+			hits[i] = hit
 		}
-		return nil
+		columns := append(reminderColumns(func(item interface{}) *models.Reminder { return item.(*searchHit).Reminder }),
+			column{"score", func(item interface{}) string { return strconv.Itoa(item.(*searchHit).Score) }},
+			column{"snippet", func(item interface{}) string { return item.(*searchHit).Snippet }},
+		)
+
+		return render(searchOutput, &output{
+			items:   hits,
+			columns: columns,
+			text: func(w io.Writer) error {
+				mark := func(s string) string { return "«" + s + "»" }
+				if w == os.Stdout && term.IsTerminal(int(os.Stdout.Fd())) {
+					mark = func(s string) string { return "\x1b[1;33m" + s + "\x1b[0m" }
+				}
+				fmt.Fprintf(w, "\n🔍 Search: '%s' → %d matches\n", query, len(results))
+				for _, res := range results {
+					r := res.Reminder
+					status := "•"
+					if r.Completed {
+						status = "✓"
+					}
+					due := ""
+					if r.Due != nil && *r.Due != "" {
+						due = fmt.Sprintf("  [due %s]", utils.DisplayDue(*r.Due))
+					}
+					title := search.Highlight(r.Title, res.TitleSpans, mark)
+					fmt.Fprintf(w, "  %s %s%s  (%s) — %s\n", status, title, due, r.ShortID(), r.ListName)
+					if len(res.NotesSpans) > 0 {
+						fmt.Fprintf(w, "      %s\n", search.Snippet(*r.Notes, res.NotesSpans, 60, mark))
+					}
+				}
+				return nil
+			},
+		})
 	},
 }
 
+// searchHit is a search result in structured output formats.
+type searchHit struct {
+	*models.Reminder
+	Score   int    `json:"score"`
+	Snippet string `json:"snippet,omitempty"`
+}
+
 func init() {
 	searchCmd.Flags().BoolVarP(&searchAll, "all", "a", false, "Include completed reminders")
+	searchCmd.Flags().StringVarP(&searchWhere, "where", "w", "", "Filter expression, e.g. 'list=Work and due' (see 'reminders help filters')")
+	searchCmd.Flags().BoolVar(&searchRegex, "regex", false, "Treat each term as a regular expression")
+	searchCmd.Flags().BoolVar(&searchExact, "exact", false, "Disable typo-tolerant matching")
+	addOutputFlag(searchCmd, &searchOutput)
 }
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"icloud-reminders/internal/server"
+)
+
+var (
+	serveListen       string
+	serveSyncInterval time.Duration
+	serveToken        string
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve a local REST API for reminders and lists",
+	Long: `Serve reminders and lists over HTTP/JSON until interrupted.
+
+The cache stays in memory and is delta-synced every --sync-interval; every
+write syncs first. The OpenAPI description is at /openapi.json.
+
+Routes:
+  GET    /health                      cache and sync state
+  POST   /sync[?full=true]            sync now
+  GET    /lists                       lists with open-reminder counts
+  POST   /lists                       {"name", "color"}
+  PATCH  /lists/{id}                  {"name", "color"}
+  DELETE /lists/{id}[?move_to=|force=true]
+  GET    /reminders                   ?list=, ?where=, ?parent=, ?completed=true
+  POST   /reminders                   {"title", "list", "due", "priority", ...}
+  GET    /reminders/{id}
+  PATCH  /reminders/{id}              {"title", "due", "list", "completed", ...}
+  DELETE /reminders/{id}
+  POST   /reminders/{id}/complete
+  POST   /reminders/{id}/reopen[?subtasks=true]
+
+With --token (or $REMINDERS_API_TOKEN) set, requests must send
+"Authorization: Bearer <token>".
+
+Examples:
+  reminders serve
+  reminders serve --listen 127.0.0.1:9000 --sync-interval 30s
+  curl 'http://127.0.0.1:8080/reminders?where=due<=today'
+  curl -X POST http://127.0.0.1:8080/reminders -d '{"title":"Milk","list":"Groceries"}'`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		token := serveToken
+		if token == "" {
+			token = os.Getenv("REMINDERS_API_TOKEN")
+		}
+		if token == "" && !isLoopback(serveListen) {
+			fmt.Fprintf(os.Stderr, "⚠️  Listening on %s without --token: anyone who can reach it can change your reminders.\n", serveListen)
+		}
+
+		ln, err := net.Listen("tcp", serveListen)
+		if err != nil {
+			return err
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		fmt.Printf("Serving on http://%s (API description: /openapi.json)\n", ln.Addr())
+		return server.New(syncEngine, w, serveSyncInterval, token).Serve(ctx, ln)
+	},
+}
+
+// isLoopback reports whether a listen address only accepts local
+// connections.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", "127.0.0.1:8080", "Address to listen on")
+	serveCmd.Flags().DurationVar(&serveSyncInterval, "sync-interval", time.Minute, "How often to delta-sync in the background (0 = only on writes and POST /sync)")
+	serveCmd.Flags().StringVar(&serveToken, "token", "", "Require this bearer token (default $REMINDERS_API_TOKEN)")
+}
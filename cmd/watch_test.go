@@ -0,0 +1,100 @@
+package cmd_test
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"icloud-reminders/cmd"
+	"icloud-reminders/internal/cloudkit/cktest"
+)
+
+// waitFor polls cond until it holds, failing the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); !cond(); time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	work := srv.AddList("Work")
+	old := srv.AddReminder("Old", work)
+	newCLI(t, srv)
+	// Sync the cache first, so the watch only sees the changes below.
+	if _, err := run(t, "list"); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+
+	f, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdout := os.Stdout
+	os.Stdout = f
+	defer func() { os.Stdout = stdout }()
+	defer resetFlags(cmd.RootCmd)
+
+	synced := srv.Requests("changes/zone")
+	done := make(chan error, 1)
+	cmd.RootCmd.SetArgs([]string{"watch", "--interval", "20ms"})
+	go func() { done <- cmd.RootCmd.Execute() }()
+	// The interrupt handler is installed before the first sync.
+	waitFor(t, "the first sync", func() bool { return srv.Requests("changes/zone") > synced })
+
+	srv.AddReminder("New", work)
+	srv.Remove(old)
+	var lines []string
+	waitFor(t, "two events", func() bool {
+		data, err := os.ReadFile(f.Name())
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+		return len(lines) >= 2
+	})
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("watch: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watch did not stop on interrupt")
+	}
+
+	if len(lines) != 2 {
+		t.Fatalf("watch printed %d events, want 2:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	types := map[string]string{}
+	for _, line := range lines {
+		var ev struct {
+			Type   string
+			Before *struct{ Title string }
+			After  *struct{ Title string }
+		}
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			t.Fatalf("event %q: %v", line, err)
+		}
+		switch {
+		case ev.After != nil:
+			types[ev.After.Title] = ev.Type
+		case ev.Before != nil:
+			types[ev.Before.Title] = ev.Type
+		}
+	}
+	if types["New"] != "created" || types["Old"] != "deleted" {
+		t.Errorf("events = %v, want New created and Old deleted", types)
+	}
+}
@@ -19,32 +19,51 @@ var SessionFile = filepath.Join(ConfigDir, "session.json")
 
 // ReminderData holds raw cached data for a single reminder.
 type ReminderData struct {
-	Title          string  `json:"title"`
-	Completed      bool    `json:"completed"`
-	CompletionDate *string `json:"completion_date,omitempty"`
-	Due            *string `json:"due,omitempty"`
-	Priority       int     `json:"priority"`
-	Notes          *string `json:"notes,omitempty"`
-	ListRef        *string `json:"list_ref,omitempty"`
-	ParentRef      *string `json:"parent_ref,omitempty"`
-	ModifiedTS     *int64  `json:"modified_ts,omitempty"`
-	ChangeTag      *string `json:"change_tag,omitempty"`
+	Title          string          `json:"title"`
+	Completed      bool            `json:"completed"`
+	CompletionDate *string         `json:"completion_date,omitempty"`
+	Due            *string         `json:"due,omitempty"` // YYYY-MM-DD (all-day) or RFC 3339 (timed)
+	Priority       int             `json:"priority"`
+	Notes          *string         `json:"notes,omitempty"`
+	ListRef        *string         `json:"list_ref,omitempty"`
+	ParentRef      *string         `json:"parent_ref,omitempty"`
+	ModifiedTS     *int64          `json:"modified_ts,omitempty"`
+	ChangeTag      *string         `json:"change_tag,omitempty"`
+	Recurrence     *RecurrenceData `json:"recurrence,omitempty"`
+	TimeZone       *string         `json:"time_zone,omitempty"`
+}
+
+// RecurrenceData holds a cached RecurrenceRule record attached to a reminder.
+type RecurrenceData struct {
+	RuleID    string  `json:"rule_id"`
+	Frequency string  `json:"frequency"`
+	Interval  int     `json:"interval"`
+	Until     *string `json:"until,omitempty"`
+	ChangeTag *string `json:"change_tag,omitempty"`
 }
 
 // Cache holds the local cache of reminders and lists.
 type Cache struct {
-	Reminders map[string]*ReminderData `json:"reminders"`
-	Lists     map[string]string        `json:"lists"`
-	SyncToken *string                  `json:"sync_token,omitempty"`
-	OwnerID   *string                  `json:"owner_id,omitempty"`
-	UpdatedAt *string                  `json:"updated_at,omitempty"`
+	Reminders      map[string]*ReminderData `json:"reminders"`
+	Lists          map[string]string        `json:"lists"`
+	ListChangeTags map[string]string        `json:"list_change_tags,omitempty"`
+	ListColors     map[string]string        `json:"list_colors,omitempty"`
+	SyncToken      *string                  `json:"sync_token,omitempty"`
+	OwnerID        *string                  `json:"owner_id,omitempty"`
+	UpdatedAt      *string                  `json:"updated_at,omitempty"`
+	// ImportedUIDs maps the source UIDs of imported reminders (e.g. an
+	// iCalendar UID) to their record names, so re-imports can skip them.
+	ImportedUIDs map[string]string `json:"imported_uids,omitempty"`
 }
 
 // NewCache returns an empty Cache.
 func NewCache() *Cache {
 	return &Cache{
-		Reminders: make(map[string]*ReminderData),
-		Lists:     make(map[string]string),
+		Reminders:      make(map[string]*ReminderData),
+		Lists:          make(map[string]string),
+		ListChangeTags: make(map[string]string),
+		ListColors:     make(map[string]string),
+		ImportedUIDs:   make(map[string]string),
 	}
 }
 
@@ -64,6 +83,15 @@ func Load() *Cache {
 	if c.Lists == nil {
 		c.Lists = make(map[string]string)
 	}
+	if c.ListChangeTags == nil {
+		c.ListChangeTags = make(map[string]string)
+	}
+	if c.ListColors == nil {
+		c.ListColors = make(map[string]string)
+	}
+	if c.ImportedUIDs == nil {
+		c.ImportedUIDs = make(map[string]string)
+	}
 	return &c
 }
 
@@ -0,0 +1,279 @@
+// Package caldav serves reminder lists as CalDAV (RFC 4791) task calendars
+// ('reminders caldav'), so desktop clients such as Thunderbird and Evolution
+// can use iCloud Reminders through this program's session.
+//
+// Layout:
+//
+//	/                          service root; /.well-known/caldav redirects here
+//	/principal/                the one principal
+//	/calendars/                calendar home
+//	/calendars/<list>/         one calendar per list (<list> is the list's UUID)
+//	/calendars/<list>/<uid>.ics  one VTODO per reminder
+//
+// A reminder's UID is its record UUID, or the UID a client gave it when it
+// created the reminder (kept in the cache's imported UIDs, as for 'reminders
+// import'). Resources are named after their UID.
+//
+// PROPFIND and REPORT (calendar-query, calendar-multiget, sync-collection)
+// are answered from the cache, which is delta-synced in the background.
+// sync-collection tokens are CloudKit sync tokens: the changes since a
+// client's token are asked of CloudKit. PUT and DELETE are applied with the
+// writer, after a delta sync, like the CLI's own writes.
+package caldav
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"icloud-reminders/internal/daemon"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/internal/writer"
+)
+
+// Paths of the fixed resources.
+const (
+	principalPath = "/principal/"
+	homePath      = "/calendars/"
+)
+
+// Server serves the lists of one engine and writer over CalDAV.
+type Server struct {
+	daemon.Warm
+	// User and Password, if Password is set, must be sent with HTTP Basic
+	// authentication on every request.
+	User     string
+	Password string
+}
+
+// New returns a server for engine and w.
+func New(engine *sync.Engine, w *writer.Writer, syncInterval time.Duration, user, password string) *Server {
+	return &Server{Warm: daemon.Warm{Engine: engine, Writer: w, SyncInterval: syncInterval}, User: user, Password: password}
+}
+
+// Serve syncs once, then serves CalDAV on ln until ctx is cancelled, when it
+// shuts down gracefully.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	return s.Warm.Serve(ctx, ln, s.Handler())
+}
+
+// Handler returns the CalDAV HTTP handler.
+func (s *Server) Handler() http.Handler {
+	return daemon.LogRequests(s.authorize(http.HandlerFunc(s.serveDAV)))
+}
+
+// serveDAV dispatches a request by method. Requests are served one at a
+// time: the engine and writer are not safe for concurrent use.
+func (s *Server) serveDAV(rw http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/.well-known/caldav" {
+		http.Redirect(rw, r, "/", http.StatusMovedPermanently)
+		return
+	}
+	rw.Header().Set("DAV", "1, 3, calendar-access")
+	if r.Method == http.MethodOptions {
+		rw.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	res, ok := s.resolve(r.URL.Path)
+	if !ok {
+		http.Error(rw, "not found", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case "PROPFIND":
+		s.propfind(rw, r, res)
+	case "REPORT":
+		s.report(rw, r, res)
+	case http.MethodGet, http.MethodHead:
+		s.get(rw, res)
+	case http.MethodPut:
+		s.put(rw, r, res)
+	case http.MethodDelete:
+		s.delete(rw, r, res)
+	default:
+		rw.Header().Set("Allow", "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT")
+		http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// authorize checks HTTP Basic credentials, if a password is configured.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	if s.Password == "" {
+		return next
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if ok && subtle.ConstantTimeCompare([]byte(user), []byte(s.User)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(password), []byte(s.Password)) == 1 {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		rw.Header().Set("WWW-Authenticate", `Basic realm="reminders"`)
+		http.Error(rw, "unauthorized", http.StatusUnauthorized)
+	})
+}
+
+// Resource kinds.
+const (
+	kindRoot = iota
+	kindPrincipal
+	kindHome
+	kindCalendar
+	kindItem
+)
+
+// resource is a resolved request path.
+type resource struct {
+	kind   int
+	href   string
+	listID string // calendars and items
+	name   string // items: the resource name without ".ics"
+	rid    string // items: the reminder's record name, "" if there is none
+}
+
+// resolve maps a request path to a resource. Items need not exist (PUT
+// creates them), but their calendar must.
+func (s *Server) resolve(path string) (*resource, bool) {
+	switch path {
+	case "/", "":
+		return &resource{kind: kindRoot, href: "/"}, true
+	case principalPath, strings.TrimSuffix(principalPath, "/"):
+		return &resource{kind: kindPrincipal, href: principalPath}, true
+	case homePath, strings.TrimSuffix(homePath, "/"):
+		return &resource{kind: kindHome, href: homePath}, true
+	}
+	rest, ok := strings.CutPrefix(path, homePath)
+	if !ok {
+		return nil, false
+	}
+	list, item, _ := strings.Cut(rest, "/")
+	listID := s.listByUUID(list)
+	if listID == "" {
+		return nil, false
+	}
+	if item == "" {
+		return s.calendar(listID), true
+	}
+	name, ok := strings.CutSuffix(item, ".ics")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return nil, false
+	}
+	res := &resource{kind: kindItem, listID: listID, name: name}
+	res.href = s.calendar(listID).href + url.PathEscape(name) + ".ics"
+	if rid := s.recordForUID(name); rid != "" {
+		if rd := s.Engine.Cache.Reminders[rid]; rd.ListRef != nil && *rd.ListRef == listID {
+			res.rid = rid
+		}
+	}
+	return res, true
+}
+
+// calendar returns the calendar resource of a list.
+func (s *Server) calendar(listID string) *resource {
+	return &resource{kind: kindCalendar, href: homePath + url.PathEscape(uuid(listID)) + "/", listID: listID}
+}
+
+// item returns the resource of a reminder in its list's calendar.
+func (s *Server) item(rid string, uids map[string]string) *resource {
+	name := uidFor(rid, uids)
+	listID := ""
+	if rd := s.Engine.Cache.Reminders[rid]; rd != nil && rd.ListRef != nil {
+		listID = *rd.ListRef
+	}
+	return &resource{
+		kind:   kindItem,
+		href:   s.calendar(listID).href + url.PathEscape(name) + ".ics",
+		listID: listID,
+		name:   name,
+		rid:    rid,
+	}
+}
+
+// listByUUID returns the ID of the list whose UUID is id (ignoring case).
+func (s *Server) listByUUID(id string) string {
+	for listID := range s.Engine.Cache.Lists {
+		if strings.EqualFold(uuid(listID), id) {
+			return listID
+		}
+	}
+	return ""
+}
+
+// listIDs returns the IDs of all lists, by name.
+func (s *Server) listIDs() []string {
+	ids := make([]string, 0, len(s.Engine.Cache.Lists))
+	for id := range s.Engine.Cache.Lists {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := s.Engine.Cache.Lists[ids[i]], s.Engine.Cache.Lists[ids[j]]
+		if a != b {
+			return a < b
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// remindersIn returns the record names of the reminders in a list, sorted.
+func (s *Server) remindersIn(listID string) []string {
+	var ids []string
+	for rid, rd := range s.Engine.Cache.Reminders {
+		if rd.ListRef != nil && *rd.ListRef == listID {
+			ids = append(ids, rid)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// recordForUID returns the record name of the reminder with the given UID:
+// one a client created under it, or the one whose record UUID it is.
+func (s *Server) recordForUID(uid string) string {
+	if rid := s.Engine.Cache.ImportedUIDs[uid]; rid != "" && s.Engine.Cache.Reminders[rid] != nil {
+		return rid
+	}
+	for _, rid := range []string{uid, "Reminder/" + uid} {
+		if s.Engine.Cache.Reminders[rid] != nil {
+			return rid
+		}
+	}
+	return ""
+}
+
+// uidsByRecord inverts the cache's imported UIDs: record name → UID. When
+// several UIDs name one record, the smallest wins.
+func (s *Server) uidsByRecord() map[string]string {
+	uids := make(map[string]string, len(s.Engine.Cache.ImportedUIDs))
+	for uid, rid := range s.Engine.Cache.ImportedUIDs {
+		if cur, ok := uids[rid]; !ok || uid < cur {
+			uids[rid] = uid
+		}
+	}
+	return uids
+}
+
+// uidFor returns the UID of a reminder: its imported UID, else its UUID.
+func uidFor(rid string, uids map[string]string) string {
+	if uid := uids[rid]; uid != "" {
+		return uid
+	}
+	return uuid(rid)
+}
+
+// uuid strips a "Reminder/" or "List/" prefix from a record name.
+func uuid(recordName string) string {
+	if i := strings.LastIndexByte(recordName, '/'); i >= 0 {
+		return recordName[i+1:]
+	}
+	return recordName
+}
@@ -0,0 +1,510 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/ical"
+	"icloud-reminders/internal/logger"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/pkg/models"
+)
+
+// XML namespaces.
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+	nsApple  = "http://apple.com/ns/ical/"
+)
+
+// prefixes are the namespace prefixes declared on every multistatus.
+var prefixes = map[string]string{nsDAV: "d", nsCalDAV: "c", nsCS: "cs", nsApple: "ic"}
+
+// syncTokenPrefix makes a CloudKit sync token a URI, as DAV:sync-token
+// values must be.
+const syncTokenPrefix = "data:,"
+
+// allProps are the properties returned for DAV:allprop.
+var allProps = []xml.Name{
+	{Space: nsDAV, Local: "resourcetype"},
+	{Space: nsDAV, Local: "displayname"},
+	{Space: nsDAV, Local: "getetag"},
+	{Space: nsDAV, Local: "getcontenttype"},
+	{Space: nsDAV, Local: "getlastmodified"},
+	{Space: nsDAV, Local: "sync-token"},
+	{Space: nsCalDAV, Local: "supported-calendar-component-set"},
+	{Space: nsCS, Local: "getctag"},
+}
+
+// propNames is the DAV:prop element of a request: the properties asked for.
+type propNames struct {
+	Names []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
+func (p *propNames) list() []xml.Name {
+	if p == nil {
+		return allProps
+	}
+	names := make([]xml.Name, len(p.Names))
+	for i, n := range p.Names {
+		names[i] = n.XMLName
+	}
+	return names
+}
+
+// propfindRequest is a PROPFIND body. An empty body means allprop.
+type propfindRequest struct {
+	XMLName xml.Name   `xml:"DAV: propfind"`
+	Prop    *propNames `xml:"DAV: prop"`
+}
+
+// reportRequest is a REPORT body: calendar-query, calendar-multiget or
+// sync-collection, told apart by XMLName.
+type reportRequest struct {
+	XMLName   xml.Name
+	Prop      *propNames  `xml:"DAV: prop"`
+	Hrefs     []string    `xml:"DAV: href"`
+	SyncToken string      `xml:"DAV: sync-token"`
+	Filter    *compFilter `xml:"urn:ietf:params:xml:ns:caldav filter>comp-filter"`
+}
+
+// compFilter is a CALDAV:comp-filter.
+type compFilter struct {
+	Name  string       `xml:"name,attr"`
+	Comps []compFilter `xml:"urn:ietf:params:xml:ns:caldav comp-filter"`
+	Props []propFilter `xml:"urn:ietf:params:xml:ns:caldav prop-filter"`
+}
+
+// propFilter is a CALDAV:prop-filter. Only is-not-defined is evaluated.
+type propFilter struct {
+	Name         string    `xml:"name,attr"`
+	IsNotDefined *struct{} `xml:"urn:ietf:params:xml:ns:caldav is-not-defined"`
+}
+
+// readXML decodes an XML request body into v; an empty body leaves v as is.
+func readXML(r *http.Request, v interface{}) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	return xml.Unmarshal(body, v)
+}
+
+// propfind handles PROPFIND with Depth 0 or 1 (infinity is treated as 1).
+func (s *Server) propfind(rw http.ResponseWriter, r *http.Request, res *resource) {
+	var req propfindRequest
+	if err := readXML(r, &req); err != nil {
+		http.Error(rw, "invalid PROPFIND body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if res.kind == kindItem && res.rid == "" {
+		http.Error(rw, "not found", http.StatusNotFound)
+		return
+	}
+	names := req.Prop.list()
+	ms := newMultistatus()
+	uids := s.uidsByRecord()
+	ms.props(res.href, names, func(name xml.Name) (string, bool) { return s.prop(res, name, uids) })
+	if r.Header.Get("Depth") != "0" {
+		for _, child := range s.children(res, uids) {
+			ms.props(child.href, names, func(name xml.Name) (string, bool) { return s.prop(child, name, uids) })
+		}
+	}
+	ms.send(rw)
+}
+
+// children returns the members of a collection.
+func (s *Server) children(res *resource, uids map[string]string) []*resource {
+	var children []*resource
+	switch res.kind {
+	case kindRoot:
+		children = append(children, &resource{kind: kindPrincipal, href: principalPath}, &resource{kind: kindHome, href: homePath})
+	case kindHome:
+		for _, listID := range s.listIDs() {
+			children = append(children, s.calendar(listID))
+		}
+	case kindCalendar:
+		for _, rid := range s.remindersIn(res.listID) {
+			children = append(children, s.item(rid, uids))
+		}
+	}
+	return children
+}
+
+// prop returns the value of a property of res as XML, or false if res does
+// not have it.
+func (s *Server) prop(res *resource, name xml.Name, uids map[string]string) (string, bool) {
+	href := func(path string) string { return "<d:href>" + escape(path) + "</d:href>" }
+	switch name {
+	case xml.Name{Space: nsDAV, Local: "resourcetype"}:
+		switch res.kind {
+		case kindPrincipal:
+			return "<d:collection/><d:principal/>", true
+		case kindCalendar:
+			return "<d:collection/><c:calendar/>", true
+		case kindItem:
+			return "", true
+		}
+		return "<d:collection/>", true
+	case xml.Name{Space: nsDAV, Local: "displayname"}:
+		switch res.kind {
+		case kindCalendar:
+			return escape(s.Engine.Cache.Lists[res.listID]), true
+		case kindItem:
+			return "", false
+		}
+		return "iCloud Reminders", true
+	case xml.Name{Space: nsDAV, Local: "current-user-principal"}, xml.Name{Space: nsDAV, Local: "principal-URL"}, xml.Name{Space: nsDAV, Local: "owner"}:
+		return href(principalPath), true
+	case xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}:
+		if res.kind == kindRoot || res.kind == kindPrincipal {
+			return href(homePath), true
+		}
+	case xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}:
+		var b strings.Builder
+		for _, p := range []string{"read", "write", "write-content", "bind", "unbind", "read-current-user-privilege-set"} {
+			b.WriteString("<d:privilege><d:" + p + "/></d:privilege>")
+		}
+		return b.String(), true
+	case xml.Name{Space: nsDAV, Local: "supported-report-set"}:
+		if res.kind == kindCalendar {
+			return "<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>" +
+				"<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>" +
+				"<d:supported-report><d:report><d:sync-collection/></d:report></d:supported-report>", true
+		}
+	case xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}:
+		if res.kind == kindCalendar {
+			return `<c:comp name="VTODO"/>`, true
+		}
+	case xml.Name{Space: nsDAV, Local: "sync-token"}, xml.Name{Space: nsCS, Local: "getctag"}:
+		if res.kind == kindCalendar {
+			return escape(s.syncToken()), true
+		}
+	case xml.Name{Space: nsApple, Local: "calendar-color"}:
+		if res.kind == kindCalendar {
+			if color := listColor(s.Engine.Cache.ListColors[res.listID]); color != "" {
+				return color, true
+			}
+		}
+	case xml.Name{Space: nsDAV, Local: "getetag"}:
+		if res.kind == kindItem {
+			return escape(etag(s.Engine.Cache.Reminders[res.rid])), true
+		}
+	case xml.Name{Space: nsDAV, Local: "getcontenttype"}:
+		if res.kind == kindItem {
+			return "text/calendar; charset=utf-8; component=VTODO", true
+		}
+	case xml.Name{Space: nsDAV, Local: "getlastmodified"}:
+		if rd := s.Engine.Cache.Reminders[res.rid]; res.kind == kindItem && rd != nil && rd.ModifiedTS != nil {
+			return time.UnixMilli(*rd.ModifiedTS).UTC().Format(http.TimeFormat), true
+		}
+	case xml.Name{Space: nsCalDAV, Local: "calendar-data"}:
+		if res.kind == kindItem {
+			data, err := s.calendarData([]string{res.rid}, "", uids)
+			if err != nil {
+				logger.Warnf("encode %s: %v", res.rid, err)
+				return "", false
+			}
+			return escape(data), true
+		}
+	}
+	return "", false
+}
+
+// report handles the calendar-query, calendar-multiget and sync-collection
+// REPORTs on a calendar.
+func (s *Server) report(rw http.ResponseWriter, r *http.Request, res *resource) {
+	var req reportRequest
+	if err := readXML(r, &req); err != nil {
+		http.Error(rw, "invalid REPORT body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if res.kind != kindCalendar {
+		http.Error(rw, "REPORT is only supported on calendars", http.StatusForbidden)
+		return
+	}
+	names := req.Prop.list()
+	uids := s.uidsByRecord()
+	ms := newMultistatus()
+	itemProps := func(item *resource) {
+		ms.props(item.href, names, func(name xml.Name) (string, bool) { return s.prop(item, name, uids) })
+	}
+
+	switch req.XMLName {
+	case xml.Name{Space: nsCalDAV, Local: "calendar-query"}:
+		for _, rid := range s.remindersIn(res.listID) {
+			if matchFilter(req.Filter, s.Engine.GetReminder(rid)) {
+				itemProps(s.item(rid, uids))
+			}
+		}
+
+	case xml.Name{Space: nsCalDAV, Local: "calendar-multiget"}:
+		for _, h := range req.Hrefs {
+			path := h
+			if u, err := url.Parse(h); err == nil {
+				path = u.Path
+			}
+			item, ok := s.resolve(path)
+			if !ok || item.kind != kindItem || item.rid == "" || item.listID != res.listID {
+				ms.status(h, http.StatusNotFound)
+				continue
+			}
+			itemProps(item)
+		}
+
+	case xml.Name{Space: nsDAV, Local: "sync-collection"}:
+		token, err := s.syncCollection(res, req.SyncToken, uids, itemProps, ms)
+		if errors.Is(err, sync.ErrInvalidSyncToken) {
+			preconditionFailed(rw, http.StatusForbidden, "<d:valid-sync-token/>")
+			return
+		}
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusBadGateway)
+			return
+		}
+		ms.syncToken = token
+
+	default:
+		preconditionFailed(rw, http.StatusForbidden, "<d:supported-report/>")
+		return
+	}
+	ms.send(rw)
+}
+
+// syncCollection reports the reminders of a calendar changed since a
+// client's sync token: all of them for an initial sync (no token), else the
+// ones CloudKit reports changed, with those no longer in the calendar as
+// 404. It returns the token to send.
+func (s *Server) syncCollection(res *resource, clientToken string, uids map[string]string, itemProps func(*resource), ms *multistatus) (string, error) {
+	if clientToken == "" {
+		if err := s.Sync(false); err != nil {
+			return "", err
+		}
+		for _, rid := range s.remindersIn(res.listID) {
+			itemProps(s.item(rid, uids))
+		}
+		return s.syncToken(), nil
+	}
+	ckToken, ok := strings.CutPrefix(clientToken, syncTokenPrefix)
+	if ok {
+		ckToken, ok = unescapeToken(ckToken)
+	}
+	if !ok {
+		return "", sync.ErrInvalidSyncToken
+	}
+
+	// Ask for the changes before syncing, so nothing that changes in
+	// between is missed: the token returned is never ahead of the cache.
+	changed, next, err := s.Engine.ChangedSince(ckToken)
+	if err != nil {
+		return "", err
+	}
+	if err := s.Sync(false); err != nil {
+		return "", err
+	}
+	for _, rid := range changed {
+		if rd := s.Engine.Cache.Reminders[rid]; rd != nil && rd.ListRef != nil && *rd.ListRef == res.listID {
+			itemProps(s.item(rid, uids))
+			continue
+		}
+		ms.status(s.calendar(res.listID).href+url.PathEscape(uidFor(rid, uids))+".ics", http.StatusNotFound)
+	}
+	return syncTokenPrefix + url.PathEscape(next), nil
+}
+
+// syncToken returns the current CloudKit sync token as a URI.
+func (s *Server) syncToken() string {
+	token := ""
+	if s.Engine.Cache.SyncToken != nil {
+		token = *s.Engine.Cache.SyncToken
+	}
+	return syncTokenPrefix + url.PathEscape(token)
+}
+
+func unescapeToken(s string) (string, bool) {
+	token, err := url.PathUnescape(s)
+	return token, err == nil && token != ""
+}
+
+// calendarData encodes reminders as one VCALENDAR. The list name is left
+// out: the calendar already names it.
+func (s *Server) calendarData(rids []string, calName string, uids map[string]string) (string, error) {
+	reminders := make([]*models.Reminder, 0, len(rids))
+	for _, rid := range rids {
+		if r := s.Engine.GetReminder(rid); r != nil {
+			r.ListName = ""
+			reminders = append(reminders, r)
+		}
+	}
+	var b strings.Builder
+	err := ical.EncodeUIDs(&b, reminders, calName, func(rid string) string { return uidFor(rid, uids) })
+	return b.String(), err
+}
+
+// matchFilter reports whether a reminder passes a calendar-query filter.
+// Only component names and is-not-defined property tests are evaluated;
+// other tests match everything, leaving the client to filter.
+func matchFilter(f *compFilter, r *models.Reminder) bool {
+	if f == nil || r == nil {
+		return r != nil
+	}
+	if !strings.EqualFold(f.Name, "VCALENDAR") {
+		return false
+	}
+	for _, comp := range f.Comps {
+		if !strings.EqualFold(comp.Name, "VTODO") {
+			return false
+		}
+		for _, p := range comp.Props {
+			if defined, known := propDefined(r, p.Name); known && p.IsNotDefined != nil && defined {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// propDefined reports whether a reminder's VTODO has an iCalendar property;
+// known is false for properties this does not track.
+func propDefined(r *models.Reminder, name string) (defined, known bool) {
+	switch strings.ToUpper(name) {
+	case "COMPLETED":
+		return r.Completed, true
+	case "DUE":
+		return r.Due != nil && *r.Due != "", true
+	case "DESCRIPTION":
+		return r.Notes != nil && *r.Notes != "", true
+	case "PRIORITY":
+		return r.Priority != 0, true
+	case "RRULE":
+		return r.Recurrence != nil, true
+	case "RELATED-TO":
+		return r.ParentRef != nil && *r.ParentRef != "", true
+	}
+	return false, false
+}
+
+// etag returns the entity tag of a reminder: its change tag and its
+// recurrence rule's, which together change whenever its VTODO does.
+func etag(rd *cache.ReminderData) string {
+	if rd == nil {
+		return ""
+	}
+	tag := ""
+	if rd.ChangeTag != nil {
+		tag = *rd.ChangeTag
+	}
+	if rd.Recurrence != nil && rd.Recurrence.ChangeTag != nil {
+		tag += "-" + *rd.Recurrence.ChangeTag
+	}
+	return `"` + tag + `"`
+}
+
+// listColor returns a list color as #RRGGBB.
+func listColor(color string) string {
+	if hex, ok := models.ListColors[color]; ok {
+		return strings.ToUpper(hex)
+	}
+	if strings.HasPrefix(color, "#") {
+		return strings.ToUpper(color)
+	}
+	return ""
+}
+
+// multistatus builds a DAV:multistatus response.
+type multistatus struct {
+	b         strings.Builder
+	syncToken string
+}
+
+func newMultistatus() *multistatus {
+	return &multistatus{}
+}
+
+// props adds the response for href: one propstat with the properties value
+// returns, another (404) with those it does not have.
+func (m *multistatus) props(href string, names []xml.Name, value func(xml.Name) (string, bool)) {
+	var found, missing strings.Builder
+	for _, name := range names {
+		v, ok := value(name)
+		start, end := element(name)
+		switch {
+		case !ok:
+			missing.WriteString(strings.TrimSuffix(start, ">") + "/>")
+		case v == "":
+			found.WriteString(strings.TrimSuffix(start, ">") + "/>")
+		default:
+			found.WriteString(start + v + end)
+		}
+	}
+	m.b.WriteString("<d:response><d:href>" + escape(href) + "</d:href>")
+	if found.Len() > 0 {
+		m.b.WriteString("<d:propstat><d:prop>" + found.String() + "</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>")
+	}
+	if missing.Len() > 0 {
+		m.b.WriteString("<d:propstat><d:prop>" + missing.String() + "</d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat>")
+	}
+	m.b.WriteString("</d:response>\n")
+}
+
+// status adds a response for href with only a status.
+func (m *multistatus) status(href string, code int) {
+	fmt.Fprintf(&m.b, "<d:response><d:href>%s</d:href><d:status>HTTP/1.1 %d %s</d:status></d:response>\n",
+		escape(href), code, http.StatusText(code))
+}
+
+// send writes the multistatus with status 207.
+func (m *multistatus) send(rw http.ResponseWriter) {
+	rw.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	rw.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(rw, `<?xml version="1.0" encoding="utf-8"?>`+"\n")
+	io.WriteString(rw, `<d:multistatus xmlns:d="DAV:" xmlns:c="`+nsCalDAV+`" xmlns:cs="`+nsCS+`" xmlns:ic="`+nsApple+`">`+"\n")
+	io.WriteString(rw, m.b.String())
+	if m.syncToken != "" {
+		io.WriteString(rw, "<d:sync-token>"+escape(m.syncToken)+"</d:sync-token>\n")
+	}
+	io.WriteString(rw, "</d:multistatus>\n")
+}
+
+// preconditionFailed sends a DAV:error body naming a failed precondition.
+func preconditionFailed(rw http.ResponseWriter, code int, condition string) {
+	rw.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	rw.WriteHeader(code)
+	io.WriteString(rw, `<?xml version="1.0" encoding="utf-8"?>`+"\n")
+	io.WriteString(rw, `<d:error xmlns:d="DAV:" xmlns:c="`+nsCalDAV+`">`+condition+"</d:error>\n")
+}
+
+// element returns the open and close tags of a property, with a declared
+// prefix or, for other namespaces, an inline declaration.
+func element(name xml.Name) (string, string) {
+	if p, ok := prefixes[name.Space]; ok {
+		return "<" + p + ":" + name.Local + ">", "</" + p + ":" + name.Local + ">"
+	}
+	if name.Space == "" {
+		return "<" + name.Local + ">", "</" + name.Local + ">"
+	}
+	return `<x:` + name.Local + ` xmlns:x="` + escape(name.Space) + `">`, "</x:" + name.Local + ">"
+}
+
+// xmlEscaper escapes text and attribute values; line breaks are kept as
+// they are, as other CalDAV servers do in calendar-data.
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;")
+
+// escape escapes text for XML.
+func escape(s string) string {
+	return xmlEscaper.Replace(s)
+}
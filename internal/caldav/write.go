@@ -0,0 +1,297 @@
+package caldav
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/daemon"
+	"icloud-reminders/internal/ical"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/internal/writer"
+	"icloud-reminders/pkg/models"
+)
+
+// priorityNames maps Reminders priorities to the writer's names.
+var priorityNames = map[int]string{0: "none", 1: "high", 5: "medium", 9: "low"}
+
+// get sends a reminder as a VCALENDAR, or a whole calendar's reminders.
+func (s *Server) get(rw http.ResponseWriter, res *resource) {
+	var rids []string
+	calName := ""
+	switch {
+	case res.kind == kindItem && res.rid != "":
+		rids = []string{res.rid}
+		rw.Header().Set("ETag", etag(s.Engine.Cache.Reminders[res.rid]))
+	case res.kind == kindCalendar:
+		rids = s.remindersIn(res.listID)
+		calName = s.Engine.Cache.Lists[res.listID]
+	default:
+		http.Error(rw, "not found", http.StatusNotFound)
+		return
+	}
+	data, err := s.calendarData(rids, calName, s.uidsByRecord())
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	io.WriteString(rw, data)
+}
+
+// put creates or replaces a reminder from a VTODO. A VTODO whose UID is a
+// reminder in another list moves that reminder here, as clients move tasks
+// between calendars with a PUT and a DELETE.
+func (s *Server) put(rw http.ResponseWriter, r *http.Request, res *resource) {
+	if res.kind != kindItem {
+		http.Error(rw, "only calendar objects can be written", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+	todos, err := ical.Decode(bytes.NewReader(body))
+	if err != nil {
+		preconditionFailed(rw, http.StatusForbidden, "<c:valid-calendar-data/>")
+		return
+	}
+	if len(todos) == 0 {
+		preconditionFailed(rw, http.StatusForbidden, "<c:supported-calendar-component/>")
+		return
+	}
+	todo := todos[0]
+	for _, t := range todos[1:] {
+		if t.UID != todo.UID {
+			preconditionFailed(rw, http.StatusForbidden, "<c:valid-calendar-object-resource/>")
+			return
+		}
+	}
+	if todo.UID == "" {
+		todo.UID = res.name
+	}
+
+	if err := s.Sync(false); err != nil {
+		http.Error(rw, "sync: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	// The sync may have changed the resource.
+	if cur, ok := s.resolve(r.URL.Path); ok {
+		res = cur
+	}
+	if !checkPreconditions(rw, r, s.Engine.Cache.Reminders[res.rid]) {
+		return
+	}
+
+	rid := res.rid
+	if rid == "" {
+		rid = s.recordForUID(todo.UID)
+	}
+	status := http.StatusNoContent
+	if rid == "" {
+		status = http.StatusCreated
+		rid, err = s.create(todo, res.listID)
+	} else {
+		err = s.update(rid, todo, res.listID)
+	}
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	rw.Header().Set("ETag", etag(s.Engine.Cache.Reminders[rid]))
+	rw.WriteHeader(status)
+}
+
+// create adds a reminder for todo to a list, remembering its UID, and
+// returns the new record name.
+func (s *Server) create(todo *ical.Todo, listID string) (string, error) {
+	item := writer.ImportItem{
+		UID:            todo.UID,
+		Title:          todo.Summary,
+		Notes:          todo.Description,
+		Due:            todo.Due,
+		Priority:       todo.Priority,
+		Completed:      todo.Completed,
+		CompletionDate: todo.CompletedAt,
+		Parent:         s.recordForUID(todo.ParentUID),
+		Recurrence:     todo.Recurrence,
+	}
+	result, err := s.Writer.ImportReminders([]writer.ImportItem{item}, s.Engine.Cache.Lists[listID], false)
+	if err := daemon.WriteError(result, err); err != nil {
+		return "", err
+	}
+	entries, _ := result["results"].([]map[string]interface{})
+	if len(entries) != 1 || entries[0]["status"] != writer.ImportCreated {
+		msg := "reminder not created"
+		if len(entries) == 1 && entries[0]["error"] != nil {
+			msg = fmt.Sprint(entries[0]["error"])
+		}
+		return "", fmt.Errorf("%s", msg)
+	}
+	rid, _ := entries[0]["id"].(string)
+	return rid, nil
+}
+
+// update makes reminder rid match todo: it moves it to listID if needed,
+// edits the fields that differ, then completes or reopens it.
+func (s *Server) update(rid string, todo *ical.Todo, listID string) error {
+	id := uuid(rid)
+	if rd := s.Engine.Cache.Reminders[rid]; rd.ListRef == nil || *rd.ListRef != listID {
+		if err := daemon.WriteError(s.Writer.MoveReminder(id, listID)); err != nil {
+			return err
+		}
+	}
+	rd := s.Engine.Cache.Reminders[rid]
+	if f := s.editFields(rd, todo); f != (writer.EditFields{}) {
+		if err := daemon.WriteError(s.Writer.EditReminder(id, f)); err != nil {
+			return err
+		}
+	}
+	switch {
+	case todo.Completed && !rd.Completed:
+		return daemon.WriteError(s.Writer.CompleteReminder(id))
+	case !todo.Completed && rd.Completed:
+		return daemon.WriteError(s.Writer.ReopenReminder(id, false))
+	}
+	return nil
+}
+
+// editFields returns the edit that makes rd match todo.
+func (s *Server) editFields(rd *cache.ReminderData, todo *ical.Todo) writer.EditFields {
+	var f writer.EditFields
+	if todo.Summary != "" && todo.Summary != rd.Title {
+		f.Title = todo.Summary
+	}
+
+	due := ""
+	if rd.Due != nil {
+		due = *rd.Due
+	}
+	switch {
+	case todo.Due == "" && due != "":
+		f.ClearDue = true
+	case todo.Due != "" && !sameDue(todo.Due, due):
+		f.Due = todo.Due
+	}
+
+	notes := ""
+	if rd.Notes != nil {
+		notes = *rd.Notes
+	}
+	switch {
+	case todo.Description == "" && notes != "":
+		f.ClearNotes = true
+	case todo.Description != notes:
+		f.Notes = todo.Description
+	}
+
+	if todo.Priority != rd.Priority {
+		f.Priority = priorityNames[todo.Priority]
+	}
+
+	switch rule := todo.Recurrence; {
+	case rule == nil && rd.Recurrence != nil:
+		f.Repeat = "none"
+	case rule != nil && !sameRule(rule, rd.Recurrence):
+		spec := *rule
+		spec.Until = nil
+		f.Repeat = spec.String()
+		if rule.Until != nil {
+			f.RepeatUntil = *rule.Until
+		}
+	}
+
+	parent := ""
+	if rd.ParentRef != nil {
+		parent = *rd.ParentRef
+	}
+	if todo.ParentUID == "" && parent != "" {
+		f.Parent = "none"
+	} else if p := s.recordForUID(todo.ParentUID); p != "" && p != parent {
+		f.Parent = uuid(p)
+	}
+	return f
+}
+
+// sameDue reports whether two due dates are the same instant (or day).
+func sameDue(a, b string) bool {
+	ta, allDayA, errA := utils.ParseDue(a)
+	tb, allDayB, errB := utils.ParseDue(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return ta == tb && allDayA == allDayB
+}
+
+// sameRule reports whether a recurrence matches a cached rule.
+func sameRule(rule *models.Recurrence, cur *cache.RecurrenceData) bool {
+	if cur == nil {
+		return false
+	}
+	until, curUntil := "", ""
+	if rule.Until != nil {
+		until = *rule.Until
+	}
+	if cur.Until != nil {
+		curUntil = *cur.Until
+	}
+	return rule.Frequency == cur.Frequency && rule.Interval == cur.Interval && until == curUntil
+}
+
+// delete deletes a reminder. Calendars cannot be deleted over CalDAV.
+func (s *Server) delete(rw http.ResponseWriter, r *http.Request, res *resource) {
+	if res.kind != kindItem {
+		http.Error(rw, "lists cannot be deleted over CalDAV; use 'reminders lists delete'", http.StatusForbidden)
+		return
+	}
+	if err := s.Sync(false); err != nil {
+		http.Error(rw, "sync: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	if cur, ok := s.resolve(r.URL.Path); ok {
+		res = cur
+	}
+	if res.rid == "" {
+		http.Error(rw, "not found", http.StatusNotFound)
+		return
+	}
+	if !checkPreconditions(rw, r, s.Engine.Cache.Reminders[res.rid]) {
+		return
+	}
+	if err := daemon.WriteError(s.Writer.DeleteReminder(uuid(res.rid))); err != nil {
+		http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// checkPreconditions applies If-Match and If-None-Match to the current
+// state of a resource (nil if it does not exist), sending 412 if they fail.
+func checkPreconditions(rw http.ResponseWriter, r *http.Request, rd *cache.ReminderData) bool {
+	cur := etag(rd)
+	ok := true
+	if m := r.Header.Get("If-Match"); m != "" {
+		ok = rd != nil && (m == "*" || etagListed(m, cur))
+	}
+	if m := r.Header.Get("If-None-Match"); m != "" && rd != nil {
+		ok = ok && m != "*" && !etagListed(m, cur)
+	}
+	if !ok {
+		http.Error(rw, "precondition failed", http.StatusPreconditionFailed)
+	}
+	return ok
+}
+
+// etagListed reports whether a comma-separated If-Match list contains tag.
+func etagListed(list, tag string) bool {
+	for _, t := range strings.Split(list, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(t), "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
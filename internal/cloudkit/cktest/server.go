@@ -0,0 +1,623 @@
+// Package cktest provides an in-memory stand-in for the CloudKit web service
+// endpoints used by the Reminders client.
+//
+// The server implements the subset of ckdatabasews that cloudkit.Client
+// talks to — zones/list, changes/zone (with syncToken / moreComing paging)
+// and records/modify (with recordChangeTag optimistic locking) — so the sync
+// engine, the writer and the CLI commands can be driven end to end without an
+// Apple account:
+//
+//	srv := cktest.NewServer()
+//	defer srv.Close()
+//	listID := srv.AddList("Groceries")
+//	srv.AddReminder("Milk", listID)
+//	ck, _ := cloudkit.NewFromSession(srv.Session())
+package cktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"icloud-reminders/internal/auth"
+	"icloud-reminders/internal/cloudkit"
+	"icloud-reminders/internal/utils"
+)
+
+// DefaultOwner is the ownerRecordName reported for the Reminders zone.
+const DefaultOwner = "_cktest_owner"
+
+// DefaultPageSize is the number of records returned per changes/zone page.
+const DefaultPageSize = 200
+
+// basePath is the path prefix of all private database endpoints.
+const basePath = "/database/1/" + cloudkit.Container + "/production/private/"
+
+// record is a stored CloudKit record plus its position in the change log.
+type record struct {
+	Name      string
+	Type      string
+	Fields    map[string]interface{}
+	ChangeTag string
+	Created   int64
+	Modified  int64
+	Deleted   bool
+	seq       int64
+}
+
+// Server is an in-memory CloudKit Reminders zone served over HTTP.
+type Server struct {
+	// Owner is the ownerRecordName of the Reminders zone.
+	Owner string
+	// PageSize caps the number of records per changes/zone response.
+	PageSize int
+
+	ts *httptest.Server
+
+	mu       sync.Mutex
+	records  map[string]*record
+	seq      int64
+	tagSeq   int64
+	failures []int
+	requests map[string]int
+}
+
+// NewServer starts a fake CloudKit server on a loopback port.
+// Call Close when done.
+func NewServer() *Server {
+	s := NewUnstartedServer()
+	s.ts = httptest.NewServer(s)
+	return s
+}
+
+// NewUnstartedServer returns a Server that is not listening; use it as an
+// http.Handler (e.g. with httptest.NewRecorder or a custom httptest.Server).
+func NewUnstartedServer() *Server {
+	return &Server{
+		Owner:    DefaultOwner,
+		PageSize: DefaultPageSize,
+		records:  make(map[string]*record),
+		requests: make(map[string]int),
+	}
+}
+
+// Close shuts the listener down.
+func (s *Server) Close() {
+	if s.ts != nil {
+		s.ts.Close()
+	}
+}
+
+// URL returns the CloudKit base URL (the equivalent of ck_base_url).
+func (s *Server) URL() string {
+	if s.ts == nil {
+		return ""
+	}
+	return s.ts.URL + "/"
+}
+
+// Session returns session data pointing at this server, suitable for
+// cloudkit.NewFromSession or for writing to a session file.
+func (s *Server) Session() *auth.SessionData {
+	return &auth.SessionData{
+		CKBaseURL: s.URL(),
+		CreatedAt: time.Now().Format(time.RFC3339),
+	}
+}
+
+// FailNext makes the next len(codes) requests fail with the given HTTP
+// status codes, in order (e.g. FailNext(503) to exercise re-auth).
+func (s *Server) FailNext(codes ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, codes...)
+}
+
+// Requests returns how many times the given endpoint (e.g. "changes/zone")
+// has been called.
+func (s *Server) Requests(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[endpoint]
+}
+
+// --- seeding and inspection ---
+
+// AddList creates a ReminderList record and returns its record name.
+func (s *Server) AddList(name string) string {
+	recordName := "List/" + utils.NewUUIDString()
+	s.Put(recordName, "ReminderList", map[string]interface{}{
+		"Name": map[string]interface{}{"value": name},
+	})
+	return recordName
+}
+
+// AddReminder creates a Reminder record in listID and returns its record name.
+// Extra fields (e.g. "DueDate", "Priority", "ParentReminder") may be passed
+// in CloudKit wire form via fields.
+func (s *Server) AddReminder(title, listID string, fields ...map[string]interface{}) string {
+	recordName := "Reminder/" + utils.NewUUIDString()
+	encoded, _ := utils.EncodeTitle(title)
+	f := map[string]interface{}{
+		"TitleDocument": map[string]interface{}{"value": encoded},
+		"Completed":     map[string]interface{}{"value": 0},
+	}
+	if listID != "" {
+		f["List"] = Ref(listID)
+	}
+	for _, extra := range fields {
+		for k, v := range extra {
+			f[k] = v
+		}
+	}
+	s.Put(recordName, "Reminder", f)
+	return recordName
+}
+
+// Put creates or replaces a record, bumping its change tag and sync position.
+func (s *Server) Put(recordName, recordType string, fields map[string]interface{}) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.store(recordName, recordType, normalize(fields), true)
+	return rec.ChangeTag
+}
+
+// Remove deletes a record as if it had been deleted on another device.
+func (s *Server) Remove(recordName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[recordName]; ok && !rec.Deleted {
+		s.tombstone(rec)
+	}
+}
+
+// Fields returns a copy of a record's fields, or nil if it does not exist
+// or has been deleted.
+func (s *Server) Fields(recordName string) map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[recordName]
+	if !ok || rec.Deleted {
+		return nil
+	}
+	return normalize(rec.Fields)
+}
+
+// ChangeTag returns the current recordChangeTag of a record.
+func (s *Server) ChangeTag(recordName string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[recordName]; ok && !rec.Deleted {
+		return rec.ChangeTag
+	}
+	return ""
+}
+
+// RecordNames returns the names of all live records of the given type
+// (all types if recordType is empty), sorted.
+func (s *Server) RecordNames(recordType string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var names []string
+	for name, rec := range s.records {
+		if rec.Deleted || (recordType != "" && rec.Type != recordType) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Ref builds a CloudKit REFERENCE field value pointing at recordName.
+func Ref(recordName string) map[string]interface{} {
+	return map[string]interface{}{
+		"value": map[string]interface{}{
+			"recordName": recordName,
+			"action":     "NONE",
+		},
+	}
+}
+
+// --- HTTP handling ---
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		writeError(rw, http.StatusMethodNotAllowed, "BAD_REQUEST", "only POST is supported")
+		return
+	}
+	endpoint, ok := strings.CutPrefix(req.URL.Path, basePath)
+	if !ok {
+		writeError(rw, http.StatusNotFound, "NOT_FOUND", "unknown path "+req.URL.Path)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests[endpoint]++
+	if len(s.failures) > 0 {
+		code := s.failures[0]
+		s.failures = s.failures[1:]
+		writeError(rw, code, "SERVICE_UNAVAILABLE", "injected failure")
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		writeError(rw, http.StatusBadRequest, "BAD_REQUEST", err.Error())
+		return
+	}
+
+	var (
+		resp   interface{}
+		status = http.StatusOK
+	)
+	switch endpoint {
+	case "zones/list":
+		resp = s.zonesList()
+	case "changes/zone":
+		resp, status, err = s.changesZone(body)
+	case "records/modify":
+		resp, status, err = s.modifyRecords(body)
+	default:
+		writeError(rw, http.StatusNotFound, "NOT_FOUND", "unknown endpoint "+endpoint)
+		return
+	}
+	if err != nil {
+		writeError(rw, status, "BAD_REQUEST", err.Error())
+		return
+	}
+	writeJSON(rw, status, resp)
+}
+
+func (s *Server) zoneID() map[string]interface{} {
+	return map[string]interface{}{
+		"zoneName":        cloudkit.Zone,
+		"ownerRecordName": s.Owner,
+		"zoneType":        "REGULAR_CUSTOM_ZONE",
+	}
+}
+
+// zonesList handles zones/list.
+func (s *Server) zonesList() map[string]interface{} {
+	return map[string]interface{}{
+		"zones": []interface{}{
+			map[string]interface{}{
+				"zoneID":    s.zoneID(),
+				"syncToken": s.token(s.seq),
+			},
+		},
+	}
+}
+
+// changesZone handles changes/zone, returning records changed after the
+// supplied syncToken in change order, PageSize at a time.
+func (s *Server) changesZone(body []byte) (interface{}, int, error) {
+	var req cloudkit.ChangesZoneRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err)
+	}
+	if len(req.Zones) == 0 {
+		return nil, http.StatusBadRequest, fmt.Errorf("no zones requested")
+	}
+	spec := req.Zones[0]
+	if spec.ZoneID.ZoneName != cloudkit.Zone {
+		return nil, http.StatusNotFound, fmt.Errorf("zone %q not found", spec.ZoneID.ZoneName)
+	}
+
+	var since int64
+	if spec.SyncToken != "" {
+		v, err := s.parseToken(spec.SyncToken)
+		if err != nil {
+			return nil, http.StatusBadRequest, err
+		}
+		since = v
+	}
+
+	var changed []*record
+	for _, rec := range s.records {
+		if rec.seq > since {
+			changed = append(changed, rec)
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].seq < changed[j].seq })
+
+	pageSize := s.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	moreComing := false
+	if len(changed) > pageSize {
+		changed = changed[:pageSize]
+		moreComing = true
+	}
+
+	newSeq := s.seq
+	if moreComing {
+		newSeq = changed[len(changed)-1].seq
+	}
+
+	records := make([]interface{}, 0, len(changed))
+	for _, rec := range changed {
+		records = append(records, s.wire(rec, spec.DesiredKeys))
+	}
+	return map[string]interface{}{
+		"zones": []interface{}{
+			map[string]interface{}{
+				"zoneID":     s.zoneID(),
+				"records":    records,
+				"moreComing": moreComing,
+				"syncToken":  s.token(newSeq),
+			},
+		},
+	}, http.StatusOK, nil
+}
+
+// modifyRecords handles records/modify. When the request is atomic and any
+// operation fails, nothing is applied and the remaining records report
+// ATOMIC_ERROR, matching CloudKit's behavior.
+func (s *Server) modifyRecords(body []byte) (interface{}, int, error) {
+	var req struct {
+		ZoneID     cloudkit.ZoneID          `json:"zoneID"`
+		Operations []map[string]interface{} `json:"operations"`
+		Atomic     bool                     `json:"atomic"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err)
+	}
+	if req.ZoneID.ZoneName != "" && req.ZoneID.ZoneName != cloudkit.Zone {
+		return nil, http.StatusNotFound, fmt.Errorf("zone %q not found", req.ZoneID.ZoneName)
+	}
+
+	// Validate every operation first so atomic batches are all-or-nothing.
+	errs := make([]map[string]interface{}, len(req.Operations))
+	failed := false
+	seen := make(map[string]bool)
+	for i, op := range req.Operations {
+		if e := s.validate(op, seen); e != nil {
+			errs[i] = e
+			failed = true
+		}
+	}
+
+	results := make([]interface{}, len(req.Operations))
+	for i, op := range req.Operations {
+		rec, _ := op["record"].(map[string]interface{})
+		name, _ := rec["recordName"].(string)
+		if errs[i] != nil {
+			results[i] = errs[i]
+			continue
+		}
+		if failed && req.Atomic {
+			results[i] = recordError(name, "ATOMIC_ERROR", "atomic batch aborted")
+			continue
+		}
+		results[i] = s.apply(op)
+	}
+	return map[string]interface{}{"records": results}, http.StatusOK, nil
+}
+
+// validate checks a single modify operation against the current state and
+// returns a CloudKit record error, or nil if the operation can be applied.
+func (s *Server) validate(op map[string]interface{}, seen map[string]bool) map[string]interface{} {
+	opType, _ := op["operationType"].(string)
+	rec, _ := op["record"].(map[string]interface{})
+	name, _ := rec["recordName"].(string)
+	if name == "" {
+		return recordError(name, "BAD_REQUEST", "missing recordName")
+	}
+	if seen[name] {
+		return recordError(name, "BAD_REQUEST", "record appears more than once in request")
+	}
+	seen[name] = true
+
+	existing, exists := s.records[name]
+	if exists && existing.Deleted {
+		exists = false
+	}
+	tag, _ := rec["recordChangeTag"].(string)
+
+	switch opType {
+	case "create":
+		if exists {
+			return recordError(name, "CONFLICT", "record already exists")
+		}
+		if t, _ := rec["recordType"].(string); t == "" {
+			return recordError(name, "BAD_REQUEST", "missing recordType")
+		}
+	case "update", "replace", "delete":
+		if !exists {
+			return recordError(name, "NOT_FOUND", "record not found")
+		}
+		if tag == "" {
+			return recordError(name, "BAD_REQUEST", "missing recordChangeTag")
+		}
+		if tag != existing.ChangeTag {
+			e := recordError(name, "CONFLICT", "record was modified by another client")
+			e["serverRecord"] = s.wire(existing, nil)
+			return e
+		}
+	case "forceUpdate", "forceReplace", "forceDelete":
+		if !exists {
+			return recordError(name, "NOT_FOUND", "record not found")
+		}
+	default:
+		return recordError(name, "BAD_REQUEST", fmt.Sprintf("unsupported operationType %q", opType))
+	}
+	return nil
+}
+
+// apply performs a validated modify operation and returns its result record.
+func (s *Server) apply(op map[string]interface{}) interface{} {
+	opType, _ := op["operationType"].(string)
+	rec, _ := op["record"].(map[string]interface{})
+	name, _ := rec["recordName"].(string)
+	fields, _ := rec["fields"].(map[string]interface{})
+
+	switch opType {
+	case "create":
+		recordType, _ := rec["recordType"].(string)
+		return s.wire(s.store(name, recordType, normalize(fields), true), nil)
+	case "update", "forceUpdate":
+		existing := s.records[name]
+		merged := normalize(existing.Fields)
+		for k, v := range normalize(fields) {
+			merged[k] = v
+		}
+		return s.wire(s.store(name, existing.Type, merged, false), nil)
+	case "replace", "forceReplace":
+		existing := s.records[name]
+		return s.wire(s.store(name, existing.Type, normalize(fields), false), nil)
+	default: // delete, forceDelete
+		s.tombstone(s.records[name])
+		return map[string]interface{}{"recordName": name, "deleted": true}
+	}
+}
+
+// store writes a record, assigning a fresh change tag and sync position.
+// Callers must hold s.mu.
+func (s *Server) store(name, recordType string, fields map[string]interface{}, created bool) *record {
+	now := time.Now().UnixMilli()
+	rec, ok := s.records[name]
+	if !ok || rec.Deleted {
+		rec = &record{Name: name, Created: now}
+		s.records[name] = rec
+	} else if created {
+		rec.Created = now
+	}
+	s.seq++
+	s.tagSeq++
+	rec.Type = recordType
+	rec.Fields = fields
+	rec.ChangeTag = strconv.FormatInt(s.tagSeq, 36)
+	rec.Modified = now
+	rec.Deleted = false
+	rec.seq = s.seq
+	return rec
+}
+
+// tombstone marks a record deleted so delta syncs report the deletion.
+// Callers must hold s.mu.
+func (s *Server) tombstone(rec *record) {
+	s.seq++
+	rec.Deleted = true
+	rec.Fields = nil
+	rec.ChangeTag = ""
+	rec.seq = s.seq
+}
+
+// wire renders a record in CloudKit JSON form, restricted to desiredKeys
+// when any are given.
+func (s *Server) wire(rec *record, desiredKeys []string) map[string]interface{} {
+	if rec.Deleted {
+		return map[string]interface{}{"recordName": rec.Name, "deleted": true}
+	}
+	fields := normalize(rec.Fields)
+	if len(desiredKeys) > 0 {
+		want := make(map[string]bool, len(desiredKeys))
+		for _, k := range desiredKeys {
+			want[k] = true
+		}
+		for k := range fields {
+			if !want[k] {
+				delete(fields, k)
+			}
+		}
+	}
+	return map[string]interface{}{
+		"recordName":      rec.Name,
+		"recordType":      rec.Type,
+		"recordChangeTag": rec.ChangeTag,
+		"fields":          fields,
+		"created":         map[string]interface{}{"timestamp": rec.Created, "userRecordName": s.Owner},
+		"modified":        map[string]interface{}{"timestamp": rec.Modified, "userRecordName": s.Owner},
+		"deleted":         false,
+	}
+}
+
+// token renders a change sequence number as an opaque sync token.
+func (s *Server) token(seq int64) string {
+	return "cktest-" + strconv.FormatInt(seq, 10)
+}
+
+// parseToken is the inverse of token.
+func (s *Server) parseToken(tok string) (int64, error) {
+	v, err := strconv.ParseInt(strings.TrimPrefix(tok, "cktest-"), 10, 64)
+	if err != nil || v < 0 || v > s.seq {
+		return 0, fmt.Errorf("invalid syncToken %q", tok)
+	}
+	return v, nil
+}
+
+// normalize deep-copies fields through a JSON round trip so stored values
+// have the same types (float64, map[string]interface{}) a real response has.
+// CloudKit has no boolean type, so boolean values are stored as INT64 0/1.
+func normalize(fields map[string]interface{}) map[string]interface{} {
+	out := map[string]interface{}{}
+	if fields == nil {
+		return out
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return out
+	}
+	_ = json.Unmarshal(data, &out)
+	for _, f := range out {
+		field, ok := f.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if b, ok := field["value"].(bool); ok {
+			if b {
+				field["value"] = float64(1)
+			} else {
+				field["value"] = float64(0)
+			}
+		}
+	}
+	return out
+}
+
+func recordError(recordName, code, reason string) map[string]interface{} {
+	return map[string]interface{}{
+		"recordName":      recordName,
+		"serverErrorCode": code,
+		"reason":          reason,
+	}
+}
+
+func writeError(rw http.ResponseWriter, status int, code, reason string) {
+	writeJSON(rw, status, map[string]interface{}{
+		"serverErrorCode": code,
+		"reason":          reason,
+	})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	_ = json.NewEncoder(rw).Encode(v)
+}
+
+// WriteSessionFile writes a session file pointing at this server, so CLI
+// commands that load cache.SessionFile talk to the fake instead of iCloud.
+func (s *Server) WriteSessionFile(path string) error {
+	data, err := json.MarshalIndent(s.Session(), "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -38,6 +38,21 @@ const (
 	Zone      = "Reminders"
 )
 
+// API is the set of CloudKit operations the sync engine and writer depend on.
+// *Client implements it against iCloud; tests and tooling may substitute
+// fakes, record/replay transports or caching decorators.
+type API interface {
+	// GetOwnerID returns the owner record name of the Reminders zone.
+	GetOwnerID() (string, error)
+	// ChangesZone fetches one page of zone changes since syncToken
+	// (a full listing when syncToken is empty).
+	ChangesZone(ownerID string, syncToken string) (map[string]interface{}, error)
+	// ModifyRecords applies create/update/delete operations atomically.
+	ModifyRecords(ownerID string, operations []map[string]interface{}) (map[string]interface{}, error)
+}
+
+var _ API = (*Client)(nil)
+
 // Client manages an authenticated CloudKit HTTP session.
 type Client struct {
 	http   *http.Client
@@ -206,11 +221,20 @@ type ZoneID struct {
 	OwnerRecordName string `json:"ownerRecordName"`
 }
 
+// desiredKeys lists the record fields requested from changes/zone.
+var desiredKeys = []string{
+	// Reminder and ReminderList records
+	"TitleDocument", "NotesDocument", "Name", "Completed", "CompletionDate", "DueDate", "List", "Deleted", "Priority", "ParentReminder",
+	"AllDay", "TimeZone", "Color",
+	// RecurrenceRule records
+	"Reminder", "Frequency", "Interval", "EndDate",
+}
+
 // ChangesZone fetches zone changes for delta or full sync.
 func (c *Client) ChangesZone(ownerID string, syncToken string) (map[string]interface{}, error) {
 	spec := ZoneChangesSpec{
 		ZoneID:      ZoneID{ZoneName: Zone, OwnerRecordName: ownerID},
-		DesiredKeys: []string{"TitleDocument", "NotesDocument", "Name", "Completed", "CompletionDate", "DueDate", "List", "Deleted", "Priority", "ParentReminder"},
+		DesiredKeys: desiredKeys,
 	}
 	if syncToken != "" {
 		spec.SyncToken = syncToken
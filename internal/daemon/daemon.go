@@ -0,0 +1,146 @@
+// Package daemon holds what the long-running servers ('reminders serve',
+// 'reminders caldav') share: a sync.Engine kept warm in memory by a
+// background delta-sync loop, HTTP serving with graceful shutdown, request
+// logging and turning writer results into errors.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	gosync "sync"
+	"time"
+
+	"icloud-reminders/internal/logger"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/internal/writer"
+)
+
+// Warm is an engine and writer that stay in memory for the life of a
+// server. The engine and writer are not safe for concurrent use: hold the
+// lock (Lock, Unlock) while using them.
+type Warm struct {
+	Engine *sync.Engine
+	Writer *writer.Writer
+	// SyncInterval is how often the cache is delta-synced in the
+	// background; 0 disables background syncing.
+	SyncInterval time.Duration
+
+	mu       gosync.Mutex
+	lastSync time.Time
+	syncErr  error
+}
+
+// Lock locks the engine and writer.
+func (w *Warm) Lock() { w.mu.Lock() }
+
+// Unlock unlocks the engine and writer.
+func (w *Warm) Unlock() { w.mu.Unlock() }
+
+// Sync syncs the engine; force makes it a full resync. The caller holds
+// the lock.
+func (w *Warm) Sync(force bool) error {
+	w.syncErr = w.Engine.Sync(force)
+	// A re-auth during the sync replaces the engine's client.
+	w.Writer.CK = w.Engine.CK
+	if w.syncErr == nil {
+		w.lastSync = time.Now()
+	}
+	return w.syncErr
+}
+
+// LastSync returns when the engine last synced successfully (zero if it
+// never did) and the error of the latest sync. The caller holds the lock.
+func (w *Warm) LastSync() (time.Time, error) {
+	return w.lastSync, w.syncErr
+}
+
+// Serve syncs once, then serves handler on ln until ctx is cancelled, when
+// it shuts down gracefully. While serving, the engine is delta-synced every
+// SyncInterval.
+func (w *Warm) Serve(ctx context.Context, ln net.Listener, handler http.Handler) error {
+	w.Lock()
+	err := w.Sync(false)
+	w.Unlock()
+	if err != nil {
+		return err
+	}
+
+	srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	if w.SyncInterval > 0 {
+		go w.syncLoop(ctx)
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// syncLoop delta-syncs every SyncInterval until ctx is cancelled. Failures
+// are logged and kept for LastSync; the next tick tries again.
+func (w *Warm) syncLoop(ctx context.Context) {
+	ticker := time.NewTicker(w.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.Lock()
+			if err := w.Sync(false); err != nil {
+				logger.Warnf("background sync failed: %v", err)
+			}
+			w.Unlock()
+		}
+	}
+}
+
+// statusRecorder remembers the status a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// LogRequests logs each request at info level (-v).
+func LogRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		logger.Infof("%s %s → %d (%s)", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+// ResultError is the "error" of a writer result: the writer refused the
+// change (not found, invalid input, stale change tag, ...).
+type ResultError struct {
+	Msg string
+}
+
+func (e *ResultError) Error() string { return e.Msg }
+
+// WriteError returns err, or a *ResultError for a result's "error", or nil.
+// It takes a writer method's return values directly:
+//
+//	err := daemon.WriteError(w.DeleteReminder(id))
+func WriteError(result map[string]interface{}, err error) error {
+	if err != nil {
+		return err
+	}
+	if msg, ok := result["error"].(string); ok {
+		return &ResultError{Msg: msg}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+// Package dateparse parses natural-language and relative dates such as
+// "tomorrow", "next friday 9am", "in 3 days", "eod" or "+2w".
+//
+// Parsing is deterministic: every phrase is resolved relative to the now
+// argument, so callers can inject a fixed clock.
+//
+// Supported forms (case-insensitive):
+//
+//	today, tonight, tomorrow (tmrw, tmr), yesterday
+//	eod                 today at 17:00 (tomorrow once 17:00 has passed)
+//	eow                 this week's Friday at 17:00 (next week's once passed)
+//	monday … sunday     next such day after today (mon, tue, …)
+//	next friday         same as "friday"
+//	this friday         today if it is Friday, else the coming Friday
+//	next week|month|year
+//	in 3 days, in 2 weeks, in 1 month, in 2 hours, in 30 minutes
+//	+3d, +2w, +1m, +1y, +4h, +30min (also -1d)
+//
+// A time of day may follow any date phrase: "tomorrow 9:30", "fri at 2pm".
+// Results with a time of day (or an hour/minute offset) are timed; all others
+// are all-day dates.
+package dateparse
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EndOfDay is the time of day used for "eod" and "eow".
+const EndOfDay = 17
+
+// Tonight is the hour used for "tonight" when no time is given.
+const Tonight = 20
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "tues": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "thur": time.Thursday, "thurs": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday,
+}
+
+// units maps offset unit words to their canonical single-letter form.
+var units = map[string]string{
+	"d": "d", "day": "d", "days": "d",
+	"w": "w", "wk": "w", "wks": "w", "week": "w", "weeks": "w",
+	"m": "m", "mo": "m", "month": "m", "months": "m",
+	"y": "y", "yr": "y", "yrs": "y", "year": "y", "years": "y",
+	"h": "h", "hr": "h", "hrs": "h", "hour": "h", "hours": "h",
+	"min": "min", "mins": "min", "minute": "min", "minutes": "min",
+}
+
+var (
+	// timeOfDayRe matches "9:30", "09:30", "9am", "9:30pm", "21:00".
+	timeOfDayRe = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?\s*(am|pm)?$`)
+	// shortOffsetRe matches "+3d", "-1w", "+30min".
+	shortOffsetRe = regexp.MustCompile(`^([+-])(\d+)\s*([a-z]+)$`)
+)
+
+// Parse resolves s relative to now. The returned time is in now's location;
+// for all-day results only its calendar date is meaningful.
+func Parse(s string, now time.Time) (t time.Time, allDay bool, err error) {
+	phrase := strings.Join(strings.Fields(strings.ToLower(s)), " ")
+	if phrase == "" {
+		return time.Time{}, false, fmt.Errorf("empty date")
+	}
+
+	datePart, hour, minute, hasTime := splitTimeOfDay(phrase)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var day time.Time
+	switch {
+	case datePart == "" && hasTime:
+		day = today
+	case datePart == "today" || datePart == "tod":
+		day = today
+	case datePart == "tonight":
+		day = today
+		if !hasTime {
+			hour, minute, hasTime = Tonight, 0, true
+		}
+	case datePart == "tomorrow" || datePart == "tmrw" || datePart == "tmr":
+		day = today.AddDate(0, 0, 1)
+	case datePart == "yesterday":
+		day = today.AddDate(0, 0, -1)
+	case datePart == "eod":
+		day = today
+		if !hasTime {
+			hour, minute, hasTime = EndOfDay, 0, true
+			if now.Hour() >= EndOfDay {
+				day = today.AddDate(0, 0, 1)
+			}
+		}
+	case datePart == "eow":
+		day = today.AddDate(0, 0, daysUntil(today.Weekday(), time.Friday, true))
+		if !hasTime {
+			hour, minute, hasTime = EndOfDay, 0, true
+			if day.Equal(today) && now.Hour() >= EndOfDay {
+				day = today.AddDate(0, 0, 7)
+			}
+		}
+	default:
+		var ok bool
+		var timed time.Time
+		day, timed, ok, err = parseRelative(datePart, now, today)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("unrecognized date %q: %w", s, err)
+		}
+		if !ok {
+			return time.Time{}, false, fmt.Errorf("unrecognized date %q", s)
+		}
+		if !timed.IsZero() {
+			if hasTime {
+				return time.Time{}, false, fmt.Errorf("unrecognized date %q: time of day cannot follow an hour or minute offset", s)
+			}
+			return timed, false, nil
+		}
+	}
+
+	if hasTime {
+		return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, now.Location()), false, nil
+	}
+	return day, true, nil
+}
+
+// parseRelative handles weekday names and "next …", "in N unit" and
+// "+N unit" offsets. timed is set (and day zero) for hour/minute offsets.
+func parseRelative(phrase string, now, today time.Time) (day, timed time.Time, ok bool, err error) {
+	if wd, found := weekdays[phrase]; found {
+		return today.AddDate(0, 0, daysUntil(today.Weekday(), wd, false)), time.Time{}, true, nil
+	}
+
+	words := strings.Fields(phrase)
+	if len(words) == 2 {
+		switch words[0] {
+		case "next":
+			if wd, found := weekdays[words[1]]; found {
+				return today.AddDate(0, 0, daysUntil(today.Weekday(), wd, false)), time.Time{}, true, nil
+			}
+			switch words[1] {
+			case "week":
+				return today.AddDate(0, 0, 7), time.Time{}, true, nil
+			case "month":
+				return today.AddDate(0, 1, 0), time.Time{}, true, nil
+			case "year":
+				return today.AddDate(1, 0, 0), time.Time{}, true, nil
+			}
+		case "this":
+			if wd, found := weekdays[words[1]]; found {
+				return today.AddDate(0, 0, daysUntil(today.Weekday(), wd, true)), time.Time{}, true, nil
+			}
+		}
+	}
+
+	var sign, amount, unit string
+	if m := shortOffsetRe.FindStringSubmatch(phrase); m != nil {
+		sign, amount, unit = m[1], m[2], m[3]
+	} else if len(words) == 3 && words[0] == "in" {
+		sign, amount, unit = "+", words[1], words[2]
+	} else if len(words) == 2 && words[0] == "in" {
+		// "in 3d"
+		if m := shortOffsetRe.FindStringSubmatch("+" + words[1]); m != nil {
+			sign, amount, unit = m[1], m[2], m[3]
+		}
+	}
+	if amount == "" {
+		return time.Time{}, time.Time{}, false, nil
+	}
+
+	n, convErr := strconv.Atoi(amount)
+	if convErr != nil {
+		return time.Time{}, time.Time{}, false, fmt.Errorf("invalid amount %q", amount)
+	}
+	if sign == "-" {
+		n = -n
+	}
+	switch units[unit] {
+	case "d":
+		return today.AddDate(0, 0, n), time.Time{}, true, nil
+	case "w":
+		return today.AddDate(0, 0, 7*n), time.Time{}, true, nil
+	case "m":
+		return today.AddDate(0, n, 0), time.Time{}, true, nil
+	case "y":
+		return today.AddDate(n, 0, 0), time.Time{}, true, nil
+	case "h":
+		return time.Time{}, now.Add(time.Duration(n) * time.Hour).Truncate(time.Minute), true, nil
+	case "min":
+		return time.Time{}, now.Add(time.Duration(n) * time.Minute).Truncate(time.Minute), true, nil
+	}
+	return time.Time{}, time.Time{}, false, fmt.Errorf("unknown unit %q (use d, w, m, y, h or min)", unit)
+}
+
+// splitTimeOfDay strips a trailing time of day ("9:30", "at 2pm", "9 am")
+// from phrase.
+func splitTimeOfDay(phrase string) (rest string, hour, minute int, ok bool) {
+	words := strings.Fields(phrase)
+	for n := 2; n >= 1; n-- {
+		if len(words) < n {
+			continue
+		}
+		candidate := strings.Join(words[len(words)-n:], " ")
+		h, m, found := parseTimeOfDay(candidate)
+		if !found {
+			continue
+		}
+		rest := words[:len(words)-n]
+		if len(rest) > 0 && rest[len(rest)-1] == "at" {
+			rest = rest[:len(rest)-1]
+		}
+		return strings.Join(rest, " "), h, m, true
+	}
+	return phrase, 0, 0, false
+}
+
+// parseTimeOfDay parses "9:30", "21:00", "9am" or "9:30 pm". A bare number
+// without a colon or am/pm is not a time (so "in 3" stays an offset).
+func parseTimeOfDay(s string) (hour, minute int, ok bool) {
+	m := timeOfDayRe.FindStringSubmatch(s)
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0, 0, false
+	}
+	hour, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	switch m[3] {
+	case "am":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		if hour == 12 {
+			hour = 0
+		}
+	case "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		if hour != 12 {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+// daysUntil returns the number of days from one weekday to the next
+// occurrence of another (1–7, or 0–6 when includeToday is set).
+func daysUntil(from, to time.Weekday, includeToday bool) int {
+	d := (int(to) - int(from) + 7) % 7
+	if d == 0 && !includeToday {
+		d = 7
+	}
+	return d
+}
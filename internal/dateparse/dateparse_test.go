@@ -0,0 +1,218 @@
+package dateparse_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // the DST tests need zoneinfo on any host
+
+	"icloud-reminders/internal/dateparse"
+)
+
+type parseTest struct {
+	in     string
+	want   string // "2006-01-02" for all-day results, else "2006-01-02 15:04 MST"
+	allDay bool
+}
+
+func runParseTests(t *testing.T, now time.Time, tests []parseTest) {
+	t.Helper()
+	for _, tt := range tests {
+		got, allDay, err := dateparse.Parse(tt.in, now)
+		if err != nil {
+			t.Errorf("Parse(%q) at %s: %v", tt.in, now.Format(time.RFC3339), err)
+			continue
+		}
+		layout := "2006-01-02 15:04 MST"
+		if tt.allDay {
+			layout = "2006-01-02"
+		}
+		if allDay != tt.allDay || got.Format(layout) != tt.want {
+			t.Errorf("Parse(%q) at %s = %s (all-day %v), want %s (all-day %v)",
+				tt.in, now.Format(time.RFC3339), got.Format(layout), allDay, tt.want, tt.allDay)
+		}
+		if got.Location() != now.Location() {
+			t.Errorf("Parse(%q) location = %s, want %s", tt.in, got.Location(), now.Location())
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	// Wednesday 2026-10-14, 10:30.
+	now := time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC)
+	runParseTests(t, now, []parseTest{
+		{"today", "2026-10-14", true},
+		{"tomorrow", "2026-10-15", true},
+		{"TMRW", "2026-10-15", true},
+		{"yesterday", "2026-10-13", true},
+		{"tonight", "2026-10-14 20:00 UTC", false},
+		{"eod", "2026-10-14 17:00 UTC", false},
+		{"eow", "2026-10-16 17:00 UTC", false},
+
+		{"in 3 days", "2026-10-17", true},
+		{"in 1 day", "2026-10-15", true},
+		{"in 3d", "2026-10-17", true},
+		{"in 2 weeks", "2026-10-28", true},
+		{"in 1 month", "2026-11-14", true},
+		{"in 2 hours", "2026-10-14 12:30 UTC", false},
+		{"in 30 minutes", "2026-10-14 11:00 UTC", false},
+
+		{"+2w", "2026-10-28", true},
+		{"+3d", "2026-10-17", true},
+		{"-1d", "2026-10-13", true},
+		{"+1m", "2026-11-14", true},
+		{"+1y", "2027-10-14", true},
+		{"+4h", "2026-10-14 14:30 UTC", false},
+		{"+30min", "2026-10-14 11:00 UTC", false},
+
+		{"next week", "2026-10-21", true},
+		{"next month", "2026-11-14", true},
+		{"next year", "2027-10-14", true},
+
+		{"tomorrow 9:30", "2026-10-15 09:30 UTC", false},
+		{"tomorrow at 2pm", "2026-10-15 14:00 UTC", false},
+		{"today 12am", "2026-10-14 00:00 UTC", false},
+		{"today 12pm", "2026-10-14 12:00 UTC", false},
+		{"9 am", "2026-10-14 09:00 UTC", false},
+		{"21:00", "2026-10-14 21:00 UTC", false},
+		{"  Next   FRIDAY  ", "2026-10-16", true},
+	})
+}
+
+func TestParseWeekdays(t *testing.T) {
+	// Wednesday 2026-10-14.
+	now := time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC)
+	runParseTests(t, now, []parseTest{
+		// A bare weekday is the next such day after today, so today's
+		// weekday is a week away.
+		{"thursday", "2026-10-15", true},
+		{"friday", "2026-10-16", true},
+		{"saturday", "2026-10-17", true},
+		{"sunday", "2026-10-18", true},
+		{"monday", "2026-10-19", true},
+		{"tuesday", "2026-10-20", true},
+		{"wednesday", "2026-10-21", true},
+		{"thu", "2026-10-15", true},
+		{"thurs", "2026-10-15", true},
+		{"tues", "2026-10-20", true},
+		{"sun", "2026-10-18", true},
+
+		{"next friday", "2026-10-16", true},
+		{"next wednesday", "2026-10-21", true},
+		{"this friday", "2026-10-16", true},
+		{"this wednesday", "2026-10-14", true},
+
+		{"fri 9am", "2026-10-16 09:00 UTC", false},
+		{"fri at 9:15pm", "2026-10-16 21:15 UTC", false},
+		{"mon 08:00", "2026-10-19 08:00 UTC", false},
+	})
+}
+
+func TestParseOnFriday(t *testing.T) {
+	// Friday 2026-10-16, 09:00.
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	runParseTests(t, now, []parseTest{
+		{"friday", "2026-10-23", true},
+		{"next friday", "2026-10-23", true},
+		{"this friday", "2026-10-16", true},
+		{"fri 9am", "2026-10-23 09:00 UTC", false},
+		{"saturday", "2026-10-17", true},
+		{"thursday", "2026-10-22", true},
+		{"eow", "2026-10-16 17:00 UTC", false},
+	})
+}
+
+func TestParseAfterEndOfDay(t *testing.T) {
+	// Wednesday 2026-10-14, 17:30: today's end of day has passed.
+	now := time.Date(2026, 10, 14, 17, 30, 0, 0, time.UTC)
+	runParseTests(t, now, []parseTest{
+		{"eod", "2026-10-15 17:00 UTC", false},
+		{"eow", "2026-10-16 17:00 UTC", false},
+		// An explicit time is taken as given.
+		{"eod 18:00", "2026-10-14 18:00 UTC", false},
+		{"today", "2026-10-14", true},
+	})
+
+	// Exactly 17:00 counts as passed.
+	runParseTests(t, time.Date(2026, 10, 14, 17, 0, 0, 0, time.UTC), []parseTest{
+		{"eod", "2026-10-15 17:00 UTC", false},
+	})
+	runParseTests(t, time.Date(2026, 10, 14, 16, 59, 0, 0, time.UTC), []parseTest{
+		{"eod", "2026-10-14 17:00 UTC", false},
+	})
+
+	// On a Friday evening the end of the week is next Friday.
+	runParseTests(t, time.Date(2026, 10, 16, 18, 0, 0, 0, time.UTC), []parseTest{
+		{"eod", "2026-10-17 17:00 UTC", false},
+		{"eow", "2026-10-23 17:00 UTC", false},
+	})
+}
+
+func TestParseAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Saturday 2026-10-31, 22:00 EDT; clocks go back to EST at 02:00 on
+	// Sunday 2026-11-01.
+	now := time.Date(2026, 10, 31, 22, 0, 0, 0, ny)
+	runParseTests(t, now, []parseTest{
+		// Day offsets keep the wall clock, not 24-hour steps.
+		{"tomorrow", "2026-11-01", true},
+		{"tomorrow 9am", "2026-11-01 09:00 EST", false},
+		{"in 3 days", "2026-11-03", true},
+		{"monday 9am", "2026-11-02 09:00 EST", false},
+		{"+1w", "2026-11-07", true},
+		{"eod", "2026-11-01 17:00 EST", false},
+		// Hour offsets are elapsed time: 22:00 EDT + 5h is 02:00 EST.
+		{"+5h", "2026-11-01 02:00 EST", false},
+		{"in 3 hours", "2026-11-01 01:00 EDT", false},
+		{"in 4 hours", "2026-11-01 01:00 EST", false},
+	})
+
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Saturday 2026-03-28, 23:30 CET; clocks go forward to CEST at 02:00 on
+	// Sunday 2026-03-29.
+	now = time.Date(2026, 3, 28, 23, 30, 0, 0, berlin)
+	runParseTests(t, now, []parseTest{
+		{"tomorrow", "2026-03-29", true},
+		{"tomorrow 9am", "2026-03-29 09:00 CEST", false},
+		{"in 2 days", "2026-03-30", true},
+		{"friday 17:00", "2026-04-03 17:00 CEST", false},
+		// 23:30 CET + 3h is 03:30 CEST.
+		{"+3h", "2026-03-29 03:30 CEST", false},
+	})
+
+	// All-day results are midnight in now's location, even on the day the
+	// clocks change.
+	day, allDay, err := dateparse.Parse("tomorrow", time.Date(2026, 10, 31, 12, 0, 0, 0, ny))
+	if err != nil || !allDay {
+		t.Fatalf("Parse(tomorrow) = %v, %v, %v", day, allDay, err)
+	}
+	if h, m, _ := day.Clock(); h != 0 || m != 0 {
+		t.Errorf("all-day result is at %02d:%02d, want midnight", h, m)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	now := time.Date(2026, 10, 14, 10, 30, 0, 0, time.UTC)
+	for _, in := range []string{
+		"",
+		"   ",
+		"someday",
+		"next fortnight",
+		"in 3 parsecs",
+		"+3x",
+		"in many days",
+		"25:00",
+		"13pm",
+		"+2h 9am",
+		"friday friday",
+	} {
+		if got, _, err := dateparse.Parse(in, now); err == nil {
+			t.Errorf("Parse(%q) = %s, want an error", in, got)
+		}
+	}
+}
@@ -0,0 +1,560 @@
+// Package filter compiles and evaluates reminder filter expressions such as
+//
+//	list=Work and due<=+3d and priority>=medium and not completed
+//
+// An expression is compiled once with Parse and then matched against any
+// number of reminders. Relative dates are resolved at compile time.
+//
+// Grammar (keywords and field names are case-insensitive):
+//
+//	expr       = term { "or" term }
+//	term       = factor { "and" factor }
+//	factor     = "not" factor | "(" expr ")" | comparison | field
+//	comparison = field op value
+//	op         = "=" | "!=" | "<" | "<=" | ">" | ">=" | "~"
+//
+// Values are bare words (Work, +3d, 2026-10-17) or quoted strings
+// ('Shopping List', "fri 9am"). A bare field name tests that the field is
+// set: "due" matches reminders with a due date, "completed" completed ones.
+//
+// Fields:
+//
+//	title, notes, list   text; = and != compare case-insensitively, ~ matches a substring
+//	id                   reminder ID prefix; = and != only
+//	due                  date; any utils.ParseDue form, or "none"
+//	priority             none < low < medium < high; = != < <= > >=
+//	completed            true/false
+//	repeating            true/false (has a recurrence rule)
+//	subtask              true/false (has a parent reminder)
+package filter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// Filter is a compiled filter expression.
+type Filter struct {
+	src    string
+	root   node
+	fields map[string]bool
+}
+
+// ParseError describes a syntax or type error in a filter expression.
+type ParseError struct {
+	Expr string
+	Pos  int // byte offset into Expr
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid filter at column %d: %s\n  %s\n  %s^", e.Pos+1, e.Msg, e.Expr, strings.Repeat(" ", e.Pos))
+}
+
+// fieldKind is the value type of a filterable field.
+type fieldKind int
+
+const (
+	kindText fieldKind = iota
+	kindID
+	kindDate
+	kindPriority
+	kindBool
+)
+
+var fieldKinds = map[string]fieldKind{
+	"title":     kindText,
+	"notes":     kindText,
+	"list":      kindText,
+	"id":        kindID,
+	"due":       kindDate,
+	"priority":  kindPriority,
+	"completed": kindBool,
+	"repeating": kindBool,
+	"subtask":   kindBool,
+}
+
+// priorityRanks orders priorities so that "priority>=medium" means medium or high.
+var priorityRanks = map[string]int{"none": 0, "low": 1, "medium": 2, "high": 3}
+
+// Parse compiles expr into a Filter. An empty expression yields a nil
+// Filter, which matches every reminder.
+func Parse(expr string) (*Filter, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	toks, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: expr, toks: toks, fields: map[string]bool{}}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t.pos, "unexpected %s", t)
+	}
+	return &Filter{src: expr, root: root, fields: p.fields}, nil
+}
+
+// String returns the source expression.
+func (f *Filter) String() string {
+	return f.src
+}
+
+// Match reports whether r satisfies the filter. A nil Filter matches everything.
+func (f *Filter) Match(r *models.Reminder) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.eval(r)
+}
+
+// Select returns the reminders that satisfy the filter, in order.
+// A nil Filter returns reminders unchanged.
+func (f *Filter) Select(reminders []*models.Reminder) []*models.Reminder {
+	if f == nil {
+		return reminders
+	}
+	var out []*models.Reminder
+	for _, r := range reminders {
+		if f.root.eval(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Uses reports whether the expression refers to field, e.g. so callers can
+// include completed reminders when the filter asks about "completed".
+func (f *Filter) Uses(field string) bool {
+	return f != nil && f.fields[field]
+}
+
+// --- evaluation ---
+
+type node interface {
+	eval(r *models.Reminder) bool
+}
+
+type andNode struct{ left, right node }
+type orNode struct{ left, right node }
+type notNode struct{ inner node }
+
+func (n andNode) eval(r *models.Reminder) bool { return n.left.eval(r) && n.right.eval(r) }
+func (n orNode) eval(r *models.Reminder) bool  { return n.left.eval(r) || n.right.eval(r) }
+func (n notNode) eval(r *models.Reminder) bool { return !n.inner.eval(r) }
+
+// predNode is a compiled comparison or bare-field test.
+type predNode func(r *models.Reminder) bool
+
+func (n predNode) eval(r *models.Reminder) bool { return n(r) }
+
+// textValue returns the text of a text field ("" when unset).
+func textValue(r *models.Reminder, field string) string {
+	switch field {
+	case "title":
+		return r.Title
+	case "notes":
+		if r.Notes != nil {
+			return *r.Notes
+		}
+	case "list":
+		return r.ListName
+	}
+	return ""
+}
+
+func boolValue(r *models.Reminder, field string) bool {
+	switch field {
+	case "completed":
+		return r.Completed
+	case "repeating":
+		return r.Recurrence != nil
+	case "subtask":
+		return r.ParentRef != nil && *r.ParentRef != ""
+	}
+	return false
+}
+
+func priorityRank(p int) int {
+	switch p {
+	case 1:
+		return priorityRanks["high"]
+	case 5:
+		return priorityRanks["medium"]
+	case 9:
+		return priorityRanks["low"]
+	}
+	return priorityRanks["none"]
+}
+
+// compare applies op to the ordering c (-1, 0, 1) of a field against a value.
+func compare(op string, c int) bool {
+	switch op {
+	case "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case "<":
+		return c < 0
+	case "<=":
+		return c <= 0
+	case ">":
+		return c > 0
+	case ">=":
+		return c >= 0
+	}
+	return false
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// dueTime parses a reminder's due string. All-day dates are midnight in
+// utils.Location.
+func dueTime(due string) (time.Time, bool) {
+	if utils.IsAllDay(due) {
+		t, err := time.ParseInLocation(utils.DateLayout, due, utils.Location)
+		return t, err == nil
+	}
+	t, err := time.Parse(time.RFC3339, due)
+	return t, err == nil
+}
+
+// --- compilation ---
+
+// compile builds the predicate for "field op value"; op is an EOF token for
+// a bare field.
+func (p *parser) compile(field token, op, value token) (node, error) {
+	name := strings.ToLower(field.text)
+	kind, ok := fieldKinds[name]
+	if !ok {
+		return nil, p.errorf(field.pos, "unknown field %q (use: %s)", field.text, fieldNames())
+	}
+	p.fields[name] = true
+
+	if op.kind == tokEOF {
+		switch kind {
+		case kindBool:
+			return predNode(func(r *models.Reminder) bool { return boolValue(r, name) }), nil
+		case kindText:
+			return predNode(func(r *models.Reminder) bool { return textValue(r, name) != "" }), nil
+		case kindDate:
+			return predNode(func(r *models.Reminder) bool { return r.Due != nil && *r.Due != "" }), nil
+		case kindPriority:
+			return predNode(func(r *models.Reminder) bool { return r.Priority != 0 }), nil
+		}
+		return nil, p.errorf(field.pos, "field %q needs a comparison, e.g. %s=ABC123", name, name)
+	}
+
+	badOp := func() error {
+		return p.errorf(op.pos, "operator %s cannot be used with %s", op.text, name)
+	}
+	v := value.text
+
+	switch kind {
+	case kindText:
+		want := strings.ToLower(v)
+		switch op.text {
+		case "=", "!=":
+			eq := op.text == "="
+			return predNode(func(r *models.Reminder) bool {
+				return (strings.ToLower(textValue(r, name)) == want) == eq
+			}), nil
+		case "~":
+			return predNode(func(r *models.Reminder) bool {
+				return strings.Contains(strings.ToLower(textValue(r, name)), want)
+			}), nil
+		}
+		return nil, badOp()
+
+	case kindID:
+		if op.text != "=" && op.text != "!=" {
+			return nil, badOp()
+		}
+		eq := op.text == "="
+		want := strings.ToLower(v)
+		return predNode(func(r *models.Reminder) bool {
+			return strings.HasPrefix(strings.ToLower(r.ShortID()), want) == eq
+		}), nil
+
+	case kindBool:
+		if op.text != "=" && op.text != "!=" {
+			return nil, badOp()
+		}
+		var want bool
+		switch strings.ToLower(v) {
+		case "true", "yes", "1":
+			want = true
+		case "false", "no", "0":
+		default:
+			return nil, p.errorf(value.pos, "%s expects true or false, got %q", name, v)
+		}
+		if op.text == "!=" {
+			want = !want
+		}
+		return predNode(func(r *models.Reminder) bool { return boolValue(r, name) == want }), nil
+
+	case kindPriority:
+		if op.text == "~" {
+			return nil, badOp()
+		}
+		rank, ok := priorityRanks[strings.ToLower(v)]
+		if !ok {
+			return nil, p.errorf(value.pos, "invalid priority %q (use: high, medium, low, none)", v)
+		}
+		o := op.text
+		return predNode(func(r *models.Reminder) bool {
+			return compare(o, cmpInt(priorityRank(r.Priority), rank))
+		}), nil
+
+	case kindDate:
+		if op.text == "~" {
+			return nil, badOp()
+		}
+		o := op.text
+		if strings.EqualFold(v, "none") {
+			if o != "=" && o != "!=" {
+				return nil, p.errorf(value.pos, "\"none\" can only be compared with = or !=")
+			}
+			set := o == "!="
+			return predNode(func(r *models.Reminder) bool { return (r.Due != nil && *r.Due != "") == set }), nil
+		}
+		ts, allDay, err := utils.ParseDue(v)
+		if err != nil {
+			return nil, p.errorf(value.pos, "%v", err)
+		}
+		if allDay {
+			// Compare calendar days, so due<=+3d includes timed reminders on that day.
+			day := time.UnixMilli(ts).UTC().Format(utils.DateLayout)
+			return predNode(func(r *models.Reminder) bool {
+				if r.Due == nil || *r.Due == "" {
+					return false
+				}
+				t, ok := dueTime(*r.Due)
+				if !ok {
+					return false
+				}
+				return compare(o, strings.Compare(t.In(utils.Location).Format(utils.DateLayout), day))
+			}), nil
+		}
+		at := time.UnixMilli(ts)
+		return predNode(func(r *models.Reminder) bool {
+			if r.Due == nil || *r.Due == "" {
+				return false
+			}
+			t, ok := dueTime(*r.Due)
+			if !ok {
+				return false
+			}
+			return compare(o, t.Compare(at))
+		}), nil
+	}
+	return nil, badOp()
+}
+
+func fieldNames() string {
+	names := make([]string, 0, len(fieldKinds))
+	for name := range fieldKinds {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// --- parser ---
+
+type parser struct {
+	src    string
+	toks   []token
+	i      int
+	fields map[string]bool
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *parser) errorf(pos int, format string, args ...interface{}) error {
+	return &ParseError{Expr: p.src, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("or") {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().isKeyword("and") {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.peek().isKeyword("not") {
+		p.next()
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{inner}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.next()
+	switch {
+	case t.kind == tokLParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokRParen {
+			return nil, p.errorf(c.pos, "expected ) but found %s", c)
+		}
+		return inner, nil
+	case t.kind == tokWord && !t.isKeyword("and") && !t.isKeyword("or") && !t.isKeyword("not"):
+		if p.peek().kind != tokOp {
+			return p.compile(t, token{kind: tokEOF}, token{})
+		}
+		op := p.next()
+		v := p.next()
+		if v.kind != tokWord && v.kind != tokString {
+			return nil, p.errorf(v.pos, "expected a value after %s%s but found %s", t.text, op.text, v)
+		}
+		return p.compile(t, op, v)
+	case t.kind == tokEOF:
+		return nil, p.errorf(t.pos, "expression ends early — expected a field name")
+	}
+	return nil, p.errorf(t.pos, "expected a field name but found %s", t)
+}
+
+// --- tokenizer ---
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokString
+	tokOp
+	tokLParen
+	tokRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return fmt.Sprintf("%q", t.text)
+	}
+	return "\"" + t.text + "\""
+}
+
+func (t token) isKeyword(kw string) bool {
+	return t.kind == tokWord && strings.EqualFold(t.text, kw)
+}
+
+// operators lists comparison operators, longest first.
+var operators = []string{"<=", ">=", "!=", "=", "<", ">", "~"}
+
+// wordBreaks are the characters that end a bare word.
+const wordBreaks = " \t\n()<>=!~\"'"
+
+func tokenize(src string) ([]token, error) {
+	var toks []token
+	i := 0
+	for i < len(src) {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			toks = append(toks, token{tokLParen, "(", i})
+			i++
+		case c == ')':
+			toks = append(toks, token{tokRParen, ")", i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(src[i+1:], c)
+			if end < 0 {
+				return nil, &ParseError{Expr: src, Pos: i, Msg: "unterminated quoted string"}
+			}
+			toks = append(toks, token{tokString, src[i+1 : i+1+end], i})
+			i += end + 2
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(src[i:], op) {
+					toks = append(toks, token{tokOp, op, i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+			if c == '!' {
+				return nil, &ParseError{Expr: src, Pos: i, Msg: "unexpected \"!\" (use \"not\" or \"!=\")"}
+			}
+			start := i
+			for i < len(src) && !strings.ContainsRune(wordBreaks, rune(src[i])) {
+				i++
+			}
+			toks = append(toks, token{tokWord, src[start:i], start})
+		}
+	}
+	return append(toks, token{tokEOF, "", len(src)}), nil
+}
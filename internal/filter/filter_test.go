@@ -0,0 +1,310 @@
+package filter_test
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"icloud-reminders/internal/filter"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// now is Friday 2026-10-16, 12:00 UTC.
+var now = time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+// fixClock resolves relative dates against at, in UTC, for one test.
+func fixClock(t *testing.T, at time.Time) {
+	t.Helper()
+	oldNow, oldLoc := utils.Now, utils.Location
+	utils.Now = func() time.Time { return at }
+	utils.Location = time.UTC
+	t.Cleanup(func() { utils.Now, utils.Location = oldNow, oldLoc })
+}
+
+func str(s string) *string { return &s }
+
+// reminders are the fixtures, keyed by a one-letter name used in the tables:
+//
+//	a  Buy milk      Groceries  high    due 2026-10-16 (today)      notes
+//	b  Write report  Work       medium  due 2026-10-19 (+3d)        notes, repeating
+//	c  Call Bob      Work       low     due 2026-10-20 09:00 UTC    completed
+//	d  Fix bike      Home       none    no due date                 subtask
+var reminders = map[string]*models.Reminder{
+	"a": {ID: "Reminder/AAAA1111-0000-0000-0000-000000000000", Title: "Buy milk", ListName: "Groceries",
+		Priority: 1, Due: str("2026-10-16"), Notes: str("2% fat")},
+	"b": {ID: "Reminder/BBBB2222-0000-0000-0000-000000000000", Title: "Write report", ListName: "Work",
+		Priority: 5, Due: str("2026-10-19"), Notes: str("Quarterly"),
+		Recurrence: &models.Recurrence{Frequency: "weekly", Interval: 1}},
+	"c": {ID: "Reminder/CCCC3333-0000-0000-0000-000000000000", Title: "Call Bob", ListName: "Work",
+		Priority: 9, Due: str("2026-10-20T09:00:00Z"), Completed: true},
+	"d": {ID: "Reminder/DDDD4444-0000-0000-0000-000000000000", Title: "Fix bike", ListName: "Home",
+		ParentRef: str("Reminder/AAAA1111-0000-0000-0000-000000000000")},
+}
+
+// matching returns the names of the fixtures f matches, sorted and joined.
+func matching(f *filter.Filter) string {
+	var names []string
+	for name, r := range reminders {
+		if f.Match(r) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+type matchTest struct {
+	expr string
+	want string // matching fixtures, e.g. "a,c"
+}
+
+func runMatchTests(t *testing.T, tests []matchTest) {
+	t.Helper()
+	for _, tt := range tests {
+		f, err := filter.Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if got := matching(f); got != tt.want {
+			t.Errorf("%q matches %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	fixClock(t, now)
+	runMatchTests(t, []matchTest{
+		// and binds tighter than or.
+		{"list=Work or list=Home and priority=none", "b,c,d"},
+		{"list=Home and priority=none or list=Work", "b,c,d"},
+		{"(list=Work or list=Home) and priority=none", "d"},
+		// not binds tighter than and.
+		{"not completed and list=Work", "b"},
+		{"not (completed and list=Work)", "a,b,d"},
+		{"not not completed", "c"},
+		{"list=Groceries or list=Home or completed", "a,c,d"},
+		{"((list=Work))", "b,c"},
+		// Keywords and field names are case-insensitive.
+		{"LIST=work AND NOT Completed", "b"},
+		{"list=Work Or list=Home", "b,c,d"},
+	})
+}
+
+func TestOperators(t *testing.T) {
+	fixClock(t, now)
+	runMatchTests(t, []matchTest{
+		// Text fields compare case-insensitively; ~ is a substring match.
+		{"title='buy milk'", "a"},
+		{"title!='Buy Milk'", "b,c,d"},
+		{"title~BO", "c"},
+		{"notes=quarterly", "b"},
+		{"notes!=quarterly", "a,c,d"},
+		{"notes~FAT", "a"},
+		{"list=work", "b,c"},
+		{"list!=work", "a,d"},
+		{"list~ome", "d"},
+
+		// id matches a prefix of the UUID.
+		{"id=aaaa", "a"},
+		{"id=AAAA1111-0000-0000-0000-000000000000", "a"},
+		{"id!=aaaa", "b,c,d"},
+
+		// Dates compare by calendar day against all-day values; reminders
+		// without a due date never match a comparison.
+		{"due=2026-10-16", "a"},
+		{"due!=2026-10-16", "b,c"},
+		{"due<2026-10-19", "a"},
+		{"due<=2026-10-19", "a,b"},
+		{"due>2026-10-19", "c"},
+		{"due>=2026-10-19", "b,c"},
+		{"due=2026-10-20", "c"},
+		{"due=none", "d"},
+		{"due!=none", "a,b,c"},
+		// Timed values compare instants.
+		{"due>='2026-10-20 08:00'", "c"},
+		{"due<'2026-10-20 09:00'", "a,b"},
+		{"due<='2026-10-20 09:00'", "a,b,c"},
+
+		{"priority=high", "a"},
+		{"priority!=high", "b,c,d"},
+		{"priority<medium", "c,d"},
+		{"priority<=medium", "b,c,d"},
+		{"priority>low", "a,b"},
+		{"priority>=medium", "a,b"},
+		{"priority=none", "d"},
+
+		{"completed=true", "c"},
+		{"completed!=true", "a,b,d"},
+		{"completed=no", "a,b,d"},
+		{"repeating=yes", "b"},
+		{"repeating=false", "a,c,d"},
+		{"subtask=1", "d"},
+		{"subtask!=false", "d"},
+
+		// A bare field tests that it is set.
+		{"title", "a,b,c,d"},
+		{"notes", "a,b"},
+		{"due", "a,b,c"},
+		{"priority", "a,b,c"},
+		{"completed", "c"},
+		{"repeating", "b"},
+		{"subtask", "d"},
+	})
+}
+
+func TestOperatorsRejected(t *testing.T) {
+	for _, expr := range []string{
+		"title<abc", "title<=abc", "title>abc", "title>=abc",
+		"notes<abc", "list>work",
+		"id~aaaa", "id<aaaa", "id>=aaaa", "id",
+		"due~2026", "due<none", "due>=none",
+		"priority~high", "priority=urgent",
+		"completed~true", "completed<true", "completed=maybe",
+		"repeating>=true", "subtask~yes",
+		"nosuchfield=1", "due=someday",
+	} {
+		if _, err := filter.Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+}
+
+func TestRelativeDates(t *testing.T) {
+	fixClock(t, now)
+	runMatchTests(t, []matchTest{
+		{"due<=+3d", "a,b"},
+		{"due<+3d", "a"},
+		{"due=today", "a"},
+		{"due<today", ""},
+		{"due>=+4d", "c"},
+		{"due<=+4d", "a,b,c"},
+		{"due<=monday", "a,b"},
+		{"due<'tomorrow 9am'", "a"},
+	})
+
+	// A day later, +3d reaches the timed reminder on 2026-10-20.
+	fixClock(t, now.AddDate(0, 0, 1))
+	runMatchTests(t, []matchTest{
+		{"due<=+3d", "a,b,c"},
+		{"due<today", "a"},
+	})
+}
+
+func TestRelativeDatesResolvedAtParse(t *testing.T) {
+	fixClock(t, now)
+	f, err := filter.Parse("due<=+3d")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixClock(t, now.AddDate(0, 0, 1))
+	if got := matching(f); got != "a,b" {
+		t.Errorf("matches %q after the clock moved, want %q", got, "a,b")
+	}
+}
+
+func TestPriorityOrdering(t *testing.T) {
+	// Lowest to highest, with their CloudKit values.
+	levels := []struct {
+		name  string
+		value int
+	}{{"none", 0}, {"low", 9}, {"medium", 5}, {"high", 1}}
+	ops := map[string]func(i, j int) bool{
+		"=":  func(i, j int) bool { return i == j },
+		"!=": func(i, j int) bool { return i != j },
+		"<":  func(i, j int) bool { return i < j },
+		"<=": func(i, j int) bool { return i <= j },
+		">":  func(i, j int) bool { return i > j },
+		">=": func(i, j int) bool { return i >= j },
+	}
+	for op, want := range ops {
+		for j, bound := range levels {
+			expr := "priority" + op + bound.name
+			f, err := filter.Parse(expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", expr, err)
+			}
+			for i, level := range levels {
+				r := &models.Reminder{Priority: level.value}
+				if got := f.Match(r); got != want(i, j) {
+					t.Errorf("%q on a %s reminder = %v, want %v", expr, level.name, got, want(i, j))
+				}
+			}
+		}
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		expr string
+		col  int // 1-based, as reported
+		msg  string
+	}{
+		{"list=Work and prio>=medium", 15, `unknown field "prio"`},
+		{"priority>=urgent", 11, `invalid priority "urgent"`},
+		{"title<abc", 6, "operator < cannot be used with title"},
+		{"completed=maybe", 11, "completed expects true or false"},
+		{"title='abc", 7, "unterminated quoted string"},
+		{"!completed", 1, `unexpected "!"`},
+		{"(list=Work", 11, "expected ) but found end of expression"},
+		{"list=Work and", 14, "expression ends early"},
+		{"list=", 6, "expected a value after list="},
+		{"list=Work list=Home", 11, `unexpected "list"`},
+		{"and completed", 1, `expected a field name but found "and"`},
+		{"id", 1, `field "id" needs a comparison`},
+	}
+	for _, tt := range tests {
+		_, err := filter.Parse(tt.expr)
+		var pe *filter.ParseError
+		if !errors.As(err, &pe) {
+			t.Errorf("Parse(%q) error = %v, want a *ParseError", tt.expr, err)
+			continue
+		}
+		if pe.Pos+1 != tt.col {
+			t.Errorf("Parse(%q) column = %d, want %d", tt.expr, pe.Pos+1, tt.col)
+		}
+		if !strings.Contains(pe.Msg, tt.msg) {
+			t.Errorf("Parse(%q) message = %q, want it to contain %q", tt.expr, pe.Msg, tt.msg)
+		}
+	}
+}
+
+func TestParseErrorCaret(t *testing.T) {
+	_, err := filter.Parse("list=Work and prio>=medium")
+	if err == nil {
+		t.Fatal("Parse succeeded")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("error has %d lines, want 3:\n%s", len(lines), err)
+	}
+	if !strings.HasPrefix(lines[0], "invalid filter at column 15: unknown field \"prio\"") {
+		t.Errorf("first line = %q", lines[0])
+	}
+	if want := "  list=Work and prio>=medium"; lines[1] != want {
+		t.Errorf("second line = %q, want %q", lines[1], want)
+	}
+	// The caret sits under the "p" of "prio".
+	if want := "  " + strings.Repeat(" ", 14) + "^"; lines[2] != want {
+		t.Errorf("third line = %q, want %q", lines[2], want)
+	}
+	if caret, expr := strings.Index(lines[2], "^"), lines[1]; expr[caret:caret+4] != "prio" {
+		t.Errorf("caret points at %q, want prio", expr[caret:])
+	}
+}
+
+func TestEmptyExpressionMatchesEverything(t *testing.T) {
+	f, err := filter.Parse("  ")
+	if err != nil || f != nil {
+		t.Fatalf("Parse(blank) = %v, %v; want nil, nil", f, err)
+	}
+	if got := matching(f); got != "a,b,c,d" {
+		t.Errorf("nil filter matches %q, want all", got)
+	}
+	if got := f.Select([]*models.Reminder{reminders["a"]}); len(got) != 1 {
+		t.Errorf("nil filter selected %d reminders, want 1", len(got))
+	}
+}
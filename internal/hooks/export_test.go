@@ -0,0 +1,11 @@
+package hooks
+
+import "time"
+
+// SetRetryDelay sets the wait before the first retry and returns a function
+// that restores it.
+func SetRetryDelay(d time.Duration) (restore func()) {
+	old := retryDelay
+	retryDelay = d
+	return func() { retryDelay = old }
+}
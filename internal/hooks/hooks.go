@@ -0,0 +1,251 @@
+// Package hooks runs local commands and HTTP callbacks when reminders
+// change ('reminders watch --hooks').
+//
+// Hooks are configured in a JSON file (DefaultFile):
+//
+//	{"hooks": [
+//	  {"name": "ops-intake", "events": ["created"], "where": "list=Ops",
+//	   "command": ["/usr/local/bin/ops-intake", "--quiet"]},
+//	  {"name": "done-high", "events": ["completed"], "where": "priority=high",
+//	   "url": "http://127.0.0.1:8000/reminders", "timeout": "5s", "retries": 2}
+//	]}
+//
+// A hook fires for a change whose type is in events (any type if empty) and
+// whose reminder matches where, a filter expression as in 'reminders list
+// --where' (see 'reminders help filters'), evaluated on the reminder as it
+// is after the change (before it, for deletions). Hooks with a where never
+// fire for list_renamed.
+//
+// The change is passed as the JSON object 'reminders watch' prints: on stdin
+// to a command, which also gets REMINDERS_EVENT_TYPE and REMINDERS_EVENT_ID
+// in its environment, or as the body of a POST to url. A hook fails if the
+// command exits non-zero, the POST does not return 2xx, or either takes
+// longer than timeout (default 10s); it is then retried up to retries times
+// with exponential backoff. Hooks run one at a time, in order.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/filter"
+	"icloud-reminders/internal/logger"
+	"icloud-reminders/internal/sync"
+)
+
+// DefaultFile is the default hooks configuration file.
+var DefaultFile = filepath.Join(cache.ConfigDir, "hooks.json")
+
+// DefaultTimeout is how long a hook may run when it sets no timeout.
+const DefaultTimeout = 10 * time.Second
+
+// retryDelay is the wait before the first retry; it doubles for each one.
+var retryDelay = time.Second
+
+// waitDelay is how long a timed-out command's I/O may outlive it.
+const waitDelay = time.Second
+
+// Config is a hooks configuration file.
+type Config struct {
+	Hooks []*Hook `json:"hooks"`
+}
+
+// Hook is one configured hook. Exactly one of Command and URL is set.
+type Hook struct {
+	Name    string   `json:"name"`
+	Events  []string `json:"events,omitempty"`
+	Where   string   `json:"where,omitempty"`
+	Command []string `json:"command,omitempty"`
+	URL     string   `json:"url,omitempty"`
+	Timeout string   `json:"timeout,omitempty"`
+	Retries int      `json:"retries,omitempty"`
+
+	timeout time.Duration
+}
+
+// changeTypes are the valid event names.
+var changeTypes = map[sync.ChangeType]bool{
+	sync.ChangeCreated:     true,
+	sync.ChangeUpdated:     true,
+	sync.ChangeCompleted:   true,
+	sync.ChangeDeleted:     true,
+	sync.ChangeListRenamed: true,
+}
+
+// Load reads and validates a hooks configuration file.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	names := make(map[string]bool)
+	for i, h := range cfg.Hooks {
+		if err := h.check(); err != nil {
+			return nil, fmt.Errorf("%s: hook %d: %w", path, i+1, err)
+		}
+		if names[h.Name] {
+			return nil, fmt.Errorf("%s: hook %d: duplicate name %q", path, i+1, h.Name)
+		}
+		names[h.Name] = true
+	}
+	return &cfg, nil
+}
+
+// check validates a hook and fills in its defaults.
+func (h *Hook) check() error {
+	if h.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if (len(h.Command) == 0) == (h.URL == "") {
+		return fmt.Errorf("%s: set exactly one of command and url", h.Name)
+	}
+	if h.URL != "" {
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s: url must be an http or https URL", h.Name)
+		}
+	}
+	for _, ev := range h.Events {
+		if !changeTypes[sync.ChangeType(ev)] {
+			return fmt.Errorf("%s: unknown event %q (use: created, updated, completed, deleted, list_renamed)", h.Name, ev)
+		}
+	}
+	if _, err := filter.Parse(h.Where); err != nil {
+		return fmt.Errorf("%s: where: %w", h.Name, err)
+	}
+	h.timeout = DefaultTimeout
+	if h.Timeout != "" {
+		d, err := time.ParseDuration(h.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("%s: invalid timeout %q", h.Name, h.Timeout)
+		}
+		h.timeout = d
+	}
+	if h.Retries < 0 {
+		return fmt.Errorf("%s: retries must not be negative", h.Name)
+	}
+	return nil
+}
+
+// Matches reports whether the hook fires for c. The where expression is
+// compiled for each change so relative dates stay current in a long watch.
+func (h *Hook) Matches(c *sync.Change) bool {
+	if len(h.Events) > 0 {
+		found := false
+		for _, ev := range h.Events {
+			found = found || sync.ChangeType(ev) == c.Type
+		}
+		if !found {
+			return false
+		}
+	}
+	if h.Where == "" {
+		return true
+	}
+	r := c.Reminder()
+	if r == nil {
+		return false
+	}
+	where, err := filter.Parse(h.Where)
+	return err == nil && where.Match(r)
+}
+
+// Dispatch runs the hooks that match each change, in order. Failures are
+// logged; they do not stop the other hooks.
+func (cfg *Config) Dispatch(ctx context.Context, changes []*sync.Change) {
+	for _, c := range changes {
+		for _, h := range cfg.Hooks {
+			if ctx.Err() != nil {
+				return
+			}
+			if !h.Matches(c) {
+				continue
+			}
+			if err := h.Run(ctx, c); err != nil {
+				logger.Warnf("hook %s: %s %s: %v", h.Name, c.Type, c.ID, err)
+			} else {
+				logger.Infof("hook %s: %s %s: ok", h.Name, c.Type, c.ID)
+			}
+		}
+	}
+}
+
+// Run runs the hook for c, retrying failed attempts.
+func (h *Hook) Run(ctx context.Context, c *sync.Change) error {
+	event, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	delay := retryDelay
+	for attempt := 0; ; attempt++ {
+		err = h.once(ctx, c, event)
+		if err == nil || attempt >= h.Retries {
+			return err
+		}
+		logger.Debugf("hook %s: attempt %d failed: %v; retrying in %s", h.Name, attempt+1, err, delay)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// once makes one attempt to run the hook.
+func (h *Hook) once(ctx context.Context, c *sync.Change, event []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	if h.URL != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(event))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "icloud-reminders")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("POST %s: %s", h.URL, resp.Status)
+		}
+		return nil
+	}
+
+	cmd := exec.CommandContext(ctx, h.Command[0], h.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(event)
+	// Standard output is reserved for the events of 'reminders watch'.
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "REMINDERS_EVENT_TYPE="+string(c.Type), "REMINDERS_EVENT_ID="+c.ID)
+	// Once the command is killed, do not wait for children that still hold
+	// its stdin or output open.
+	cmd.WaitDelay = waitDelay
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s", h.timeout)
+		}
+		return err
+	}
+	return nil
+}
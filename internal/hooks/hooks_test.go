@@ -0,0 +1,326 @@
+package hooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	gosync "sync"
+	"testing"
+	"time"
+
+	"icloud-reminders/internal/hooks"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/pkg/models"
+)
+
+// load writes a hooks file and loads it.
+func load(t *testing.T, text string) (*hooks.Config, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hooks.json")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return hooks.Load(path)
+}
+
+// hook returns the only hook of a valid configuration.
+func hook(t *testing.T, text string) *hooks.Hook {
+	t.Helper()
+	cfg, err := load(t, `{"hooks": [`+text+`]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg.Hooks[0]
+}
+
+func change(typ sync.ChangeType, r *models.Reminder) *sync.Change {
+	c := &sync.Change{Type: typ, Time: "2026-10-16T12:00:00Z", ID: "Reminder/" + r.Title}
+	if typ == sync.ChangeDeleted {
+		c.Before = r
+	} else {
+		c.After = r
+	}
+	return c
+}
+
+// recorder is an HTTP callback that answers with the given statuses in
+// turn (200 once they run out) and records the requests it gets.
+type recorder struct {
+	mu       gosync.Mutex
+	statuses []int
+	delay    time.Duration
+	bodies   []string
+	paths    []string
+	times    []time.Time
+	header   http.Header
+}
+
+func (rec *recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rec.mu.Lock()
+	rec.bodies = append(rec.bodies, string(body))
+	rec.paths = append(rec.paths, r.URL.Path)
+	rec.times = append(rec.times, time.Now())
+	rec.header = r.Header
+	status := http.StatusOK
+	if len(rec.statuses) > 0 {
+		status, rec.statuses = rec.statuses[0], rec.statuses[1:]
+	}
+	rec.mu.Unlock()
+	if rec.delay > 0 {
+		select {
+		case <-time.After(rec.delay):
+		case <-r.Context().Done():
+		}
+	}
+	w.WriteHeader(status)
+}
+
+func (rec *recorder) calls() int {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return len(rec.bodies)
+}
+
+func TestLoad(t *testing.T) {
+	cfg, err := load(t, `{"hooks": [
+		{"name": "cmd", "command": ["true"]},
+		{"name": "web", "events": ["completed", "list_renamed"], "where": "priority=high",
+		 "url": "https://example.com/hook", "timeout": "2s", "retries": 3}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Hooks) != 2 {
+		t.Fatalf("loaded %d hooks, want 2", len(cfg.Hooks))
+	}
+	if h := cfg.Hooks[1]; h.Name != "web" || len(h.Events) != 2 || h.Retries != 3 || h.URL != "https://example.com/hook" {
+		t.Errorf("hook 2 = %+v", h)
+	}
+
+	for _, tc := range []struct{ text, want string }{
+		{`{"hooks": [{"name": "a", "command": ["true"], "on": "x"}]}`, `unknown field "on"`},
+		{`{"hooks": [`, "unexpected EOF"},
+		{`{"hooks": [{"command": ["true"]}]}`, "hook 1: name is required"},
+		{`{"hooks": [{"name": "a"}]}`, "a: set exactly one of command and url"},
+		{`{"hooks": [{"name": "a", "command": ["true"], "url": "http://x"}]}`, "set exactly one"},
+		{`{"hooks": [{"name": "a", "url": "ftp://x/y"}]}`, "url must be an http or https URL"},
+		{`{"hooks": [{"name": "a", "url": "http://"}]}`, "url must be"},
+		{`{"hooks": [{"name": "a", "command": ["true"], "events": ["moved"]}]}`, `unknown event "moved"`},
+		{`{"hooks": [{"name": "a", "command": ["true"], "where": "colour=red"}]}`, "a: where:"},
+		{`{"hooks": [{"name": "a", "command": ["true"], "timeout": "soon"}]}`, `invalid timeout "soon"`},
+		{`{"hooks": [{"name": "a", "command": ["true"], "timeout": "0s"}]}`, "invalid timeout"},
+		{`{"hooks": [{"name": "a", "command": ["true"], "retries": -1}]}`, "retries must not be negative"},
+		{`{"hooks": [{"name": "a", "command": ["true"]}, {"name": "a", "url": "http://x"}]}`, `hook 2: duplicate name "a"`},
+	} {
+		_, err := load(t, tc.text)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("Load(%s) = %v, want an error containing %q", tc.text, err, tc.want)
+		}
+	}
+
+	if _, err := hooks.Load(filepath.Join(t.TempDir(), "missing.json")); !os.IsNotExist(err) {
+		t.Errorf("Load(missing file) = %v, want a not-exist error", err)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	ops := &models.Reminder{Title: "Deploy", ListName: "Ops", Priority: 1}
+	home := &models.Reminder{Title: "Dishes", ListName: "Home"}
+	renamed := &sync.Change{
+		Type:   sync.ChangeListRenamed,
+		ID:     "List/1",
+		Before: &models.ReminderList{ID: "List/1", Name: "Ops"},
+		After:  &models.ReminderList{ID: "List/1", Name: "Operations"},
+	}
+	tests := []struct {
+		hook   string
+		change *sync.Change
+		want   bool
+	}{
+		{`{"name": "all", "command": ["true"]}`, change(sync.ChangeUpdated, home), true},
+		{`{"name": "all", "command": ["true"]}`, renamed, true},
+		{`{"name": "c", "command": ["true"], "events": ["created"]}`, change(sync.ChangeCreated, home), true},
+		{`{"name": "c", "command": ["true"], "events": ["created"]}`, change(sync.ChangeCompleted, home), false},
+		{`{"name": "cd", "command": ["true"], "events": ["created", "deleted"]}`, change(sync.ChangeDeleted, home), true},
+		{`{"name": "w", "command": ["true"], "where": "list=ops"}`, change(sync.ChangeCreated, ops), true},
+		{`{"name": "w", "command": ["true"], "where": "list=ops"}`, change(sync.ChangeCreated, home), false},
+		// Deletions are matched on the reminder as it was.
+		{`{"name": "w", "command": ["true"], "where": "list=ops"}`, change(sync.ChangeDeleted, ops), true},
+		{`{"name": "both", "command": ["true"], "events": ["completed"], "where": "priority=high"}`, change(sync.ChangeCompleted, ops), true},
+		{`{"name": "both", "command": ["true"], "events": ["completed"], "where": "priority=high"}`, change(sync.ChangeUpdated, ops), false},
+		{`{"name": "both", "command": ["true"], "events": ["completed"], "where": "priority=high"}`, change(sync.ChangeCompleted, home), false},
+		// A where never matches a list rename, even one that would match.
+		{`{"name": "l", "command": ["true"], "events": ["list_renamed"]}`, renamed, true},
+		{`{"name": "l", "command": ["true"], "events": ["list_renamed"], "where": "list=ops"}`, renamed, false},
+		{`{"name": "l", "command": ["true"], "events": ["list_renamed"], "where": "not completed"}`, renamed, false},
+	}
+	for _, tt := range tests {
+		if got := hook(t, tt.hook).Matches(tt.change); got != tt.want {
+			t.Errorf("%s matches %s %s = %v, want %v", tt.hook, tt.change.Type, tt.change.ID, got, tt.want)
+		}
+	}
+}
+
+func TestRunPostsEvent(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	h := hook(t, `{"name": "web", "url": "`+srv.URL+`"}`)
+
+	c := change(sync.ChangeCreated, &models.Reminder{Title: "Deploy", ListName: "Ops"})
+	if err := h.Run(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	if rec.calls() != 1 {
+		t.Fatalf("%d POSTs, want 1", rec.calls())
+	}
+	want, _ := json.Marshal(c)
+	if rec.bodies[0] != string(want) {
+		t.Errorf("body = %s, want %s", rec.bodies[0], want)
+	}
+	if got := rec.header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q", got)
+	}
+}
+
+func TestRunRetriesWithBackoff(t *testing.T) {
+	defer hooks.SetRetryDelay(20 * time.Millisecond)()
+	c := change(sync.ChangeCreated, &models.Reminder{Title: "Deploy"})
+
+	rec := &recorder{statuses: []int{500, 503}}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	h := hook(t, `{"name": "web", "url": "`+srv.URL+`", "retries": 2}`)
+	if err := h.Run(context.Background(), c); err != nil {
+		t.Fatalf("Run = %v, want success on the third attempt", err)
+	}
+	if rec.calls() != 3 {
+		t.Fatalf("%d attempts, want 3", rec.calls())
+	}
+	// The delay doubles: 20ms, then 40ms.
+	if gap := rec.times[1].Sub(rec.times[0]); gap < 20*time.Millisecond {
+		t.Errorf("first retry after %s, want at least 20ms", gap)
+	}
+	if gap := rec.times[2].Sub(rec.times[1]); gap < 40*time.Millisecond {
+		t.Errorf("second retry after %s, want at least 40ms", gap)
+	}
+
+	rec = &recorder{statuses: []int{500, 500, 500}}
+	srv2 := httptest.NewServer(rec)
+	defer srv2.Close()
+	h = hook(t, `{"name": "web", "url": "`+srv2.URL+`", "retries": 1}`)
+	err := h.Run(context.Background(), c)
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Errorf("Run = %v, want the 500 of the last attempt", err)
+	}
+	if rec.calls() != 2 {
+		t.Errorf("%d attempts, want 2", rec.calls())
+	}
+
+	// Cancelling stops the retries.
+	rec = &recorder{statuses: []int{500, 500, 500}}
+	srv3 := httptest.NewServer(rec)
+	defer srv3.Close()
+	defer hooks.SetRetryDelay(time.Hour)()
+	h = hook(t, `{"name": "web", "url": "`+srv3.URL+`", "retries": 2}`)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := h.Run(ctx, c); err == nil {
+		t.Error("cancelled Run succeeded")
+	}
+	if rec.calls() != 1 {
+		t.Errorf("%d attempts after cancel, want 1", rec.calls())
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	c := change(sync.ChangeCreated, &models.Reminder{Title: "Deploy"})
+
+	rec := &recorder{delay: 5 * time.Second}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	start := time.Now()
+	err := hook(t, `{"name": "web", "url": "`+srv.URL+`", "timeout": "50ms"}`).Run(context.Background(), c)
+	if err == nil {
+		t.Error("slow POST succeeded")
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("slow POST took %s", d)
+	}
+
+	// The shell's child outlives the killed shell; Run does not wait for it.
+	start = time.Now()
+	err = hook(t, `{"name": "cmd", "command": ["sh", "-c", "sleep 3 >/dev/null 2>&1; true"], "timeout": "50ms"}`).Run(context.Background(), c)
+	if err == nil || !strings.Contains(err.Error(), "timed out after 50ms") {
+		t.Errorf("Run = %v, want a timeout", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("slow command took %s", d)
+	}
+}
+
+func TestRunCommand(t *testing.T) {
+	defer hooks.SetRetryDelay(time.Millisecond)()
+	out := filepath.Join(t.TempDir(), "out")
+	c := change(sync.ChangeCompleted, &models.Reminder{Title: "Deploy"})
+
+	h := hook(t, `{"name": "cmd", "command": ["sh", "-c",
+		"cat > \"$1\"; echo \"$REMINDERS_EVENT_TYPE $REMINDERS_EVENT_ID\" >> \"$1\"", "sh", "`+out+`"]}`)
+	if err := h.Run(context.Background(), c); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event, _ := json.Marshal(c)
+	if want := string(event) + "completed Reminder/Deploy\n"; string(data) != want {
+		t.Errorf("command got %q, want %q", data, want)
+	}
+
+	// A non-zero exit fails the attempt; each one appends a line.
+	h = hook(t, `{"name": "fail", "command": ["sh", "-c", "echo x >> \"$1\"; exit 3", "sh", "`+out+`"], "retries": 2}`)
+	os.Remove(out)
+	if err := h.Run(context.Background(), c); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Run = %v, want exit status 3", err)
+	}
+	if data, _ := os.ReadFile(out); string(data) != "x\nx\nx\n" {
+		t.Errorf("%d attempts, want 3", strings.Count(string(data), "x"))
+	}
+}
+
+func TestDispatch(t *testing.T) {
+	rec := &recorder{}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+	cfg, err := load(t, `{"hooks": [
+		{"name": "created", "events": ["created"], "url": "`+srv.URL+`/created"},
+		{"name": "ops", "where": "list=Ops", "url": "`+srv.URL+`/ops"},
+		{"name": "broken", "command": ["false"]},
+		{"name": "all", "url": "`+srv.URL+`/all"}
+	]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Dispatch(context.Background(), []*sync.Change{
+		change(sync.ChangeCreated, &models.Reminder{Title: "A", ListName: "Ops"}),
+		change(sync.ChangeUpdated, &models.Reminder{Title: "B", ListName: "Home"}),
+	})
+	var got []string
+	for i, body := range rec.bodies {
+		var c struct{ ID string }
+		_ = json.Unmarshal([]byte(body), &c)
+		got = append(got, c.ID+" "+rec.paths[i])
+	}
+	// A failing hook does not stop the ones after it.
+	want := []string{"Reminder/A /created", "Reminder/A /ops", "Reminder/A /all", "Reminder/B /all"}
+	if strings.Join(got, ", ") != strings.Join(want, ", ") {
+		t.Errorf("hooks ran for %v, want %v", got, want)
+	}
+}
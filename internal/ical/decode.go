@@ -0,0 +1,323 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// Todo is a VTODO read by Decode, with values converted to the forms the
+// rest of the program uses.
+type Todo struct {
+	UID         string
+	Summary     string
+	Description string
+	Due         string // YYYY-MM-DD (all-day) or RFC 3339
+	Priority    int    // Reminders scale: 0 (none), 1 (high), 5 (medium), 9 (low)
+	Completed   bool
+	CompletedAt string // RFC 3339; "" if not given
+	ParentUID   string // RELATED-TO with RELTYPE=PARENT (the default)
+	Recurrence  *models.Recurrence
+}
+
+// contentLine is one unfolded "NAME;PARAM=VALUE:value" line.
+type contentLine struct {
+	num    int // line number where the content line starts
+	name   string
+	params map[string]string
+	value  string
+}
+
+// Decode reads the VTODOs of an iCalendar stream. Other components (VEVENT,
+// VTIMEZONE, VALARM inside a VTODO, ...) are skipped. Floating date-times
+// are read in utils.Location.
+func Decode(r io.Reader) ([]*Todo, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var todos []*Todo
+	var stack []string
+	var cur *Todo
+	sawCalendar := false
+	for _, l := range lines {
+		switch l.name {
+		case "BEGIN":
+			comp := strings.ToUpper(l.value)
+			if len(stack) == 0 && comp != "VCALENDAR" {
+				return nil, fmt.Errorf("line %d: expected BEGIN:VCALENDAR, got BEGIN:%s", l.num, l.value)
+			}
+			stack = append(stack, comp)
+			if comp == "VCALENDAR" {
+				sawCalendar = true
+			}
+			if comp == "VTODO" && len(stack) == 2 {
+				cur = &Todo{}
+			}
+			continue
+		case "END":
+			comp := strings.ToUpper(l.value)
+			if len(stack) == 0 || stack[len(stack)-1] != comp {
+				return nil, fmt.Errorf("line %d: unexpected END:%s", l.num, l.value)
+			}
+			stack = stack[:len(stack)-1]
+			if comp == "VTODO" && len(stack) == 1 {
+				todos = append(todos, cur)
+				cur = nil
+			}
+			continue
+		}
+		if cur == nil || len(stack) != 2 {
+			continue // property of the calendar or of another component
+		}
+		if err := cur.set(l); err != nil {
+			return nil, fmt.Errorf("line %d: %s: %w", l.num, l.name, err)
+		}
+	}
+	if !sawCalendar {
+		return nil, fmt.Errorf("not an iCalendar file (no BEGIN:VCALENDAR)")
+	}
+	if len(stack) > 0 {
+		return nil, fmt.Errorf("unexpected end of file: missing END:%s", stack[len(stack)-1])
+	}
+	return todos, nil
+}
+
+// set applies one VTODO property.
+func (t *Todo) set(l contentLine) error {
+	switch l.name {
+	case "UID":
+		t.UID = l.value
+	case "SUMMARY":
+		t.Summary = unescapeText(l.value)
+	case "DESCRIPTION":
+		t.Description = unescapeText(l.value)
+	case "DUE":
+		due, err := parseDateValue(l)
+		if err != nil {
+			return err
+		}
+		t.Due = due
+	case "PRIORITY":
+		p, err := strconv.Atoi(l.value)
+		if err != nil || p < 0 || p > 9 {
+			return fmt.Errorf("invalid priority %q", l.value)
+		}
+		t.Priority = remindersPriority(p)
+	case "STATUS":
+		t.Completed = strings.EqualFold(l.value, "COMPLETED")
+	case "COMPLETED":
+		at, err := parseDateValue(l)
+		if err != nil {
+			return err
+		}
+		if utils.IsAllDay(at) {
+			at += "T00:00:00Z"
+		}
+		t.Completed = true
+		t.CompletedAt = at
+	case "RELATED-TO":
+		if rel := l.params["RELTYPE"]; rel == "" || strings.EqualFold(rel, "PARENT") {
+			t.ParentUID = l.value
+		}
+	case "RRULE":
+		rule, err := parseRRule(l.value)
+		if err != nil {
+			return err
+		}
+		t.Recurrence = rule
+	}
+	return nil
+}
+
+// remindersPriority maps the iCalendar priority scale (1–4 high, 5 medium,
+// 6–9 low, 0 undefined) to the Reminders values.
+func remindersPriority(p int) int {
+	switch {
+	case p == 0:
+		return 0
+	case p < 5:
+		return 1
+	case p == 5:
+		return 5
+	}
+	return 9
+}
+
+// parseDateValue parses a DATE or DATE-TIME property value into YYYY-MM-DD
+// or RFC 3339. Date-times with a TZID are read in that zone, floating ones
+// in utils.Location.
+func parseDateValue(l contentLine) (string, error) {
+	v := l.value
+	if strings.EqualFold(l.params["VALUE"], "DATE") || len(v) == len(dateLayout) {
+		t, err := time.Parse(dateLayout, v)
+		if err != nil {
+			return "", fmt.Errorf("invalid date %q", v)
+		}
+		return t.Format(utils.DateLayout), nil
+	}
+	if strings.HasSuffix(v, "Z") {
+		t, err := time.Parse(dateTimeLayout, v)
+		if err != nil {
+			return "", fmt.Errorf("invalid date-time %q", v)
+		}
+		return t.Format(time.RFC3339), nil
+	}
+	loc := utils.Location
+	if tzid := l.params["TZID"]; tzid != "" {
+		if zone, err := time.LoadLocation(strings.TrimPrefix(tzid, "/")); err == nil {
+			loc = zone
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", v, loc)
+	if err != nil {
+		return "", fmt.Errorf("invalid date-time %q", v)
+	}
+	return t.Format(time.RFC3339), nil
+}
+
+// parseRRule reads FREQ, INTERVAL and UNTIL from an RRULE value. Other
+// parts (BYDAY, COUNT, ...) cannot be represented and are ignored.
+func parseRRule(v string) (*models.Recurrence, error) {
+	rule := &models.Recurrence{Interval: 1}
+	for _, part := range strings.Split(v, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			freq := strings.ToLower(val)
+			if _, ok := models.FrequencyMap[freq]; !ok {
+				return nil, fmt.Errorf("unsupported repeat frequency %q", val)
+			}
+			rule.Frequency = freq
+		case "INTERVAL":
+			n, err := strconv.Atoi(val)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid interval %q", val)
+			}
+			rule.Interval = n
+		case "UNTIL":
+			if len(val) < len(dateLayout) {
+				return nil, fmt.Errorf("invalid until %q", val)
+			}
+			t, err := time.Parse(dateLayout, val[:len(dateLayout)])
+			if err != nil {
+				return nil, fmt.Errorf("invalid until %q", val)
+			}
+			until := t.Format(utils.DateLayout)
+			rule.Until = &until
+		}
+	}
+	if rule.Frequency == "" {
+		return nil, fmt.Errorf("missing FREQ")
+	}
+	return rule, nil
+}
+
+// unfold reads content lines, joining folded continuation lines.
+func unfold(r io.Reader) ([]contentLine, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	var raw []string
+	var nums []int
+	n := 0
+	for scanner.Scan() {
+		n++
+		s := strings.TrimRight(scanner.Text(), "\r")
+		if n == 1 {
+			s = strings.TrimPrefix(s, "\ufeff")
+		}
+		if (strings.HasPrefix(s, " ") || strings.HasPrefix(s, "\t")) && len(raw) > 0 {
+			raw[len(raw)-1] += s[1:]
+			continue
+		}
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+		raw = append(raw, s)
+		nums = append(nums, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	lines := make([]contentLine, 0, len(raw))
+	for i, s := range raw {
+		l, err := parseContentLine(s)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", nums[i], err)
+		}
+		l.num = nums[i]
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// parseContentLine splits "NAME;P1=V1;P2="V:2":value". Parameter values
+// may be quoted and contain ':' or ';'.
+func parseContentLine(s string) (contentLine, error) {
+	l := contentLine{params: map[string]string{}}
+	i := strings.IndexAny(s, ";:")
+	if i < 0 {
+		return l, fmt.Errorf("malformed content line %q", s)
+	}
+	l.name = strings.ToUpper(s[:i])
+	for s[i] == ';' {
+		s = s[i+1:]
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			return l, fmt.Errorf("malformed parameter in %s", l.name)
+		}
+		key := strings.ToUpper(s[:eq])
+		s = s[eq+1:]
+		var val string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				return l, fmt.Errorf("unterminated quoted parameter in %s", l.name)
+			}
+			val, s = s[1:end+1], s[end+2:]
+		} else {
+			end := strings.IndexAny(s, ";:")
+			if end < 0 {
+				return l, fmt.Errorf("malformed content line for %s", l.name)
+			}
+			val, s = s[:end], s[end:]
+		}
+		l.params[key] = val
+		i = 0
+		if s == "" {
+			return l, fmt.Errorf("missing value for %s", l.name)
+		}
+	}
+	l.value = s[i+1:]
+	return l, nil
+}
+
+// unescapeText reverses escapeText.
+func unescapeText(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
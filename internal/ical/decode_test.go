@@ -0,0 +1,191 @@
+package ical_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // TZID parameters need zoneinfo on any host
+
+	"icloud-reminders/internal/ical"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// calendar wraps content lines in a VCALENDAR with CRLF line ends.
+func calendar(lines ...string) string {
+	return strings.Join(append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR", ""), "\r\n")
+}
+
+func TestDecode(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := utils.Location
+	utils.Location = loc
+	t.Cleanup(func() { utils.Location = old })
+
+	todos, err := ical.Decode(strings.NewReader("\ufeff" + calendar(
+		"BEGIN:VEVENT",
+		"SUMMARY:Not a task",
+		"END:VEVENT",
+		"BEGIN:VTODO",
+		"UID:parent-1",
+		"SUMMARY:Plan the trip\\, part 1\\; or 2",
+		"DESCRIPTION:Line one\\nLine two\\NC:\\\\path",
+		"DUE;VALUE=DATE:20261020",
+		"PRIORITY:3",
+		"RRULE:FREQ=MONTHLY;INTERVAL=2;BYMONTHDAY=20;UNTIL=20270101T000000Z",
+		"BEGIN:VALARM",
+		"ACTION:DISPLAY",
+		"SUMMARY:Alarm, not the task",
+		"END:VALARM",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:child-1",
+		"SUMMARY:Book a ",
+		" hotel",
+		"DUE;TZID=Europe/Berlin:20261020T090000",
+		"STATUS:COMPLETED",
+		"COMPLETED;VALUE=DATE:20261002",
+		"RELATED-TO:parent-1",
+		"PRIORITY:7",
+		"END:VTODO",
+		"BEGIN:VTODO",
+		"UID:floating",
+		"summary:Lower-case names",
+		"DUE:20261020T090000",
+		"COMPLETED:20261002T153000Z",
+		"RELATED-TO;RELTYPE=SIBLING:parent-1",
+		"END:VTODO",
+	)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*ical.Todo{
+		{
+			UID:         "parent-1",
+			Summary:     "Plan the trip, part 1; or 2",
+			Description: "Line one\nLine two\nC:\\path",
+			Due:         "2026-10-20",
+			Priority:    1,
+			Recurrence:  &models.Recurrence{Frequency: "monthly", Interval: 2, Until: str("2027-01-01")},
+		},
+		{
+			UID:         "child-1",
+			Summary:     "Book a hotel",
+			Due:         "2026-10-20T09:00:00+02:00",
+			Priority:    9,
+			Completed:   true,
+			CompletedAt: "2026-10-02T00:00:00Z",
+			ParentUID:   "parent-1",
+		},
+		{
+			UID:         "floating",
+			Summary:     "Lower-case names",
+			Due:         "2026-10-20T09:00:00-04:00", // utils.Location
+			Completed:   true,
+			CompletedAt: "2026-10-02T15:30:00Z",
+		},
+	}
+	if len(todos) != len(want) {
+		t.Fatalf("decoded %d todos, want %d", len(todos), len(want))
+	}
+	for i := range want {
+		if !reflect.DeepEqual(todos[i], want[i]) {
+			t.Errorf("todo %d = %+v, want %+v", i+1, todos[i], want[i])
+		}
+	}
+}
+
+func TestDecodePriority(t *testing.T) {
+	for p, want := range map[string]int{"0": 0, "1": 1, "4": 1, "5": 5, "6": 9, "9": 9} {
+		todos, err := ical.Decode(strings.NewReader(calendar("BEGIN:VTODO", "PRIORITY:"+p, "END:VTODO")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := todos[0].Priority; got != want {
+			t.Errorf("PRIORITY:%s = %d, want %d", p, got, want)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"BEGIN:VTODO\r\nEND:VTODO\r\n",
+		calendar("BEGIN:VTODO"),
+		calendar("END:VTODO"),
+		calendar("BEGIN:VTODO", "PRIORITY:high", "END:VTODO"),
+		calendar("BEGIN:VTODO", "PRIORITY:10", "END:VTODO"),
+		calendar("BEGIN:VTODO", "DUE;VALUE=DATE:2026-10-20", "END:VTODO"),
+		calendar("BEGIN:VTODO", "DUE:20261020T25", "END:VTODO"),
+		calendar("BEGIN:VTODO", "RRULE:FREQ=HOURLY", "END:VTODO"),
+		calendar("BEGIN:VTODO", "RRULE:INTERVAL=2", "END:VTODO"),
+		calendar("BEGIN:VTODO", "RRULE:FREQ=DAILY;INTERVAL=0", "END:VTODO"),
+		calendar("BEGIN:VTODO", `X-FOO;P="unterminated:1`, "END:VTODO"),
+		calendar("BEGIN:VTODO", "no colon", "END:VTODO"),
+	} {
+		if _, err := ical.Decode(strings.NewReader(text)); err == nil {
+			t.Errorf("Decode(%q) succeeded, want an error", text)
+		}
+	}
+}
+
+// TestRoundTrip checks that Decode reads what Encode writes.
+func TestRoundTrip(t *testing.T) {
+	parent := &models.Reminder{
+		ID:         "Reminder/" + uid,
+		Title:      `Plan; the, trip \ now`,
+		ListName:   "Travel",
+		Notes:      str("Flights\nHotel: " + strings.Repeat("long ", 30)),
+		Due:        str("2026-10-20"),
+		Priority:   1,
+		Recurrence: &models.Recurrence{Frequency: "yearly", Interval: 1, Until: str("2030-10-20")},
+	}
+	child := &models.Reminder{
+		ID:             "Reminder/BBBBBBBB-0000-0000-0000-000000000000",
+		Title:          "Book a hotel",
+		ListName:       "Travel",
+		Due:            str("2026-10-20T09:00:00+02:00"),
+		Priority:       9,
+		Completed:      true,
+		CompletionDate: str("2026-10-02"),
+		ParentRef:      str(parent.ID),
+	}
+	plain := &models.Reminder{ID: "Reminder/CCCCCCCC-0000-0000-0000-000000000000", Title: "Plain", Priority: 5}
+
+	todos, err := ical.Decode(strings.NewReader(encode(t, parent, child, plain)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(todos) != 3 {
+		t.Fatalf("decoded %d todos, want 3", len(todos))
+	}
+	want := []*ical.Todo{
+		{
+			UID:         uid,
+			Summary:     parent.Title,
+			Description: *parent.Notes,
+			Due:         "2026-10-20",
+			Priority:    1,
+			Recurrence:  parent.Recurrence,
+		},
+		{
+			UID:         "BBBBBBBB-0000-0000-0000-000000000000",
+			Summary:     "Book a hotel",
+			Due:         "2026-10-20T07:00:00Z", // the same instant, in UTC
+			Priority:    9,
+			Completed:   true,
+			CompletedAt: "2026-10-02T00:00:00Z",
+			ParentUID:   uid,
+		},
+		{UID: "CCCCCCCC-0000-0000-0000-000000000000", Summary: "Plain", Priority: 5},
+	}
+	for i := range want {
+		if !reflect.DeepEqual(todos[i], want[i]) {
+			t.Errorf("todo %d = %+v, want %+v", i+1, todos[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package ical converts reminders to and from iCalendar (RFC 5545) VTODO
+// components.
+//
+// Field mapping:
+//
+//	ID              UID (the record UUID)
+//	Title           SUMMARY
+//	Notes           DESCRIPTION
+//	Due             DUE; all-day dates as VALUE=DATE, timed ones in UTC
+//	Priority        PRIORITY; Reminders already uses the iCalendar scale
+//	                (1 high, 5 medium, 9 low), 0 (none) is omitted
+//	Completed       STATUS:COMPLETED or STATUS:NEEDS-ACTION
+//	CompletionDate  COMPLETED, a UTC date-time; dates become midnight UTC
+//	ParentRef       RELATED-TO;RELTYPE=PARENT
+//	Recurrence      RRULE (FREQ, INTERVAL, UNTIL)
+//	ListName        CATEGORIES
+//	ModifiedTS      LAST-MODIFIED
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// ProdID identifies this program in exported calendars.
+const ProdID = "-//icloud-reminders//Reminders CLI//EN"
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+	// maxLineOctets is the folding limit for content lines (RFC 5545 3.1).
+	maxLineOctets = 75
+)
+
+// Encode writes reminders as one VCALENDAR with a VTODO per reminder.
+// calName, if set, is written as X-WR-CALNAME.
+func Encode(w io.Writer, reminders []*models.Reminder, calName string) error {
+	return EncodeUIDs(w, reminders, calName, shortID)
+}
+
+// EncodeUIDs is Encode with the UIDs of reminders, and of parents in
+// RELATED-TO, given by uid, which maps a record name to a UID.
+func EncodeUIDs(w io.Writer, reminders []*models.Reminder, calName string, uid func(recordName string) string) error {
+	e := &encoder{w: bufio.NewWriter(w), now: utils.Now().UTC(), uid: uid}
+	e.line("BEGIN", "VCALENDAR")
+	e.line("VERSION", "2.0")
+	e.line("PRODID", ProdID)
+	e.line("CALSCALE", "GREGORIAN")
+	if calName != "" {
+		e.line("X-WR-CALNAME", escapeText(calName))
+	}
+	for _, r := range reminders {
+		e.todo(r)
+	}
+	e.line("END", "VCALENDAR")
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+type encoder struct {
+	w   *bufio.Writer
+	now time.Time
+	uid func(recordName string) string
+	err error
+}
+
+func (e *encoder) todo(r *models.Reminder) {
+	e.line("BEGIN", "VTODO")
+	e.line("UID", e.uid(r.ID))
+	e.line("DTSTAMP", e.now.Format(dateTimeLayout))
+	e.line("SUMMARY", escapeText(r.Title))
+	if r.Notes != nil && *r.Notes != "" {
+		e.line("DESCRIPTION", escapeText(*r.Notes))
+	}
+	if r.Due != nil && *r.Due != "" {
+		if utils.IsAllDay(*r.Due) {
+			if t, err := time.Parse(utils.DateLayout, *r.Due); err == nil {
+				e.line("DUE;VALUE=DATE", t.Format(dateLayout))
+			}
+		} else if t, err := time.Parse(time.RFC3339, *r.Due); err == nil {
+			e.line("DUE", t.UTC().Format(dateTimeLayout))
+		}
+	}
+	if r.Priority != 0 {
+		e.line("PRIORITY", fmt.Sprint(r.Priority))
+	}
+	if r.Completed {
+		e.line("STATUS", "COMPLETED")
+		if r.CompletionDate != nil {
+			if at, ok := completedValue(*r.CompletionDate); ok {
+				e.line("COMPLETED", at)
+			}
+		}
+	} else {
+		e.line("STATUS", "NEEDS-ACTION")
+	}
+	if r.ParentRef != nil && *r.ParentRef != "" {
+		e.line("RELATED-TO;RELTYPE=PARENT", e.uid(*r.ParentRef))
+	}
+	if rule := RRule(r.Recurrence); rule != "" {
+		e.line("RRULE", rule)
+	}
+	if r.ListName != "" && r.ListName != "?" {
+		e.line("CATEGORIES", escapeText(r.ListName))
+	}
+	if r.ModifiedTS != nil {
+		e.line("LAST-MODIFIED", time.UnixMilli(*r.ModifiedTS).UTC().Format(dateTimeLayout))
+	}
+	e.line("END", "VTODO")
+}
+
+// line writes one content line, folded at 75 octets without splitting a
+// UTF-8 sequence.
+func (e *encoder) line(name, value string) {
+	if e.err != nil {
+		return
+	}
+	s := name + ":" + value
+	var b strings.Builder
+	n := 0
+	for len(s) > 0 {
+		_, size := utf8.DecodeRuneInString(s)
+		if n+size > maxLineOctets {
+			b.WriteString("\r\n ")
+			n = 1
+		}
+		b.WriteString(s[:size])
+		n += size
+		s = s[size:]
+	}
+	b.WriteString("\r\n")
+	_, e.err = e.w.WriteString(b.String())
+}
+
+// completedValue returns the COMPLETED value for a completion date, which
+// RFC 5545 requires to be a UTC DATE-TIME: a date-only value (the form the
+// cache keeps) becomes midnight UTC.
+func completedValue(date string) (string, bool) {
+	t, err := time.Parse(utils.DateLayout, date)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, date); err != nil {
+			return "", false
+		}
+	}
+	return t.UTC().Format(dateTimeLayout), true
+}
+
+// RRule returns the RRULE value for a recurrence, or "" for nil.
+func RRule(rule *models.Recurrence) string {
+	if rule == nil {
+		return ""
+	}
+	s := "FREQ=" + strings.ToUpper(rule.Frequency)
+	if rule.Interval > 1 {
+		s += fmt.Sprintf(";INTERVAL=%d", rule.Interval)
+	}
+	if rule.Until != nil && *rule.Until != "" {
+		if t, err := time.Parse(utils.DateLayout, *rule.Until); err == nil {
+			s += ";UNTIL=" + t.Format(dateLayout)
+		}
+	}
+	return s
+}
+
+// escapeText escapes a TEXT value (RFC 5545 3.3.11).
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(s)
+}
+
+// shortID strips a "Reminder/" prefix from a record name.
+func shortID(id string) string {
+	if i := strings.LastIndexByte(id, '/'); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
@@ -0,0 +1,175 @@
+package ical_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"icloud-reminders/internal/ical"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+func str(s string) *string { return &s }
+
+const uid = "AAAAAAAA-0000-0000-0000-000000000000"
+
+// encode returns the calendar for reminders, with DTSTAMP fixed at
+// 2026-10-16 12:00 UTC.
+func encode(t *testing.T, reminders ...*models.Reminder) string {
+	t.Helper()
+	old := utils.Now
+	utils.Now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	defer func() { utils.Now = old }()
+	var buf bytes.Buffer
+	if err := ical.Encode(&buf, reminders, "Work"); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// property returns the unfolded value of the first content line of cal
+// named name (with any parameters), and whether there is one.
+func property(cal, name string) (string, bool) {
+	unfolded := strings.ReplaceAll(cal, "\r\n ", "")
+	for _, line := range strings.Split(unfolded, "\r\n") {
+		if strings.HasPrefix(line, name+":") {
+			return strings.TrimPrefix(line, name+":"), true
+		}
+	}
+	return "", false
+}
+
+func TestEncode(t *testing.T) {
+	r := &models.Reminder{
+		ID:       "Reminder/" + uid,
+		Title:    "Call Bob",
+		ListName: "Work",
+		Due:      str("2026-10-20T09:00:00+02:00"),
+		Priority: 5,
+	}
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:" + ical.ProdID,
+		"CALSCALE:GREGORIAN",
+		"X-WR-CALNAME:Work",
+		"BEGIN:VTODO",
+		"UID:" + uid,
+		"DTSTAMP:20261016T120000Z",
+		"SUMMARY:Call Bob",
+		"DUE:20261020T070000Z",
+		"PRIORITY:5",
+		"STATUS:NEEDS-ACTION",
+		"CATEGORIES:Work",
+		"END:VTODO",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	if got := encode(t, r); got != want {
+		t.Errorf("Encode =\n%q, want\n%q", got, want)
+	}
+}
+
+func TestEncodeProperties(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(r *models.Reminder)
+		prop string
+		want string // "" when the property must be absent
+	}{
+		{"all-day due", func(r *models.Reminder) { r.Due = str("2026-10-20") }, "DUE;VALUE=DATE", "20261020"},
+		{"timed due in UTC", func(r *models.Reminder) { r.Due = str("2026-10-20T09:00:00-04:00") }, "DUE", "20261020T130000Z"},
+		{"no due", func(r *models.Reminder) {}, "DUE", ""},
+		{"high", func(r *models.Reminder) { r.Priority = 1 }, "PRIORITY", "1"},
+		{"low", func(r *models.Reminder) { r.Priority = 9 }, "PRIORITY", "9"},
+		{"no priority", func(r *models.Reminder) {}, "PRIORITY", ""},
+		{"completed date", func(r *models.Reminder) {
+			r.Completed, r.CompletionDate = true, str("2026-10-01")
+		}, "COMPLETED", "20261001T000000Z"},
+		{"completed time", func(r *models.Reminder) {
+			r.Completed, r.CompletionDate = true, str("2026-10-01T18:30:00+02:00")
+		}, "COMPLETED", "20261001T163000Z"},
+		{"completed status", func(r *models.Reminder) { r.Completed = true }, "STATUS", "COMPLETED"},
+		{"completed undated", func(r *models.Reminder) { r.Completed = true }, "COMPLETED", ""},
+		{"parent", func(r *models.Reminder) { r.ParentRef = str("Reminder/BBBB") }, "RELATED-TO;RELTYPE=PARENT", "BBBB"},
+		{"notes", func(r *models.Reminder) { r.Notes = str("a;b,c\\d\r\ne\nf") }, "DESCRIPTION", `a\;b\,c\\d\ne\nf`},
+		{"no list", func(r *models.Reminder) { r.ListName = "?" }, "CATEGORIES", ""},
+		{"modified", func(r *models.Reminder) {
+			ts := time.Date(2026, 10, 2, 8, 0, 0, 0, time.UTC).UnixMilli()
+			r.ModifiedTS = &ts
+		}, "LAST-MODIFIED", "20261002T080000Z"},
+		{"repeat", func(r *models.Reminder) {
+			r.Recurrence = &models.Recurrence{Frequency: "weekly", Interval: 2, Until: str("2026-12-31")}
+		}, "RRULE", "FREQ=WEEKLY;INTERVAL=2;UNTIL=20261231"},
+	}
+	for _, tt := range tests {
+		r := &models.Reminder{ID: "Reminder/" + uid, Title: "Task", ListName: "Work"}
+		tt.edit(r)
+		cal := encode(t, r)
+		got, ok := property(cal, tt.prop)
+		if tt.want == "" {
+			if ok {
+				t.Errorf("%s: %s:%s written, want none", tt.name, tt.prop, got)
+			}
+		} else if got != tt.want {
+			t.Errorf("%s: %s = %q, want %q\n%s", tt.name, tt.prop, got, tt.want, cal)
+		}
+	}
+}
+
+func TestRRule(t *testing.T) {
+	tests := []struct {
+		rule *models.Recurrence
+		want string
+	}{
+		{nil, ""},
+		{&models.Recurrence{Frequency: "daily", Interval: 1}, "FREQ=DAILY"},
+		{&models.Recurrence{Frequency: "monthly", Interval: 0}, "FREQ=MONTHLY"},
+		{&models.Recurrence{Frequency: "yearly", Interval: 3}, "FREQ=YEARLY;INTERVAL=3"},
+		{&models.Recurrence{Frequency: "daily", Interval: 1, Until: str("2027-01-02")}, "FREQ=DAILY;UNTIL=20270102"},
+		{&models.Recurrence{Frequency: "daily", Interval: 1, Until: str("")}, "FREQ=DAILY"},
+	}
+	for _, tt := range tests {
+		if got := ical.RRule(tt.rule); got != tt.want {
+			t.Errorf("RRule(%v) = %q, want %q", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestEncodeFolding(t *testing.T) {
+	titles := []string{
+		strings.Repeat("abcdefghij", 20),
+		strings.Repeat("é", 100),  // 2-byte runes
+		strings.Repeat("日本語", 40), // 3-byte runes
+		strings.Repeat("🙂", 50),   // 4-byte runes
+		strings.Repeat("a,", 60),  // escapes across the fold
+	}
+	for _, title := range titles {
+		cal := encode(t, &models.Reminder{ID: "Reminder/" + uid, Title: title})
+		lines := strings.Split(strings.TrimSuffix(cal, "\r\n"), "\r\n")
+		for i, line := range lines {
+			if len(line) > 75 {
+				t.Errorf("line %d is %d octets: %q", i+1, len(line), line)
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("line %d splits a UTF-8 sequence: %q", i+1, line)
+			}
+			if i > 0 && strings.HasPrefix(line, " ") && !strings.HasPrefix(lines[i-1], "SUMMARY:") && !strings.HasPrefix(lines[i-1], " ") {
+				t.Errorf("line %d continues a line other than SUMMARY", i+1)
+			}
+		}
+		todos, err := ical.Decode(strings.NewReader(cal))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(todos) != 1 {
+			t.Fatalf("decoded %d todos, want 1", len(todos))
+		}
+		if todos[0].Summary != title {
+			t.Errorf("folded title reads back as %q, want %q", todos[0].Summary, title)
+		}
+	}
+}
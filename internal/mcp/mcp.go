@@ -0,0 +1,214 @@
+// Package mcp serves reminders to AI agents over the Model Context Protocol
+// ('reminders mcp'): JSON-RPC 2.0 messages, one per line, on stdin and
+// stdout.
+//
+// The server only offers tools (see tools.go). Their input and output
+// schemas are generated from the Go types of their arguments and results,
+// so reminders are described by models.Reminder itself. Every tool syncs
+// the cache first. Every result has "ok" and, when the tool failed,
+// "error"; failures are also flagged with isError so clients can tell them
+// apart without parsing. Bulk tools report each reminder's outcome, so a
+// partially applied change shows what was and was not done.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"icloud-reminders/internal/logger"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/internal/writer"
+)
+
+// protocolVersions are the MCP revisions the server speaks, newest first.
+var protocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// instructions is sent to the client on initialize, for the model.
+const instructions = `Tools for the user's iCloud Reminders. Reminders are addressed by ID: the UUID from "id" (without "Reminder/") or a unique prefix of at least 4 characters. Lists are addressed by name. Filter expressions ("where") use the syntax of 'reminders help filters', e.g. "list=Work and due<=+3d and priority>=medium".`
+
+// Server serves the tools for one engine and writer.
+type Server struct {
+	Engine *sync.Engine
+	Writer *writer.Writer
+	// Version is reported to clients as the server version.
+	Version string
+}
+
+// New returns a server for engine and w.
+func New(engine *sync.Engine, w *writer.Writer, version string) *Server {
+	return &Server{Engine: engine, Writer: w, Version: version}
+}
+
+// request is a JSON-RPC request, or a notification if ID is absent.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func errorf(code int, format string, args ...interface{}) *rpcError {
+	return &rpcError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Serve answers the requests read from in on out until in is closed.
+// Requests are handled one at a time, in order.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	r := bufio.NewReader(in)
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+	for {
+		line, err := r.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if resp := s.handle(line); resp != nil {
+				if err := enc.Encode(resp); err != nil {
+					return err
+				}
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers one message; notifications get no response.
+func (s *Server) handle(msg []byte) *response {
+	var req request
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return &response{JSONRPC: "2.0", Error: errorf(codeParseError, "parse error: %v", err)}
+	}
+	if req.ID == nil {
+		logger.Debugf("mcp: notification %s", req.Method)
+		return nil
+	}
+	resp := &response{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = errorf(codeInvalidRequest, "invalid request")
+		return resp
+	}
+	logger.Infof("mcp: %s", req.Method)
+	resp.Result, resp.Error = s.call(req.Method, req.Params)
+	return resp
+}
+
+// call runs a method.
+func (s *Server) call(method string, params json.RawMessage) (interface{}, *rpcError) {
+	switch method {
+	case "initialize":
+		return s.initialize(params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": tools}, nil
+	case "tools/call":
+		return s.callTool(params)
+	}
+	return nil, errorf(codeMethodNotFound, "method not found: %s", method)
+}
+
+// initialize agrees on a protocol version: the client's if the server
+// speaks it, else the newest one.
+func (s *Server) initialize(params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, errorf(codeInvalidParams, "invalid params: %v", err)
+	}
+	version := protocolVersions[0]
+	for _, v := range protocolVersions {
+		if v == p.ProtocolVersion {
+			version = v
+		}
+	}
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities":    map[string]interface{}{"tools": map[string]bool{"listChanged": false}},
+		"serverInfo":      map[string]string{"name": "icloud-reminders", "version": s.Version},
+		"instructions":    instructions,
+	}, nil
+}
+
+// content is an item of a tool result's unstructured content.
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// callResult is the result of tools/call. The structured content is also
+// sent as JSON text for clients that do not read structured content.
+type callResult struct {
+	Content           []content   `json:"content"`
+	StructuredContent interface{} `json:"structuredContent"`
+	IsError           bool        `json:"isError,omitempty"`
+}
+
+// callTool runs a tool. An unknown tool is a protocol error; a tool that
+// fails returns a result with isError set.
+func (s *Server) callTool(params json.RawMessage) (interface{}, *rpcError) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, errorf(codeInvalidParams, "invalid params: %v", err)
+	}
+	var t *tool
+	for _, cand := range tools {
+		if cand.Name == p.Name {
+			t = cand
+		}
+	}
+	if t == nil {
+		return nil, errorf(codeInvalidParams, "unknown tool: %s", p.Name)
+	}
+
+	res := t.call(s, p.Arguments)
+	text, err := json.Marshal(res)
+	if err != nil {
+		return nil, errorf(codeInvalidParams, "%s: %v", p.Name, err)
+	}
+	return &callResult{
+		Content:           []content{{Type: "text", Text: string(text)}},
+		StructuredContent: res,
+		IsError:           !res.base().OK,
+	}, nil
+}
+
+// sync delta-syncs the engine.
+func (s *Server) sync() error {
+	err := s.Engine.Sync(false)
+	// A re-auth during the sync replaces the engine's client.
+	s.Writer.CK = s.Engine.CK
+	if err != nil {
+		return fmt.Errorf("sync: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+package mcp_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/cloudkit"
+	"icloud-reminders/internal/cloudkit/cktest"
+	"icloud-reminders/internal/mcp"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/internal/writer"
+)
+
+// newServer returns an MCP server for an engine that has synced srv. The
+// cache file goes to a temporary directory.
+func newServer(t *testing.T, srv *cktest.Server) *mcp.Server {
+	t.Helper()
+	cache.CacheFile = filepath.Join(t.TempDir(), "ck_cache.json")
+	ck, err := cloudkit.NewFromSession(srv.Session())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &sync.Engine{CK: ck, Cache: cache.NewCache()}
+	if err := e.Sync(false); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	return mcp.New(e, writer.New(ck, e), "1.2.3")
+}
+
+// response is a JSON-RPC response as a client reads it.
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// serve sends messages, one per line, and returns the responses.
+func serve(t *testing.T, s *mcp.Server, messages ...string) []*response {
+	t.Helper()
+	var out bytes.Buffer
+	if err := s.Serve(strings.NewReader(strings.Join(messages, "\n")), &out); err != nil {
+		t.Fatal(err)
+	}
+	var resps []*response
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		resp := &response{}
+		if err := dec.Decode(resp); err != nil {
+			t.Fatal(err)
+		}
+		resps = append(resps, resp)
+	}
+	return resps
+}
+
+// toolResult is the result of tools/call with the fields the tests read.
+type toolResult struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error"`
+	Succeeded int    `json:"succeeded"`
+	Failed    int    `json:"failed"`
+	Reminders []struct {
+		ID       string `json:"id"`
+		Title    string `json:"title"`
+		ListName string `json:"list_name"`
+		Due      string `json:"due"`
+		OK       bool   `json:"ok"`
+		Error    string `json:"error"`
+	} `json:"reminders"`
+	Lists []struct {
+		Name   string `json:"name"`
+		Active int    `json:"active"`
+	} `json:"lists"`
+	Results []struct {
+		Title   string `json:"title"`
+		Score   int    `json:"score"`
+		Snippet string `json:"snippet"`
+	} `json:"results"`
+}
+
+// call calls a tool with JSON arguments. It checks that the text content
+// repeats the structured content and that isError is set exactly when the
+// result is not ok.
+func call(t *testing.T, s *mcp.Server, name, args string) *toolResult {
+	t.Helper()
+	resps := serve(t, s, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":%q,"arguments":%s}}`, name, args))
+	if len(resps) != 1 || resps[0].Error != nil {
+		t.Fatalf("tools/call %s: %+v", name, resps)
+	}
+	var res struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		StructuredContent json.RawMessage `json:"structuredContent"`
+		IsError           bool            `json:"isError"`
+	}
+	if err := json.Unmarshal(resps[0].Result, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Content) != 1 || res.Content[0].Type != "text" || !sameJSON(res.Content[0].Text, string(res.StructuredContent)) {
+		t.Errorf("%s: content %+v does not repeat %s", name, res.Content, res.StructuredContent)
+	}
+	var out toolResult
+	if err := json.Unmarshal(res.StructuredContent, &out); err != nil {
+		t.Fatal(err)
+	}
+	if res.IsError == out.OK {
+		t.Errorf("%s: isError = %v with ok = %v", name, res.IsError, out.OK)
+	}
+	return &out
+}
+
+// sameJSON reports whether two JSON texts hold the same value.
+func sameJSON(a, b string) bool {
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func shortID(recordName string) string {
+	return strings.TrimPrefix(recordName, "Reminder/")
+}
+
+func serverTitle(srv *cktest.Server, recordName string) string {
+	doc, _ := srv.Fields(recordName)["TitleDocument"].(map[string]interface{})["value"].(string)
+	return utils.ExtractTitle(doc)
+}
+
+func TestProtocol(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	s := newServer(t, srv)
+
+	resps := serve(t, s,
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		``,
+		`{"jsonrpc":"2.0","id":"two","method":"initialize","params":{"protocolVersion":"1999-01-01"}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"resources/list"}`,
+		`{"jsonrpc":"1.0","id":5,"method":"ping"}`,
+		`{not json`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"fly"}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"initialize","params":"2025-06-18"}`,
+	)
+	if len(resps) != 8 {
+		t.Fatalf("%d responses, want 8 (none for the notification and the blank line)", len(resps))
+	}
+
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name, Version string
+		} `json:"serverInfo"`
+		Capabilities map[string]interface{} `json:"capabilities"`
+		Instructions string                 `json:"instructions"`
+	}
+	if err := json.Unmarshal(resps[0].Result, &init); err != nil {
+		t.Fatal(err)
+	}
+	if init.ProtocolVersion != "2025-03-26" || init.ServerInfo.Version != "1.2.3" || init.Capabilities["tools"] == nil || init.Instructions == "" {
+		t.Errorf("initialize = %s", resps[0].Result)
+	}
+	// An unknown version gets the newest one.
+	if err := json.Unmarshal(resps[1].Result, &init); err != nil || init.ProtocolVersion != "2025-06-18" {
+		t.Errorf("initialize with an unknown version = %s", resps[1].Result)
+	}
+	if string(resps[1].ID) != `"two"` {
+		t.Errorf("response id = %s, want \"two\"", resps[1].ID)
+	}
+	if string(resps[2].Result) != "{}" || resps[2].Error != nil {
+		t.Errorf("ping = %+v", resps[2])
+	}
+
+	for i, want := range map[int]int{3: -32601, 4: -32600, 5: -32700, 6: -32602, 7: -32602} {
+		if resp := resps[i]; resp.Error == nil || resp.Error.Code != want || resp.Result != nil {
+			t.Errorf("response %d = %+v, want error %d", i+1, resp, want)
+		}
+	}
+	if string(resps[5].ID) != "null" {
+		t.Errorf("parse error id = %s, want null", resps[5].ID)
+	}
+}
+
+func TestToolsList(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	resps := serve(t, newServer(t, srv), `{"jsonrpc":"2.0","id":1,"method":"tools/list"}`)
+	var list struct {
+		Tools []struct {
+			Name         string                 `json:"name"`
+			Description  string                 `json:"description"`
+			InputSchema  map[string]interface{} `json:"inputSchema"`
+			OutputSchema map[string]interface{} `json:"outputSchema"`
+			Annotations  struct {
+				ReadOnly    bool `json:"readOnlyHint"`
+				Destructive bool `json:"destructiveHint"`
+			} `json:"annotations"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(resps[0].Result, &list); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, tool := range list.Tools {
+		names = append(names, tool.Name)
+		if tool.Description == "" || tool.InputSchema["type"] != "object" || tool.InputSchema["additionalProperties"] != false {
+			t.Errorf("%s: input schema %v", tool.Name, tool.InputSchema)
+		}
+		props, _ := tool.OutputSchema["properties"].(map[string]interface{})
+		if props["ok"] == nil || props["error"] == nil {
+			t.Errorf("%s: output schema lacks ok/error: %v", tool.Name, tool.OutputSchema)
+		}
+		if tool.Annotations.ReadOnly && tool.Annotations.Destructive {
+			t.Errorf("%s is both read-only and destructive", tool.Name)
+		}
+	}
+	if got := strings.Join(names, " "); got != "lists list search add add-batch complete edit delete" {
+		t.Errorf("tools = %s", got)
+	}
+
+	// The add tool's schema, generated from its argument type.
+	add := list.Tools[3]
+	if got := fmt.Sprint(add.InputSchema["required"]); got != "[title list]" {
+		t.Errorf("add requires %s, want [title list]", got)
+	}
+	props := add.InputSchema["properties"].(map[string]interface{})
+	priority := props["priority"].(map[string]interface{})
+	if fmt.Sprint(priority["enum"]) != "[high medium low none]" || priority["type"] != "string" {
+		t.Errorf("add priority schema = %v", priority)
+	}
+	// Reminders in results are described by models.Reminder.
+	out := add.OutputSchema["properties"].(map[string]interface{})
+	items := out["reminders"].(map[string]interface{})["items"].(map[string]interface{})
+	due := items["properties"].(map[string]interface{})["due"].(map[string]interface{})
+	if !strings.Contains(fmt.Sprint(due["description"]), "RFC 3339") {
+		t.Errorf("reminder due schema = %v", due)
+	}
+	if list.Tools[0].Annotations.ReadOnly != true || list.Tools[7].Annotations.Destructive != true {
+		t.Error("lists is not read-only or delete not destructive")
+	}
+}
+
+func TestReadTools(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	work := srv.AddList("Work")
+	home := srv.AddList("Home")
+	report := srv.AddReminder("Write report", work)
+	srv.AddReminder("Review report", work, map[string]interface{}{
+		"Completed": map[string]interface{}{"value": 1},
+	})
+	notes, _ := utils.EncodeTitle("oat milk, not the report paper")
+	srv.AddReminder("Buy milk", home, map[string]interface{}{
+		"NotesDocument": map[string]interface{}{"value": notes},
+	})
+	sub := srv.AddReminder("Collect figures", work, map[string]interface{}{
+		"ParentReminder": map[string]interface{}{"value": map[string]interface{}{"recordName": report, "action": "NONE"}},
+	})
+	s := newServer(t, srv)
+
+	res := call(t, s, "lists", `{}`)
+	if !res.OK || len(res.Lists) != 2 || res.Lists[0].Name != "Home" || res.Lists[0].Active != 1 || res.Lists[1].Active != 2 {
+		t.Errorf("lists = %+v", res)
+	}
+
+	titles := func(res *toolResult) string {
+		var ts []string
+		for _, r := range res.Reminders {
+			ts = append(ts, r.Title)
+		}
+		return strings.Join(ts, ", ")
+	}
+	for _, tc := range []struct{ args, want string }{
+		{`{}`, "Buy milk, Collect figures, Write report"},
+		{`{"completed": true}`, "Buy milk, Collect figures, Review report, Write report"},
+		{`{"list": "work"}`, "Collect figures, Write report"},
+		{`{"where": "completed"}`, "Review report"},
+		{`{"where": "title~report"}`, "Write report"},
+		{`{"parent": "` + shortID(report)[:8] + `"}`, "Collect figures"},
+	} {
+		res := call(t, s, "list", tc.args)
+		if got := titles(res); !res.OK || got != tc.want {
+			t.Errorf("list %s = %q (%s), want %q", tc.args, got, res.Error, tc.want)
+		}
+	}
+	if res := call(t, s, "list", `{"parent": "`+sub+`"}`); !res.OK || len(res.Reminders) != 0 {
+		t.Errorf("subtasks of a leaf = %+v", res)
+	}
+
+	res = call(t, s, "search", `{"query": "report"}`)
+	var hits []string
+	for _, h := range res.Results {
+		hits = append(hits, h.Title)
+	}
+	// Title matches first; the notes match comes with a snippet.
+	if !res.OK || strings.Join(hits, ", ") != "Write report, Buy milk" || res.Results[1].Snippet == "" {
+		t.Errorf("search = %+v", res)
+	}
+	if res := call(t, s, "search", `{"query": "reprot", "exact": true}`); !res.OK || len(res.Results) != 0 {
+		t.Errorf("exact search = %+v", res)
+	}
+
+	for _, tc := range []struct{ tool, args, want string }{
+		{"list", `{"list": "Nowhere"}`, "list 'Nowhere' not found"},
+		{"list", `{"where": "due<"}`, ""},
+		{"list", `{"parent": "abc"}`, "at least 4 characters"},
+		{"list", `{"colour": "red"}`, `invalid arguments: json: unknown field "colour"`},
+		{"search", `{"query": " "}`, "query is required"},
+		{"search", `{"query": "(", "regex": true}`, "invalid regular expression"},
+	} {
+		res := call(t, s, tc.tool, tc.args)
+		if res.OK || !strings.Contains(res.Error, tc.want) {
+			t.Errorf("%s %s = ok %v, error %q; want an error containing %q", tc.tool, tc.args, res.OK, res.Error, tc.want)
+		}
+	}
+}
+
+func TestWriteTools(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	work := srv.AddList("Work")
+	report := srv.AddReminder("Write report", work)
+	slides := srv.AddReminder("Make slides", work)
+	s := newServer(t, srv)
+
+	res := call(t, s, "add", `{"title": "Call Bob", "list": "Work", "due": "2026-10-20", "priority": "high"}`)
+	if !res.OK || res.Succeeded != 1 || len(res.Reminders) != 1 || res.Reminders[0].Title != "Call Bob" || res.Reminders[0].Due != "2026-10-20" {
+		t.Fatalf("add = %+v", res)
+	}
+	if got := serverTitle(srv, res.Reminders[0].ID); got != "Call Bob" {
+		t.Errorf("server title = %q", got)
+	}
+
+	res = call(t, s, "add-batch", `{"titles": ["One", "Two"], "list": "Work", "parent": "`+shortID(report)+`"}`)
+	if !res.OK || res.Succeeded != 2 || len(res.Reminders) != 2 {
+		t.Fatalf("add-batch = %+v", res)
+	}
+	for _, r := range res.Reminders {
+		if serverTitle(srv, r.ID) != r.Title {
+			t.Errorf("%s not created on the server", r.Title)
+		}
+	}
+
+	res = call(t, s, "edit", `{"ids": ["`+shortID(report)[:6]+`", "`+slides+`"], "priority": "low", "due": "2026-11-01"}`)
+	if !res.OK || res.Succeeded != 2 {
+		t.Fatalf("edit = %+v", res)
+	}
+	for _, r := range res.Reminders {
+		if r.Due != "2026-11-01" {
+			t.Errorf("edited %s due = %q", r.Title, r.Due)
+		}
+	}
+
+	// A bulk change reports each reminder; one bad ID fails the call but
+	// not the others.
+	res = call(t, s, "complete", `{"ids": ["`+shortID(report)+`", "ffffffff"]}`)
+	if res.OK || res.Succeeded != 1 || res.Failed != 1 || res.Error != "1 of 2 reminder(s) failed" {
+		t.Fatalf("complete = %+v", res)
+	}
+	if r := res.Reminders[0]; r.ID != "ffffffff" || r.OK || !strings.Contains(r.Error, "not found") {
+		t.Errorf("bad ID entry = %+v", r)
+	}
+	if r := res.Reminders[1]; r.ID != report || !r.OK {
+		t.Errorf("completed entry = %+v", r)
+	}
+	if f, _ := srv.Fields(report)["Completed"].(map[string]interface{}); fmt.Sprint(f["value"]) != "1" {
+		t.Error("not completed on the server")
+	}
+
+	res = call(t, s, "delete", `{"ids": ["`+shortID(slides)+`"]}`)
+	if !res.OK || res.Succeeded != 1 || res.Reminders[0].Title != "Make slides" {
+		t.Fatalf("delete = %+v", res)
+	}
+	if srv.Fields(slides) != nil {
+		t.Error("not deleted on the server")
+	}
+
+	for _, tc := range []struct{ tool, args, want string }{
+		{"add", `{"title": "x"}`, "title and list are required"},
+		{"add", `{"title": "x", "list": "Work", "priority": "urgent"}`, "invalid priority"},
+		{"add", `{"title": "x", "list": "Nowhere"}`, "Nowhere"},
+		{"add", `{"title": "x", "list": "Work", "parent": "zzzzzzzz"}`, "parent: reminder 'zzzzzzzz' not found"},
+		{"add-batch", `{"list": "Work"}`, "titles and list are required"},
+		{"edit", `{"ids": ["` + shortID(report) + `"]}`, "no changes given"},
+		{"complete", `{"ids": []}`, "ids is required"},
+		{"delete", `{"ids": ["abc"]}`, "1 of 1 reminder(s) failed"},
+	} {
+		res := call(t, s, tc.tool, tc.args)
+		if res.OK || !strings.Contains(res.Error, tc.want) {
+			t.Errorf("%s %s = ok %v, error %q; want an error containing %q", tc.tool, tc.args, res.OK, res.Error, tc.want)
+		}
+	}
+}
+
+func TestSyncFailure(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	srv.AddReminder("Write report", srv.AddList("Work"))
+	s := newServer(t, srv)
+
+	srv.FailNext(500, 500, 500, 500, 500, 500)
+	res := call(t, s, "lists", `{}`)
+	if res.OK || !strings.HasPrefix(res.Error, "sync: ") {
+		t.Errorf("lists with a failing sync = %+v", res)
+	}
+}
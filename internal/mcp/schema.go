@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"reflect"
+	"strings"
+)
+
+// schema is a JSON Schema.
+type schema map[string]interface{}
+
+// modelDocs describes the fields of the pkg/models types, keyed by
+// "Type.json_name". Tool argument types use `desc` tags instead.
+var modelDocs = map[string]string{
+	"Reminder.id":              `Record name: "Reminder/<UUID>" or the bare UUID; tools also take a prefix of at least 4 characters of the UUID`,
+	"Reminder.title":           "Title",
+	"Reminder.completed":       "Whether the reminder is completed",
+	"Reminder.completion_date": "When it was completed (YYYY-MM-DD)",
+	"Reminder.due":             "Due date: YYYY-MM-DD for all-day reminders, RFC 3339 for timed ones",
+	"Reminder.priority":        "Priority: 0 none, 1 high, 5 medium, 9 low",
+	"Reminder.notes":           "Notes",
+	"Reminder.list_ref":        "Record name of the reminder's list",
+	"Reminder.list_name":       "Name of the reminder's list",
+	"Reminder.parent_ref":      "Record name of the parent reminder, for subtasks",
+	"Reminder.modified_ts":     "Last modification, in Unix milliseconds",
+	"Reminder.recurrence":      "Repeat rule of a repeating reminder",
+	"Reminder.time_zone":       "IANA time zone of a timed due date",
+	"Reminder.change_tag":      "CloudKit record change tag",
+	"Recurrence.frequency":     "daily, weekly, monthly or yearly",
+	"Recurrence.interval":      "Repeats every interval frequency units",
+	"Recurrence.until":         "Last date it repeats (YYYY-MM-DD)",
+	"ReminderList.id":          `Record name ("List/<UUID>")`,
+	"ReminderList.name":        "Name",
+	"ReminderList.color":       `Color: a name such as "blue", or hex`,
+}
+
+// schemaOf returns the schema of values of type t as encoding/json writes
+// them. Struct fields are described by their `desc` tag or modelDocs, `enum`
+// tags list allowed values (comma-separated), and fields without omitempty
+// are required.
+func schemaOf(t reflect.Type) schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return schema{"type": "string"}
+	case reflect.Bool:
+		return schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return schema{"type": "array", "items": schemaOf(t.Elem())}
+	case reflect.Struct:
+		props := schema{}
+		required := []string{}
+		addFields(t, props, &required)
+		s := schema{"type": "object", "properties": props}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	}
+	return schema{}
+}
+
+// addFields adds the properties of struct type t to props, promoting the
+// fields of embedded structs as encoding/json does.
+func addFields(t reflect.Type, props schema, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(ft, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s := schemaOf(f.Type)
+		desc := f.Tag.Get("desc")
+		if desc == "" {
+			desc = modelDocs[t.Name()+"."+name]
+		}
+		if desc != "" {
+			s["description"] = desc
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			s["enum"] = strings.Split(enum, ",")
+		}
+		props[name] = s
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,483 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"icloud-reminders/internal/daemon"
+	"icloud-reminders/internal/filter"
+	"icloud-reminders/internal/search"
+	"icloud-reminders/internal/writer"
+	"icloud-reminders/pkg/models"
+)
+
+// tool is a tool as listed by tools/list.
+type tool struct {
+	Name         string      `json:"name"`
+	Description  string      `json:"description"`
+	InputSchema  schema      `json:"inputSchema"`
+	OutputSchema schema      `json:"outputSchema"`
+	Annotations  annotations `json:"annotations"`
+
+	call func(s *Server, args json.RawMessage) result
+}
+
+// annotations are hints to the client about what a tool does. A tool that
+// is not read-only and not destructive only adds reminders.
+type annotations struct {
+	ReadOnly    bool `json:"readOnlyHint"`
+	Destructive bool `json:"destructiveHint"`
+}
+
+var (
+	readOnly    = annotations{ReadOnly: true}
+	additive    = annotations{}
+	destructive = annotations{Destructive: true}
+)
+
+// result is a tool's structured result.
+type result interface {
+	base() *status
+}
+
+// status is the part every tool result has.
+type status struct {
+	OK    bool   `json:"ok" desc:"Whether the tool succeeded; false for any failure, including single reminders of a bulk change"`
+	Error string `json:"error,omitempty" desc:"What failed"`
+}
+
+func (st *status) base() *status { return st }
+
+// define makes a tool whose arguments are decoded into an A and whose
+// result is a fresh R from newResult, filled in by run. The schemas are
+// generated from A and R. A tool fails if its arguments are invalid, the
+// sync fails or run returns an error.
+func define[A any, R result](name, description string, hints annotations, newResult func() R, run func(s *Server, args *A, res R) error) *tool {
+	input := schemaOf(reflect.TypeOf((*A)(nil)).Elem())
+	input["additionalProperties"] = false
+	return &tool{
+		Name:         name,
+		Description:  description,
+		InputSchema:  input,
+		OutputSchema: schemaOf(reflect.TypeOf(newResult())),
+		Annotations:  hints,
+		call: func(s *Server, raw json.RawMessage) result {
+			res := newResult()
+			var args A
+			err := decodeArgs(raw, &args)
+			if err == nil {
+				err = s.sync()
+			}
+			if err == nil {
+				err = run(s, &args, res)
+			}
+			st := res.base()
+			st.OK = err == nil
+			if err != nil {
+				st.Error = err.Error()
+			}
+			return res
+		},
+	}
+}
+
+// decodeArgs decodes tool arguments, rejecting unknown ones.
+func decodeArgs(raw json.RawMessage, v interface{}) error {
+	if len(raw) == 0 || string(raw) == "null" {
+		raw = json.RawMessage("{}")
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid arguments: %v", err)
+	}
+	return nil
+}
+
+// tools are the tools the server offers, in the order they are listed.
+var tools = []*tool{
+	define("lists", "List the reminder lists with their number of open reminders.",
+		readOnly, func() *listsResult { return &listsResult{Lists: []*listInfo{}} }, (*Server).lists),
+	define("list", "List reminders, optionally only those of one list, the subtasks of one reminder or those matching a filter expression. Completed reminders are left out unless asked for.",
+		readOnly, func() *remindersResult { return &remindersResult{Reminders: []*models.Reminder{}} }, (*Server).list),
+	define("search", "Search reminder titles and notes. Every term must match; results are ranked with title matches first. Scope a term with title: or notes:, quote phrases; terms also match words with a typo or two unless exact is set.",
+		readOnly, func() *searchResult { return &searchResult{Results: []*searchHit{}} }, (*Server).search),
+	define("add", "Add a reminder to a list.",
+		additive, newWriteResult, (*Server).add),
+	define("add-batch", "Add several reminders with just a title to one list in a single request.",
+		additive, newWriteResult, (*Server).addBatch),
+	define("complete", "Mark reminders as complete. Repeating reminders roll forward to their next due date instead.",
+		destructive, newWriteResult, (*Server).complete),
+	define("edit", "Apply the same edit to one or more reminders. Omitted fields are left unchanged.",
+		destructive, newWriteResult, (*Server).edit),
+	define("delete", "Delete reminders. Their subtasks are kept.",
+		destructive, newWriteResult, (*Server).delete),
+}
+
+// noArgs are the arguments of a tool that takes none.
+type noArgs struct{}
+
+// listInfo is a list with its number of open reminders.
+type listInfo struct {
+	*models.ReminderList
+	Active int `json:"active" desc:"Number of reminders in the list that are not completed"`
+}
+
+type listsResult struct {
+	status
+	Lists []*listInfo `json:"lists"`
+}
+
+func (s *Server) lists(_ *noArgs, res *listsResult) error {
+	active := make(map[string]int)
+	for _, rd := range s.Engine.Cache.Reminders {
+		if rd.ListRef != nil && !rd.Completed {
+			active[*rd.ListRef]++
+		}
+	}
+	lists := s.Engine.GetLists()
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Name < lists[j].Name })
+	for _, lst := range lists {
+		res.Lists = append(res.Lists, &listInfo{ReminderList: lst, Active: active[lst.ID]})
+	}
+	return nil
+}
+
+type listArgs struct {
+	List      string `json:"list,omitempty" desc:"Only reminders in this list (name or ID)"`
+	Parent    string `json:"parent,omitempty" desc:"Only the subtasks of this reminder (ID)"`
+	Where     string `json:"where,omitempty" desc:"Filter expression, e.g. \"due<=today and priority>=medium\""`
+	Completed bool   `json:"completed,omitempty" desc:"Include completed reminders (implied by a where expression that tests completed)"`
+}
+
+type remindersResult struct {
+	status
+	Reminders []*models.Reminder `json:"reminders" desc:"Ordered by list, then title"`
+}
+
+func (s *Server) list(args *listArgs, res *remindersResult) error {
+	where, err := filter.Parse(args.Where)
+	if err != nil {
+		return err
+	}
+	listID := ""
+	if args.List != "" {
+		if listID = s.Engine.FindList(args.List); listID == "" {
+			return fmt.Errorf("list '%s' not found", args.List)
+		}
+	}
+	parentID := ""
+	if args.Parent != "" {
+		if parentID, err = s.findReminder(args.Parent); err != nil {
+			return err
+		}
+	}
+
+	for _, r := range where.Select(s.Engine.GetReminders(args.Completed || where.Uses("completed"))) {
+		if listID != "" && (r.ListRef == nil || *r.ListRef != listID) {
+			continue
+		}
+		if parentID != "" && (r.ParentRef == nil || *r.ParentRef != parentID) {
+			continue
+		}
+		res.Reminders = append(res.Reminders, r)
+	}
+	sortReminders(res.Reminders)
+	return nil
+}
+
+type searchArgs struct {
+	Query     string `json:"query" desc:"Search terms, e.g. \"milk\", \"notes:invoice\" or 'title:\"tax return\" 2025'"`
+	Where     string `json:"where,omitempty" desc:"Filter expression narrowing the reminders searched"`
+	Completed bool   `json:"completed,omitempty" desc:"Include completed reminders"`
+	Regex     bool   `json:"regex,omitempty" desc:"Treat each term as a regular expression"`
+	Exact     bool   `json:"exact,omitempty" desc:"Disable typo-tolerant matching"`
+}
+
+// searchHit is a reminder found by search.
+type searchHit struct {
+	*models.Reminder
+	Score   int    `json:"score" desc:"Relevance; higher is better"`
+	Snippet string `json:"snippet,omitempty" desc:"Notes text around the matches"`
+}
+
+type searchResult struct {
+	status
+	Results []*searchHit `json:"results" desc:"Best matches first"`
+}
+
+func (s *Server) search(args *searchArgs, res *searchResult) error {
+	if strings.TrimSpace(args.Query) == "" {
+		return fmt.Errorf("query is required")
+	}
+	q, err := search.Parse(args.Query, search.Options{Regex: args.Regex, Exact: args.Exact})
+	if err != nil {
+		return err
+	}
+	where, err := filter.Parse(args.Where)
+	if err != nil {
+		return err
+	}
+	for _, r := range q.Search(where.Select(s.Engine.GetReminders(args.Completed || where.Uses("completed")))) {
+		hit := &searchHit{Reminder: r.Reminder, Score: r.Score}
+		if len(r.NotesSpans) > 0 {
+			hit.Snippet = search.Snippet(*r.Reminder.Notes, r.NotesSpans, 60, func(t string) string { return t })
+		}
+		res.Results = append(res.Results, hit)
+	}
+	return nil
+}
+
+// writeResult is the result of a tool that changes reminders, shaped like
+// the CLI's --output json.
+type writeResult struct {
+	status
+	Succeeded int      `json:"succeeded"`
+	Failed    int      `json:"failed"`
+	Reminders []*entry `json:"reminders" desc:"The reminders changed, as they are now (as they were, for deletions)"`
+}
+
+// entry is one reminder a write touched.
+type entry struct {
+	*models.Reminder
+	OK      bool   `json:"ok" desc:"Whether the change was applied to this reminder"`
+	Error   string `json:"error,omitempty" desc:"Why it was not"`
+	NextDue string `json:"next_due,omitempty" desc:"For a completed repeating reminder: the due date it rolled forward to"`
+}
+
+func newWriteResult() *writeResult {
+	return &writeResult{Reminders: []*entry{}}
+}
+
+// add records the outcome for one reminder.
+func (res *writeResult) add(e *entry) {
+	res.Reminders = append(res.Reminders, e)
+	if e.OK {
+		res.Succeeded++
+	} else {
+		res.Failed++
+	}
+}
+
+// err returns an error if the change failed for any reminder.
+func (res *writeResult) err() error {
+	if res.Failed == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d of %d reminder(s) failed", res.Failed, res.Succeeded+res.Failed)
+}
+
+type addArgs struct {
+	Title       string `json:"title" desc:"Title"`
+	List        string `json:"list" desc:"List name"`
+	Due         string `json:"due,omitempty" desc:"Due date: YYYY-MM-DD, \"YYYY-MM-DD HH:MM\" or relative (tomorrow, \"fri 9am\", +3d)"`
+	Priority    string `json:"priority,omitempty" enum:"high,medium,low,none" desc:"Priority"`
+	Notes       string `json:"notes,omitempty" desc:"Notes"`
+	Parent      string `json:"parent,omitempty" desc:"Make it a subtask of this reminder (ID)"`
+	Repeat      string `json:"repeat,omitempty" desc:"Repeat rule: daily, weekly, monthly, yearly or \"every N days|weeks|months|years\" (needs due)"`
+	RepeatUntil string `json:"repeat_until,omitempty" desc:"Last date it repeats (YYYY-MM-DD or relative, e.g. +6m)"`
+}
+
+func (s *Server) add(args *addArgs, res *writeResult) error {
+	if strings.TrimSpace(args.Title) == "" || args.List == "" {
+		return fmt.Errorf("title and list are required")
+	}
+	if err := checkPriority(args.Priority); err != nil {
+		return err
+	}
+	parent, err := s.parentID(args.Parent)
+	if err != nil {
+		return err
+	}
+	result, err := s.Writer.AddReminder(args.Title, args.List, args.Due, args.Priority, args.Notes, parent, args.Repeat, args.RepeatUntil)
+	if err := daemon.WriteError(result, err); err != nil {
+		return err
+	}
+	id, _ := result["reminder_id"].(string)
+	res.add(&entry{Reminder: s.reminder(id, nil), OK: true})
+	return nil
+}
+
+type addBatchArgs struct {
+	Titles []string `json:"titles" desc:"Titles of the reminders to add"`
+	List   string   `json:"list" desc:"List name"`
+	Parent string   `json:"parent,omitempty" desc:"Make them subtasks of this reminder (ID)"`
+}
+
+func (s *Server) addBatch(args *addBatchArgs, res *writeResult) error {
+	if len(args.Titles) == 0 || args.List == "" {
+		return fmt.Errorf("titles and list are required")
+	}
+	parent, err := s.parentID(args.Parent)
+	if err != nil {
+		return err
+	}
+	result, err := s.Writer.AddRemindersBatch(args.Titles, args.List, parent)
+	if err := daemon.WriteError(result, err); err != nil {
+		return err
+	}
+	ids, _ := result["reminder_ids"].([]string)
+	for _, id := range ids {
+		res.add(&entry{Reminder: s.reminder(id, nil), OK: true})
+	}
+	return nil
+}
+
+type idsArgs struct {
+	IDs []string `json:"ids" desc:"Reminder IDs"`
+}
+
+func (s *Server) complete(args *idsArgs, res *writeResult) error {
+	return s.bulk(args.IDs, res, s.Writer.CompleteReminders)
+}
+
+func (s *Server) delete(args *idsArgs, res *writeResult) error {
+	return s.bulk(args.IDs, res, s.Writer.DeleteReminders)
+}
+
+type editArgs struct {
+	IDs         []string `json:"ids" desc:"Reminder IDs"`
+	Title       string   `json:"title,omitempty" desc:"New title"`
+	Due         string   `json:"due,omitempty" desc:"New due date: YYYY-MM-DD, \"YYYY-MM-DD HH:MM\" or relative (tomorrow, \"fri 9am\", +3d)"`
+	Notes       string   `json:"notes,omitempty" desc:"New notes"`
+	Priority    string   `json:"priority,omitempty" enum:"high,medium,low,none" desc:"New priority"`
+	Repeat      string   `json:"repeat,omitempty" desc:"New repeat rule: daily, weekly, monthly, yearly, \"every N weeks\", or none to stop repeating"`
+	RepeatUntil string   `json:"repeat_until,omitempty" desc:"New last date it repeats (YYYY-MM-DD or relative)"`
+	Parent      string   `json:"parent,omitempty" desc:"Make it a subtask of this reminder (ID, same list), or none to make it top-level"`
+	ClearDue    bool     `json:"clear_due,omitempty" desc:"Remove the due date"`
+	ClearNotes  bool     `json:"clear_notes,omitempty" desc:"Remove the notes"`
+}
+
+func (s *Server) edit(args *editArgs, res *writeResult) error {
+	f := writer.EditFields{
+		Title:       args.Title,
+		Due:         args.Due,
+		Notes:       args.Notes,
+		Priority:    args.Priority,
+		Repeat:      args.Repeat,
+		RepeatUntil: args.RepeatUntil,
+		Parent:      args.Parent,
+		ClearDue:    args.ClearDue,
+		ClearNotes:  args.ClearNotes,
+	}
+	if f == (writer.EditFields{}) {
+		return fmt.Errorf("no changes given")
+	}
+	if err := checkPriority(args.Priority); err != nil {
+		return err
+	}
+	if f.Parent != "none" {
+		var err error
+		if f.Parent, err = s.parentID(f.Parent); err != nil {
+			return err
+		}
+	}
+	return s.bulk(args.IDs, res, func(ids []string) (map[string]interface{}, error) {
+		return s.Writer.EditReminders(ids, f)
+	})
+}
+
+// bulk resolves ids and runs a bulk writer on them, adding one entry per
+// reminder to res: as it is afterwards, or as it was if it is gone.
+func (s *Server) bulk(ids []string, res *writeResult, run func(ids []string) (map[string]interface{}, error)) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("ids is required")
+	}
+	var uuids []string
+	before := make(map[string]*models.Reminder)
+	for _, id := range ids {
+		rid, err := s.findReminder(id)
+		if err != nil {
+			res.add(&entry{Reminder: &models.Reminder{ID: id}, Error: err.Error()})
+			continue
+		}
+		u := rid[strings.LastIndexByte(rid, '/')+1:]
+		uuids = append(uuids, u)
+		before[u] = s.Engine.GetReminder(rid)
+	}
+
+	if len(uuids) > 0 {
+		result, err := run(uuids)
+		if err := daemon.WriteError(result, err); err != nil {
+			return err
+		}
+		entries, _ := result["results"].([]map[string]interface{})
+		for _, e := range entries {
+			id, _ := e["id"].(string)
+			ok, _ := e["ok"].(bool)
+			msg, _ := e["error"].(string)
+			next, _ := e["next_due"].(string)
+			res.add(&entry{Reminder: s.reminder(s.Engine.FindReminderByID(id), before[id]), OK: ok, Error: msg, NextDue: next})
+		}
+	}
+	return res.err()
+}
+
+// reminder returns the reminder with record name id from the cache, else
+// fallback, else a reminder with just the ID.
+func (s *Server) reminder(id string, fallback *models.Reminder) *models.Reminder {
+	if r := s.Engine.GetReminder(id); r != nil {
+		return r
+	}
+	if fallback != nil {
+		return fallback
+	}
+	return &models.Reminder{ID: id}
+}
+
+// minIDPrefix is the shortest reminder ID prefix findReminder accepts.
+const minIDPrefix = 4
+
+// findReminder resolves a reminder's record name, its UUID, or a prefix of
+// at least minIDPrefix characters of the UUID to its record name. Shorter
+// prefixes are refused so a typo does not pick some other reminder.
+func (s *Server) findReminder(id string) (string, error) {
+	if s.Engine.Cache.Reminders[id] != nil {
+		return id, nil
+	}
+	short := id[strings.LastIndexByte(id, '/')+1:]
+	if len(short) < minIDPrefix {
+		return "", fmt.Errorf("reminder '%s' not found (give at least %d characters of the ID)", id, minIDPrefix)
+	}
+	fullID := s.Engine.FindReminderByID(short)
+	if fullID == "" {
+		return "", fmt.Errorf("reminder '%s' not found", id)
+	}
+	return fullID, nil
+}
+
+// parentID resolves a parent reminder (see findReminder) to the UUID the
+// writer looks up, "" for none.
+func (s *Server) parentID(parent string) (string, error) {
+	if parent == "" {
+		return "", nil
+	}
+	fullID, err := s.findReminder(parent)
+	if err != nil {
+		return "", fmt.Errorf("parent: %v", err)
+	}
+	return fullID[strings.LastIndexByte(fullID, '/')+1:], nil
+}
+
+// checkPriority validates a priority name.
+func checkPriority(p string) error {
+	if _, ok := models.PriorityMap[p]; p != "" && !ok {
+		return fmt.Errorf("invalid priority %q (use: high, medium, low, none)", p)
+	}
+	return nil
+}
+
+// sortReminders orders reminders by list, then title.
+func sortReminders(reminders []*models.Reminder) {
+	sort.SliceStable(reminders, func(i, j int) bool {
+		a, b := reminders[i], reminders[j]
+		if a.ListName != b.ListName {
+			return a.ListName < b.ListName
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+}
@@ -0,0 +1,137 @@
+package plaintext
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// CSV mapping: a header row names the columns, in any order and case.
+//
+//	title     required
+//	list      list name ("" = the import's default list)
+//	due       any form 'reminders add --due' accepts
+//	priority  high, medium, low or none
+//	notes     notes; cells may span lines when quoted
+//	parent    title of the parent: another row in the same list, or an
+//	          existing reminder there
+//
+// Rows are numbered by the line they start on, so the header is row 1, as
+// in a spreadsheet.
+
+// csvColumns are the known CSV columns.
+var csvColumns = []string{"title", "list", "due", "priority", "notes", "parent"}
+
+// DecodeCSV reads a CSV file with a header row. Every row is checked before
+// any is returned; all invalid rows are reported together, by row number.
+func DecodeCSV(r io.Reader) ([]*Task, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("empty file")
+	}
+	if err != nil {
+		return nil, err
+	}
+	col, err := csvHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*Task
+	var problems []string
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("row %d: %w", parseErr.StartLine, parseErr.Err)
+		}
+		if err != nil {
+			return nil, err
+		}
+		row, _ := cr.FieldPos(0)
+		cell := func(name string) string {
+			if i, ok := col[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		t := &Task{
+			Title:       cell("title"),
+			List:        cell("list"),
+			Due:         cell("due"),
+			Notes:       cell("notes"),
+			ParentTitle: cell("parent"),
+			Line:        row,
+		}
+		var rowProblems []string
+		if t.Title == "" {
+			rowProblems = append(rowProblems, "missing title")
+		}
+		if t.Due != "" {
+			if _, _, err := utils.ParseDue(t.Due); err != nil {
+				rowProblems = append(rowProblems, err.Error())
+			}
+		}
+		if p := strings.ToLower(cell("priority")); p != "" {
+			v, ok := models.PriorityMap[p]
+			if !ok {
+				rowProblems = append(rowProblems, fmt.Sprintf("invalid priority %q (use: high, medium, low, none)", cell("priority")))
+			}
+			t.Priority = v
+		}
+		if t.ParentTitle != "" && strings.EqualFold(t.ParentTitle, t.Title) {
+			rowProblems = append(rowProblems, "reminder is its own parent")
+		}
+		for _, p := range rowProblems {
+			problems = append(problems, fmt.Sprintf("row %d: %s", row, p))
+		}
+		tasks = append(tasks, t)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%d problem(s), nothing imported:\n  %s", len(problems), strings.Join(problems, "\n  "))
+	}
+	return tasks, nil
+}
+
+// csvHeader maps the known columns of a header row to their positions.
+func csvHeader(header []string) (map[string]int, error) {
+	col := make(map[string]int)
+	for i, name := range header {
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		known := false
+		for _, c := range csvColumns {
+			known = known || c == name
+		}
+		if !known {
+			return nil, fmt.Errorf("row 1: unknown column %q (use: %s)", header[i], strings.Join(csvColumns, ", "))
+		}
+		if _, dup := col[name]; dup {
+			return nil, fmt.Errorf("row 1: duplicate column %q", header[i])
+		}
+		col[name] = i
+	}
+	if _, ok := col["title"]; !ok {
+		return nil, fmt.Errorf("row 1: missing title column (columns: %s)", strings.Join(csvColumns, ", "))
+	}
+	return col, nil
+}
@@ -0,0 +1,336 @@
+package plaintext
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// Markdown checklist mapping, following the Obsidian Tasks emoji format:
+//
+//	# Groceries                   heading = list (any level; the nearest above)
+//	- [ ] Milk                    open task; [x] = completed
+//		- [ ] Butter              indented task = subtask
+//		Notes                     other lines indented under a task = notes
+//	⏫ 🔼 🔽                      priority high, medium, low
+//	📅 2026-10-20                 due date (the local day for timed dates)
+//	✅ 2026-10-01                 completion date
+//	🔁 every 2 weeks until 2026-12-31
+//	🆔 …                          reminder ID
+//
+// Fenced code blocks and lines that are not tasks, headings or notes are
+// ignored, so a checklist can live inside an ordinary note.
+
+// Markdown task signifiers.
+const (
+	mdHigh    = "⏫"
+	mdMedium  = "🔼"
+	mdLow     = "🔽"
+	mdHighest = "🔺" // read as high
+	mdLowest  = "⏬" // read as low
+	mdDue     = "📅"
+	mdDone    = "✅"
+	mdRepeat  = "🔁"
+	mdID      = "🆔"
+)
+
+var mdSignifiers = []string{mdHigh, mdMedium, mdLow, mdHighest, mdLowest, mdDue, mdDone, mdRepeat, mdID}
+
+var mdPrioritySignifiers = map[int]string{1: mdHigh, 5: mdMedium, 9: mdLow}
+
+// EncodeMarkdown writes reminders as a checklist with one heading per list
+// and subtasks nested below their parents.
+func EncodeMarkdown(w io.Writer, reminders []*models.Reminder, _ string) error {
+	bw := bufio.NewWriter(w)
+	inFile := make(map[string]bool, len(reminders))
+	for _, r := range reminders {
+		inFile[r.ID] = true
+	}
+	var lists []string
+	roots := make(map[string][]*models.Reminder)
+	children := make(map[string][]*models.Reminder)
+	for _, r := range reminders {
+		if r.ParentRef != nil && inFile[*r.ParentRef] {
+			children[*r.ParentRef] = append(children[*r.ParentRef], r)
+			continue
+		}
+		if _, ok := roots[r.ListName]; !ok {
+			lists = append(lists, r.ListName)
+		}
+		roots[r.ListName] = append(roots[r.ListName], r)
+	}
+
+	var write func(r *models.Reminder, depth int)
+	write = func(r *models.Reminder, depth int) {
+		indent := strings.Repeat("\t", depth)
+		box := " "
+		if r.Completed {
+			box = "x"
+		}
+		fmt.Fprintf(bw, "%s- [%s] %s%s\n", indent, box, r.Title, markdownSignifiers(r))
+		if r.Notes != nil && *r.Notes != "" {
+			for _, line := range strings.Split(*r.Notes, "\n") {
+				fmt.Fprintf(bw, "%s\t%s\n", indent, strings.TrimRight(line, "\r"))
+			}
+		}
+		for _, c := range children[r.ID] {
+			write(c, depth+1)
+		}
+	}
+	for i, list := range lists {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		name := list
+		if name == "" || name == "?" {
+			name = "Inbox"
+		}
+		fmt.Fprintf(bw, "# %s\n\n", name)
+		for _, r := range roots[list] {
+			write(r, 0)
+		}
+	}
+	return bw.Flush()
+}
+
+// markdownSignifiers returns the signifiers written after a task's title.
+func markdownSignifiers(r *models.Reminder) string {
+	var b strings.Builder
+	if s := mdPrioritySignifiers[r.Priority]; s != "" {
+		b.WriteString(" " + s)
+	}
+	if r.Recurrence != nil {
+		fmt.Fprintf(&b, " %s %s", mdRepeat, markdownRule(r.Recurrence))
+	}
+	if r.Due != nil && *r.Due != "" {
+		fmt.Fprintf(&b, " %s %s", mdDue, utils.DisplayDue(*r.Due)[:len(utils.DateLayout)])
+	}
+	if r.Completed && r.CompletionDate != nil && *r.CompletionDate != "" {
+		fmt.Fprintf(&b, " %s %s", mdDone, *r.CompletionDate)
+	}
+	fmt.Fprintf(&b, " %s %s", mdID, r.ShortID())
+	return b.String()
+}
+
+// markdownRule writes a recurrence the way Obsidian Tasks does, always in
+// the "every …" form: "every week", "every 2 months until 2026-12-31".
+func markdownRule(rule *models.Recurrence) string {
+	unit := map[string]string{"daily": "day", "weekly": "week", "monthly": "month", "yearly": "year"}[rule.Frequency]
+	s := "every " + unit
+	if rule.Interval > 1 {
+		s = fmt.Sprintf("every %d %ss", rule.Interval, unit)
+	}
+	if rule.Until != nil && *rule.Until != "" {
+		s += " until " + *rule.Until
+	}
+	return s
+}
+
+// DecodeMarkdown reads the checklist items of a Markdown file. Tasks take
+// the list of the nearest heading above them (subtasks, their parent's).
+func DecodeMarkdown(r io.Reader) ([]*Task, error) {
+	type open struct {
+		task   *Task
+		column int
+	}
+	var tasks []*Task
+	var stack []open // enclosing tasks, innermost last
+	var notes []string
+	heading := ""
+	fence := ""
+	flushNotes := func() {
+		if len(stack) > 0 {
+			for len(notes) > 0 && notes[len(notes)-1] == "" {
+				notes = notes[:len(notes)-1]
+			}
+			// A task's notes may resume after its subtasks.
+			if t := stack[len(stack)-1].task; len(notes) > 0 && t.Notes != "" {
+				t.Notes += "\n" + strings.Join(notes, "\n")
+			} else if len(notes) > 0 {
+				t.Notes = strings.Join(notes, "\n")
+			}
+		}
+		notes = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		raw := strings.TrimRight(scanner.Text(), " \t\r")
+		column, text := markdownIndent(raw)
+
+		if fence != "" {
+			if strings.HasPrefix(text, fence) {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(text, "```") || strings.HasPrefix(text, "~~~") {
+			flushNotes()
+			stack = nil
+			fence = text[:3]
+			continue
+		}
+		if text == "" {
+			if len(stack) > 0 && len(notes) > 0 {
+				notes = append(notes, "")
+			}
+			continue
+		}
+
+		// Anything not indented under the innermost task closes it.
+		if len(stack) > 0 && column <= stack[len(stack)-1].column {
+			flushNotes()
+			for len(stack) > 0 && stack[len(stack)-1].column >= column {
+				stack = stack[:len(stack)-1]
+			}
+		}
+
+		if box, rest, ok := markdownCheckbox(text); ok {
+			flushNotes()
+			t, err := parseMarkdownTask(rest)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			t.Line = n
+			t.Completed = box == 'x' || box == 'X'
+			if len(stack) > 0 {
+				t.Parent = stack[len(stack)-1].task
+				t.List = t.Parent.List
+			} else {
+				t.List = heading
+			}
+			tasks = append(tasks, t)
+			stack = append(stack, open{t, column})
+			continue
+		}
+		if column == 0 && strings.HasPrefix(text, "#") {
+			if level := len(text) - len(strings.TrimLeft(text, "#")); level <= 6 && (len(text) == level || text[level] == ' ') {
+				heading = strings.TrimSpace(strings.TrimRight(strings.TrimSpace(text[level:]), "#"))
+				stack = nil
+				continue
+			}
+		}
+		if len(stack) > 0 {
+			notes = append(notes, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flushNotes()
+	return tasks, nil
+}
+
+// markdownIndent returns a line's indentation in columns (tabs advance to
+// the next multiple of four) and its text without the indentation.
+func markdownIndent(line string) (int, string) {
+	column, i := 0, 0
+	for ; i < len(line); i++ {
+		switch line[i] {
+		case ' ':
+			column++
+			continue
+		case '\t':
+			column += 4 - column%4
+			continue
+		}
+		break
+	}
+	return column, line[i:]
+}
+
+// markdownCheckbox splits a "- [ ] text" list item (also with "*" or "+")
+// into the checkbox character and the text.
+func markdownCheckbox(text string) (byte, string, bool) {
+	if len(text) < 5 || !strings.ContainsRune("-*+", rune(text[0])) ||
+		text[1] != ' ' || text[2] != '[' || text[4] != ']' {
+		return 0, "", false
+	}
+	if len(text) > 5 && text[5] != ' ' {
+		return 0, "", false
+	}
+	return text[3], strings.TrimSpace(text[5:]), true
+}
+
+// parseMarkdownTask reads the text of a checklist item after its checkbox.
+// The title is the text before the first known signifier; unknown emoji and
+// tags stay in it.
+func parseMarkdownTask(text string) (*Task, error) {
+	t := &Task{}
+	first := len(text)
+	for _, s := range mdSignifiers {
+		if i := strings.Index(text, s); i >= 0 && i < first {
+			first = i
+		}
+	}
+	title := []string{strings.TrimSpace(text[:first])}
+
+	rest := text[first:]
+	for rest != "" {
+		sig := ""
+		for _, s := range mdSignifiers {
+			if strings.HasPrefix(rest, s) {
+				sig = s
+				break
+			}
+		}
+		rest = rest[len(sig):]
+		next := len(rest)
+		for _, s := range mdSignifiers {
+			if i := strings.Index(rest, s); i >= 0 && i < next {
+				next = i
+			}
+		}
+		value := strings.TrimSpace(rest[:next])
+		rest = rest[next:]
+
+		// Date and ID signifiers take one word; the rest of the segment
+		// belongs to the title.
+		word, extra, _ := strings.Cut(value, " ")
+		switch sig {
+		case mdHigh, mdHighest:
+			t.Priority = models.PriorityMap["high"]
+			extra = value
+		case mdMedium:
+			t.Priority = models.PriorityMap["medium"]
+			extra = value
+		case mdLow, mdLowest:
+			t.Priority = models.PriorityMap["low"]
+			extra = value
+		case mdDue:
+			if !datePattern.MatchString(word) {
+				return nil, fmt.Errorf("invalid due date %q", word)
+			}
+			t.Due = word
+		case mdDone:
+			if !datePattern.MatchString(word) {
+				return nil, fmt.Errorf("invalid done date %q", word)
+			}
+			t.CompletionDate = word
+		case mdRepeat:
+			rule, err := parseRule(value)
+			if err != nil {
+				return nil, err
+			}
+			t.Recurrence = rule
+			extra = ""
+		case mdID:
+			t.ID = word
+		}
+		if extra = strings.TrimSpace(extra); extra != "" {
+			title = append(title, extra)
+		}
+	}
+	t.Title = strings.TrimSpace(strings.Join(title, " "))
+	if t.Title == "" {
+		return nil, fmt.Errorf("missing title")
+	}
+	return t, nil
+}
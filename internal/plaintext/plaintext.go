@@ -0,0 +1,107 @@
+// Package plaintext reads and writes reminders as plain-text task files:
+// todo.txt (todotxt.go), TaskPaper (taskpaper.go) and Markdown checklists
+// (markdown.go). It also reads CSV (csv.go).
+//
+// todo.txt and TaskPaper carry every field the CLI supports — title, notes,
+// list, due date, priority, completion, repeat rule and parent — so a file
+// written by Encode reads back to the same reminders. Reminder IDs are kept
+// (id:…, @id(…) and 🆔) so re-importing an exported file can be recognized.
+package plaintext
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"icloud-reminders/pkg/models"
+)
+
+// Task is a reminder read from a task file.
+type Task struct {
+	ID             string // reminder ID from the file, "" if none
+	Title          string
+	Notes          string
+	List           string // list name as written, "" if the file does not say
+	Due            string // as written: YYYY-MM-DD or RFC 3339
+	Priority       int    // 0 (none), 1 (high), 5 (medium) or 9 (low)
+	Completed      bool
+	CompletionDate string // YYYY-MM-DD, "" if not given
+	// Parent is the parent task when it is in the same file; otherwise
+	// ParentID names it (a reminder ID).
+	Parent   *Task
+	ParentID string
+	// ParentTitle names the parent by title (CSV).
+	ParentTitle string
+	Recurrence  *models.Recurrence
+	Line        int // line number, for error messages
+}
+
+// datePattern matches a YYYY-MM-DD date.
+var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+
+// priorityName returns the models.PriorityMap name of a priority value.
+func priorityName(p int) string {
+	for name, v := range models.PriorityMap {
+		if v == p && name != "none" {
+			return name
+		}
+	}
+	return ""
+}
+
+// shortID strips a "Reminder/" prefix from a record name.
+func shortID(id string) string {
+	if i := strings.LastIndexByte(id, '/'); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
+
+// compactRule writes a recurrence as N<unit> (1d, 2w, 3m, 1y), the todo.txt
+// rec: convention.
+func compactRule(rule *models.Recurrence) string {
+	unit := map[string]string{"daily": "d", "weekly": "w", "monthly": "m", "yearly": "y"}[rule.Frequency]
+	return fmt.Sprintf("%d%s", rule.Interval, unit)
+}
+
+// parseCompactRule reads a rec: value such as 2w or +1m (a leading "+",
+// strict recurrence in some tools, is accepted and ignored).
+func parseCompactRule(s, until string) (*models.Recurrence, error) {
+	s = strings.TrimPrefix(s, "+")
+	if len(s) < 2 {
+		return nil, fmt.Errorf("invalid repeat %q", s)
+	}
+	freq := map[byte]string{'d': "daily", 'w': "weekly", 'm': "monthly", 'y': "yearly"}[s[len(s)-1]]
+	n, err := strconv.Atoi(s[:len(s)-1])
+	if freq == "" || err != nil || n < 1 {
+		return nil, fmt.Errorf("invalid repeat %q (use e.g. 1d, 2w, 3m, 1y)", s)
+	}
+	rule := &models.Recurrence{Frequency: freq, Interval: n}
+	if until != "" {
+		rule.Until = &until
+	}
+	return rule, nil
+}
+
+// parseRule reads a repeat spec in models.Recurrence.String form, e.g.
+// "every 2 weeks until 2026-12-31".
+func parseRule(s string) (*models.Recurrence, error) {
+	spec, until, _ := strings.Cut(s, " until ")
+	rule, err := models.ParseRecurrence(spec, strings.TrimSpace(until))
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, fmt.Errorf("invalid repeat %q", s)
+	}
+	return rule, nil
+}
+
+// checkDate validates a YYYY-MM-DD or RFC 3339 date value.
+func checkDate(key, v string) error {
+	if datePattern.MatchString(v) || (len(v) > 10 && datePattern.MatchString(v[:10]) && v[10] == 'T') {
+		return nil
+	}
+	return fmt.Errorf("invalid %s date %q", key, v)
+}
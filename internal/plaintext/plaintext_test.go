@@ -0,0 +1,106 @@
+package plaintext_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"icloud-reminders/internal/plaintext"
+	"icloud-reminders/pkg/models"
+)
+
+func str(s string) *string { return &s }
+
+func uuid(n string) string {
+	return strings.Repeat(n, 8) + "-0000-0000-0000-000000000000"
+}
+
+// reminder returns an active reminder with ID Reminder/<uuid(n)>.
+func reminder(n, title, list string) *models.Reminder {
+	return &models.Reminder{ID: "Reminder/" + uuid(n), Title: title, ListName: list}
+}
+
+// roundTrip encodes reminders, decodes the output and checks that each task
+// reads back as its reminder. list maps a reminder's list name to the name
+// the format writes.
+func roundTrip(t *testing.T, reminders []*models.Reminder,
+	encode func(*bytes.Buffer, []*models.Reminder) error,
+	decode func(*bytes.Buffer) ([]*plaintext.Task, error),
+	list func(string) string) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := encode(&buf, reminders); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.String()
+	tasks, err := decode(&buf)
+	if err != nil {
+		t.Fatalf("decode: %v\n%s", err, encoded)
+	}
+	if len(tasks) != len(reminders) {
+		t.Fatalf("decoded %d tasks, want %d:\n%s", len(tasks), len(reminders), encoded)
+	}
+	byID := make(map[string]*plaintext.Task)
+	for _, task := range tasks {
+		byID[task.ID] = task
+	}
+	for _, r := range reminders {
+		task := byID[r.ShortID()]
+		if task == nil {
+			t.Errorf("%q: no task with ID %s in\n%s", r.Title, r.ShortID(), encoded)
+			continue
+		}
+		check := func(field string, got, want interface{}) {
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%q: %s = %#v, want %#v\n%s", r.Title, field, got, want, encoded)
+			}
+		}
+		check("title", task.Title, r.Title)
+		check("notes", task.Notes, deref(r.Notes))
+		check("due", task.Due, deref(r.Due))
+		check("priority", task.Priority, r.Priority)
+		check("completed", task.Completed, r.Completed)
+		check("completion date", task.CompletionDate, deref(r.CompletionDate))
+		check("recurrence", task.Recurrence, r.Recurrence)
+		if r.ParentRef != nil {
+			if task.Parent == nil {
+				t.Errorf("%q: no parent", r.Title)
+			} else {
+				check("parent", task.Parent.ID, strings.TrimPrefix(*r.ParentRef, "Reminder/"))
+			}
+		} else {
+			check("parent", task.Parent, (*plaintext.Task)(nil))
+			check("list", task.List, list(r.ListName))
+		}
+	}
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// awkwardTitles are titles that a naive line format would misread.
+var awkwardTitles = []string{
+	"x marks the spot",
+	"x",
+	"(A) grade paper",
+	"2026-10-01 report",
+	"Ask about due:friday",
+	"+1 for the idea",
+	"rename id:abc and note:this",
+	"title:like this",
+	"pri:A was wrong",
+	"until:later rec:never parent:me",
+	"two  spaces",
+	" leading space",
+	"trailing space ",
+	"tab\there",
+	"50% off",
+	"email @alice about it",
+	"Agenda:",
+	"- dash",
+}
@@ -0,0 +1,279 @@
+package plaintext
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"icloud-reminders/pkg/models"
+)
+
+// TaskPaper mapping (https://www.taskpaper.com):
+//
+//	Groceries:                    project = list (@list(Name) on a task overrides it)
+//		- Milk @priority(high)        task; indentation under a task = subtask
+//			Notes, one line each      other lines indented under a task = notes;
+//			\- not a task             a \ starts a note line that would otherwise be
+//	                              read as a task or project, or that starts with
+//	                              whitespace or \ (the \ is dropped on import)
+//	@due(2026-10-20)              due date (RFC 3339 for timed dates)
+//	@done(2026-10-01)             completed, with completion date
+//	@repeat(every 2 weeks until 2026-12-31)
+//	@id(…) @parent(…)             reminder ID; parent outside the file
+
+// tagPattern matches a TaskPaper tag, @name or @name(value).
+var tagPattern = regexp.MustCompile(`(?:^|\s)@([A-Za-z][\w-]*)(?:\(([^)]*)\))?`)
+
+// EncodeTaskPaper writes reminders as one project per list, with subtasks
+// indented below their parents.
+func EncodeTaskPaper(w io.Writer, reminders []*models.Reminder, _ string) error {
+	bw := bufio.NewWriter(w)
+	inFile := make(map[string]bool, len(reminders))
+	children := make(map[string][]*models.Reminder)
+	for _, r := range reminders {
+		inFile[r.ID] = true
+	}
+	var lists []string
+	roots := make(map[string][]*models.Reminder)
+	for _, r := range reminders {
+		if r.ParentRef != nil && inFile[*r.ParentRef] {
+			children[*r.ParentRef] = append(children[*r.ParentRef], r)
+			continue
+		}
+		if _, ok := roots[r.ListName]; !ok {
+			lists = append(lists, r.ListName)
+		}
+		roots[r.ListName] = append(roots[r.ListName], r)
+	}
+
+	var write func(r *models.Reminder, depth int)
+	write = func(r *models.Reminder, depth int) {
+		indent := strings.Repeat("\t", depth)
+		fmt.Fprintf(bw, "%s- %s%s\n", indent, r.Title, taskPaperTags(r, inFile))
+		if r.Notes != nil && *r.Notes != "" {
+			for _, line := range strings.Split(*r.Notes, "\n") {
+				fmt.Fprintf(bw, "%s\t%s\n", indent, escapeNoteLine(strings.TrimRight(line, "\r")))
+			}
+		}
+		for _, c := range children[r.ID] {
+			write(c, depth+1)
+		}
+	}
+	for i, list := range lists {
+		if i > 0 {
+			fmt.Fprintln(bw)
+		}
+		name := list
+		if name == "" || name == "?" {
+			name = "Inbox"
+		}
+		fmt.Fprintf(bw, "%s:\n", name)
+		for _, r := range roots[list] {
+			write(r, 1)
+		}
+	}
+	return bw.Flush()
+}
+
+// taskPaperTags returns the tags written after a task's title.
+func taskPaperTags(r *models.Reminder, inFile map[string]bool) string {
+	var b strings.Builder
+	if name := priorityName(r.Priority); name != "" {
+		fmt.Fprintf(&b, " @priority(%s)", name)
+	}
+	if r.Due != nil && *r.Due != "" {
+		fmt.Fprintf(&b, " @due(%s)", *r.Due)
+	}
+	if r.Recurrence != nil {
+		fmt.Fprintf(&b, " @repeat(%s)", r.Recurrence.String())
+	}
+	if r.Completed {
+		if r.CompletionDate != nil && *r.CompletionDate != "" {
+			fmt.Fprintf(&b, " @done(%s)", *r.CompletionDate)
+		} else {
+			b.WriteString(" @done")
+		}
+	}
+	fmt.Fprintf(&b, " @id(%s)", r.ShortID())
+	if r.ParentRef != nil && *r.ParentRef != "" && !inFile[*r.ParentRef] {
+		fmt.Fprintf(&b, " @parent(%s)", shortID(*r.ParentRef))
+	}
+	return b.String()
+}
+
+// DecodeTaskPaper reads a TaskPaper file. Tasks take the list of the
+// nearest project above them (subtasks, their parent's); unknown tags stay
+// in the title.
+func DecodeTaskPaper(r io.Reader) ([]*Task, error) {
+	type open struct {
+		task  *Task
+		depth int
+	}
+	var tasks []*Task
+	var stack []open // enclosing tasks, innermost last
+	var notes []string
+	var noteTask *Task
+	project := ""
+	flushNotes := func() {
+		if noteTask != nil {
+			for len(notes) > 0 && notes[len(notes)-1] == "" {
+				notes = notes[:len(notes)-1]
+			}
+			noteTask.Notes = strings.Join(notes, "\n")
+		}
+		notes, noteTask = nil, nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		raw := strings.TrimRight(scanner.Text(), "\r")
+		depth, text := taskPaperIndent(raw)
+		for len(stack) > 0 && stack[len(stack)-1].depth >= depth && text != "" {
+			stack = stack[:len(stack)-1]
+		}
+
+		switch {
+		case text == "":
+			// A blank line inside notes is part of them; trailing ones are dropped.
+			if noteTask != nil {
+				notes = append(notes, "")
+			}
+		case isTaskPaperTask(text):
+			flushNotes()
+			t, err := parseTaskPaperTask(strings.TrimPrefix(text, "-"))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			t.Line = n
+			if len(stack) > 0 {
+				t.Parent = stack[len(stack)-1].task
+				t.ParentID = ""
+			}
+			// Subtasks live in their parent's list.
+			if t.List == "" && t.Parent != nil {
+				t.List = t.Parent.List
+			} else if t.List == "" {
+				t.List = project
+			}
+			tasks = append(tasks, t)
+			stack = append(stack, open{t, depth})
+			noteTask = t
+		case isTaskPaperProject(text) && !strings.HasPrefix(text, `\`):
+			flushNotes()
+			project = strings.TrimSpace(text[:strings.LastIndex(text, ":")])
+			stack = nil
+		default:
+			if len(stack) > 0 && noteTask == stack[len(stack)-1].task {
+				notes = append(notes, strings.TrimPrefix(text, `\`))
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flushNotes()
+	return tasks, nil
+}
+
+// taskPaperIndent returns a line's indentation depth (a tab or four spaces
+// per level) and its text without the indentation.
+func taskPaperIndent(line string) (int, string) {
+	depth, spaces, i := 0, 0, 0
+	for ; i < len(line); i++ {
+		switch line[i] {
+		case '\t':
+			depth++
+			spaces = 0
+			continue
+		case ' ':
+			if spaces++; spaces == 4 {
+				depth++
+				spaces = 0
+			}
+			continue
+		}
+		break
+	}
+	return depth, strings.TrimRight(line[i:], " \t")
+}
+
+// escapeNoteLine prefixes a note line with \ if it would not read back as
+// written: as a task, a project, or with its leading whitespace or \ lost.
+func escapeNoteLine(line string) string {
+	if isTaskPaperTask(line) || isTaskPaperProject(line) || strings.HasPrefix(line, `\`) ||
+		strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t") {
+		return `\` + line
+	}
+	return line
+}
+
+// isTaskPaperTask reports whether text is a task line.
+func isTaskPaperTask(text string) bool {
+	return strings.HasPrefix(text, "- ") || text == "-"
+}
+
+// isTaskPaperProject reports whether text is a project line: it ends with a
+// colon, optionally followed by tags.
+func isTaskPaperProject(text string) bool {
+	stripped := strings.TrimSpace(tagPattern.ReplaceAllString(text, ""))
+	return strings.HasSuffix(stripped, ":") && len(stripped) > 1
+}
+
+// parseTaskPaperTask reads the text of a task line after its dash.
+func parseTaskPaperTask(text string) (*Task, error) {
+	t := &Task{}
+	var err error
+	title := tagPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := tagPattern.FindStringSubmatch(m)
+		name, value := strings.ToLower(sub[1]), strings.TrimSpace(sub[2])
+		switch name {
+		case "priority":
+			p, ok := models.PriorityMap[strings.ToLower(value)]
+			if !ok && err == nil {
+				err = fmt.Errorf("invalid priority %q (use: high, medium, low)", value)
+			}
+			t.Priority = p
+		case "due":
+			if e := checkDate("due", value); e != nil && err == nil {
+				err = e
+			}
+			t.Due = value
+		case "done":
+			t.Completed = true
+			if value != "" {
+				if !datePattern.MatchString(value) && err == nil {
+					err = fmt.Errorf("invalid done date %q", value)
+				}
+				t.CompletionDate = value
+			}
+		case "repeat":
+			rule, e := parseRule(value)
+			if e != nil && err == nil {
+				err = e
+			}
+			t.Recurrence = rule
+		case "id":
+			t.ID = value
+		case "parent":
+			t.ParentID = value
+		case "list":
+			t.List = value
+		default:
+			return m
+		}
+		return ""
+	})
+	if err != nil {
+		return nil, err
+	}
+	t.Title = strings.TrimSpace(title)
+	if t.Title == "" {
+		return nil, fmt.Errorf("missing title")
+	}
+	return t, nil
+}
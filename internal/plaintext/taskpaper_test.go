@@ -0,0 +1,109 @@
+package plaintext_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"icloud-reminders/internal/plaintext"
+	"icloud-reminders/pkg/models"
+)
+
+func taskPaperRoundTrip(t *testing.T, reminders []*models.Reminder) {
+	t.Helper()
+	roundTrip(t, reminders,
+		func(buf *bytes.Buffer, rs []*models.Reminder) error { return plaintext.EncodeTaskPaper(buf, rs, "") },
+		func(buf *bytes.Buffer) ([]*plaintext.Task, error) { return plaintext.DecodeTaskPaper(buf) },
+		func(list string) string { return list })
+}
+
+func TestTaskPaperRoundTrip(t *testing.T) {
+	milk := reminder("a", "Buy milk", "Groceries")
+	milk.Priority = 1
+	milk.Due = str("2026-10-20")
+
+	call := reminder("b", "Call Bob", "Work")
+	call.Due = str("2026-10-20T09:00:00Z")
+	call.Priority = 5
+	call.Recurrence = &models.Recurrence{Frequency: "monthly", Interval: 3, Until: str("2027-01-31")}
+	call.Notes = str("Agenda:\n- budget\n-\n  indented\n\ttabbed\n\\\\server\\share\n\nlast line")
+
+	done := reminder("c", "Send invoice", "Work")
+	done.Completed = true
+	done.CompletionDate = str("2026-10-01")
+
+	doneUndated := reminder("d", "File taxes", "Work")
+	doneUndated.Completed = true
+
+	sub := reminder("e", "Ask for a receipt", "Work")
+	sub.ParentRef = str(call.ID)
+	sub.Notes = str("- not a subtask")
+	subsub := reminder("f", "Scan it", "Work")
+	subsub.ParentRef = str(sub.ID)
+
+	taskPaperRoundTrip(t, []*models.Reminder{milk, call, done, doneUndated, sub, subsub})
+}
+
+func TestTaskPaperRoundTripAwkwardTitles(t *testing.T) {
+	for _, title := range awkwardTitles {
+		if strings.TrimSpace(title) != title {
+			continue // TaskPaper does not keep surrounding whitespace
+		}
+		r := reminder("a", title, "Inbox")
+		r.Notes = str(title)
+		taskPaperRoundTrip(t, []*models.Reminder{r})
+	}
+}
+
+func TestTaskPaperNoteEscaping(t *testing.T) {
+	r := reminder("a", "Plan", "Work")
+	r.Notes = str("Agenda:\n- item\nplain")
+	var buf bytes.Buffer
+	if err := plaintext.EncodeTaskPaper(&buf, []*models.Reminder{r}, ""); err != nil {
+		t.Fatal(err)
+	}
+	want := "Work:\n\t- Plan @id(" + uuid("a") + ")\n\t\t\\Agenda:\n\t\t\\- item\n\t\tplain\n"
+	if got := buf.String(); got != want {
+		t.Errorf("encoded\n%q, want\n%q", got, want)
+	}
+}
+
+func TestTaskPaperDecode(t *testing.T) {
+	tasks, err := plaintext.DecodeTaskPaper(strings.NewReader(strings.Join([]string{
+		"Home:",
+		"    - Fix bike @priority(low) @flag",
+		"        - Buy tube @list(Errands)",
+		"            Note for the bike task? No: for Buy tube.",
+		"Work: @focus",
+		"\t- Report @done(2026-10-02) @repeat(every 2 weeks until 2026-12-31)",
+	}, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("decoded %d tasks, want 3", len(tasks))
+	}
+	if got := tasks[0]; got.Title != "Fix bike @flag" || got.List != "Home" || got.Priority != 9 {
+		t.Errorf("task 1 = %+v", got)
+	}
+	if got := tasks[1]; got.Parent != tasks[0] || got.List != "Errands" || got.Notes != "Note for the bike task? No: for Buy tube." {
+		t.Errorf("task 2 = %+v", got)
+	}
+	rule := tasks[2].Recurrence
+	if got := tasks[2]; got.List != "Work" || !got.Completed || got.CompletionDate != "2026-10-02" ||
+		rule == nil || rule.Frequency != "weekly" || rule.Interval != 2 || rule.Until == nil || *rule.Until != "2026-12-31" {
+		t.Errorf("task 3 = %+v", got)
+	}
+
+	for _, text := range []string{
+		"L:\n\t- Milk @priority(urgent)",
+		"L:\n\t- Milk @due(someday)",
+		"L:\n\t- Milk @done(yesterday)",
+		"L:\n\t- Milk @repeat(hourly)",
+		"L:\n\t- @id(abc)",
+	} {
+		if _, err := plaintext.DecodeTaskPaper(strings.NewReader(text)); err == nil {
+			t.Errorf("DecodeTaskPaper(%q) succeeded, want an error", text)
+		}
+	}
+}
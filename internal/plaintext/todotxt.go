@@ -0,0 +1,290 @@
+package plaintext
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// todo.txt mapping (http://todotxt.org):
+//
+//	x 2026-10-01 Title            completed, with completion date
+//	(A) (B) (C)                   priority high, medium, low; pri:A when completed
+//	2026-09-30 x-ray              creation date, written before a title that
+//	                              starts with "x", "(A)" or a date
+//	+Project                      list (the last +word; spaces are written as "_")
+//	due:2026-10-20                due date (RFC 3339 for timed dates)
+//	rec:2w until:2026-12-31       repeat rule (d, w, m, y)
+//	id:… parent:…                 reminder ID and parent reminder ID
+//	note:…                        notes, percent-encoded (%20 space, %0A newline)
+//	title:…                       the title, percent-encoded, when it would not
+//	                              read back as written (it contains a +word or
+//	                              a word such as due:x, or runs of spaces)
+
+var todoPriorityLetters = map[int]string{1: "A", 5: "B", 9: "C"}
+
+var todoLetterPriorities = map[string]int{"A": 1, "B": 5, "C": 9}
+
+// noteEscaper percent-encodes the characters that would end a todo.txt tag.
+var noteEscaper = strings.NewReplacer("%", "%25", " ", "%20", "\t", "%09", "\n", "%0A", "\r", "%0D")
+
+// todoTags are the key:value tags read as reminder fields.
+var todoTags = map[string]bool{
+	"due": true, "pri": true, "rec": true, "until": true,
+	"id": true, "parent": true, "note": true, "title": true,
+}
+
+// EncodeTodoTxt writes one todo.txt line per reminder. Reminders should be
+// ordered with parents first (the order is kept on import).
+func EncodeTodoTxt(w io.Writer, reminders []*models.Reminder, _ string) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range reminders {
+		var parts []string
+		letter := todoPriorityLetters[r.Priority]
+		if r.Completed {
+			parts = append(parts, "x")
+			if r.CompletionDate != nil && *r.CompletionDate != "" {
+				parts = append(parts, *r.CompletionDate)
+			}
+		} else if letter != "" {
+			parts = append(parts, "("+letter+")")
+		}
+		noCompletionDate := r.Completed && (r.CompletionDate == nil || *r.CompletionDate == "")
+		titleTag := needsTitleTag(r.Title) || (noCompletionDate && needsCreationDate(r.Title))
+		if !titleTag {
+			if needsCreationDate(r.Title) {
+				// Keeps the title from being read back as a completion
+				// mark, priority or date.
+				parts = append(parts, creationDate(r))
+			}
+			parts = append(parts, r.Title)
+		}
+		if r.ListName != "" && r.ListName != "?" {
+			parts = append(parts, "+"+strings.ReplaceAll(r.ListName, " ", "_"))
+		}
+		if r.Due != nil && *r.Due != "" {
+			parts = append(parts, "due:"+*r.Due)
+		}
+		if r.Completed && letter != "" {
+			parts = append(parts, "pri:"+letter)
+		}
+		if rule := r.Recurrence; rule != nil {
+			parts = append(parts, "rec:"+compactRule(rule))
+			if rule.Until != nil && *rule.Until != "" {
+				parts = append(parts, "until:"+*rule.Until)
+			}
+		}
+		if r.Notes != nil && *r.Notes != "" {
+			parts = append(parts, "note:"+noteEscaper.Replace(*r.Notes))
+		}
+		if titleTag {
+			parts = append(parts, "title:"+noteEscaper.Replace(r.Title))
+		}
+		parts = append(parts, "id:"+r.ShortID())
+		if r.ParentRef != nil && *r.ParentRef != "" {
+			parts = append(parts, "parent:"+shortID(*r.ParentRef))
+		}
+		if _, err := fmt.Fprintln(bw, strings.Join(parts, " ")); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// needsCreationDate reports whether a title's first word would be taken for
+// a completion mark, priority or date at the start of a todo.txt line.
+func needsCreationDate(title string) bool {
+	words := strings.Fields(title)
+	if len(words) == 0 {
+		return false
+	}
+	_, isPriority := todoPriority(words[0])
+	return words[0] == "x" || isPriority || datePattern.MatchString(words[0])
+}
+
+// needsTitleTag reports whether a title would not read back from a plain
+// todo.txt line: a word in it would be taken for a tag or the list, or
+// its spacing would be lost.
+func needsTitleTag(title string) bool {
+	words := strings.Fields(title)
+	if strings.Join(words, " ") != title {
+		return true
+	}
+	for _, word := range words {
+		key, value, isTag := strings.Cut(word, ":")
+		if isTag && value != "" && todoTags[key] {
+			return true
+		}
+		if len(word) > 1 && word[0] == '+' {
+			return true
+		}
+	}
+	return false
+}
+
+// creationDate returns the date written as a reminder's creation date.
+// iCloud does not expose when a reminder was created; the last modification
+// stands in for it.
+func creationDate(r *models.Reminder) string {
+	if r.ModifiedTS != nil && *r.ModifiedTS != 0 {
+		return utils.TsToStr(*r.ModifiedTS)
+	}
+	return utils.Now().UTC().Format("2006-01-02")
+}
+
+// DecodeTodoTxt reads a todo.txt file. Blank lines are skipped; contexts
+// (@word) and unknown key:value tags stay in the title.
+func DecodeTodoTxt(r io.Reader) ([]*Task, error) {
+	var tasks []*Task
+	byID := make(map[string]*Task)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		t, err := parseTodoLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		t.Line = n
+		tasks = append(tasks, t)
+		if t.ID != "" {
+			byID[t.ID] = t
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, t := range tasks {
+		if p := byID[t.ParentID]; p != nil && t.ParentID != "" {
+			t.Parent, t.ParentID = p, ""
+		}
+	}
+	return tasks, nil
+}
+
+func parseTodoLine(line string) (*Task, error) {
+	t := &Task{}
+	words := strings.Fields(line)
+	i := 0
+	if words[0] == "x" {
+		t.Completed = true
+		i++
+		// Completion date, optionally followed by the creation date.
+		for k := 0; k < 2 && i < len(words) && datePattern.MatchString(words[i]); k++ {
+			if k == 0 {
+				t.CompletionDate = words[i]
+			}
+			i++
+		}
+	} else {
+		if p, ok := todoPriority(words[0]); ok {
+			t.Priority = p
+			i++
+		}
+		if i < len(words) && datePattern.MatchString(words[i]) {
+			i++ // creation date
+		}
+	}
+
+	var title []string
+	project := -1
+	until, rec, titleTag := "", "", ""
+	for _, word := range words[i:] {
+		key, value, isTag := strings.Cut(word, ":")
+		if isTag && value != "" {
+			switch key {
+			case "due":
+				if err := checkDate("due", value); err != nil {
+					return nil, err
+				}
+				t.Due = value
+				continue
+			case "pri":
+				p, ok := todoLetterPriorities[strings.ToUpper(value)]
+				if !ok {
+					return nil, fmt.Errorf("invalid priority %q", value)
+				}
+				t.Priority = p
+				continue
+			case "rec":
+				rec = value
+				continue
+			case "until":
+				if !datePattern.MatchString(value) {
+					return nil, fmt.Errorf("invalid until date %q", value)
+				}
+				until = value
+				continue
+			case "id":
+				t.ID = value
+				continue
+			case "parent":
+				t.ParentID = value
+				continue
+			case "note":
+				notes, err := url.PathUnescape(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid note encoding: %w", err)
+				}
+				t.Notes = notes
+				continue
+			case "title":
+				decoded, err := url.PathUnescape(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid title encoding: %w", err)
+				}
+				titleTag = decoded
+				continue
+			}
+		}
+		if len(word) > 1 && word[0] == '+' {
+			project = len(title)
+		}
+		title = append(title, word)
+	}
+	if project >= 0 {
+		t.List = title[project][1:]
+		title = append(title[:project], title[project+1:]...)
+	}
+	if rec != "" {
+		rule, err := parseCompactRule(rec, until)
+		if err != nil {
+			return nil, err
+		}
+		t.Recurrence = rule
+	} else if until != "" {
+		return nil, fmt.Errorf("until: without rec:")
+	}
+	t.Title = strings.Join(title, " ")
+	if titleTag != "" {
+		if t.Title != "" {
+			return nil, fmt.Errorf("title: tag given with a title")
+		}
+		t.Title = titleTag
+	}
+	if t.Title == "" {
+		return nil, fmt.Errorf("missing title")
+	}
+	return t, nil
+}
+
+// todoPriority reads a "(A)" priority. Letters past C count as low.
+func todoPriority(word string) (int, bool) {
+	if len(word) != 3 || word[0] != '(' || word[2] != ')' || word[1] < 'A' || word[1] > 'Z' {
+		return 0, false
+	}
+	if p, ok := todoLetterPriorities[word[1:2]]; ok {
+		return p, true
+	}
+	return 9, true
+}
@@ -0,0 +1,128 @@
+package plaintext_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"icloud-reminders/internal/plaintext"
+	"icloud-reminders/pkg/models"
+)
+
+func todoRoundTrip(t *testing.T, reminders []*models.Reminder) {
+	t.Helper()
+	roundTrip(t, reminders,
+		func(buf *bytes.Buffer, rs []*models.Reminder) error { return plaintext.EncodeTodoTxt(buf, rs, "") },
+		func(buf *bytes.Buffer) ([]*plaintext.Task, error) { return plaintext.DecodeTodoTxt(buf) },
+		func(list string) string { return strings.ReplaceAll(list, " ", "_") })
+}
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	milk := reminder("a", "Buy milk", "Groceries")
+	milk.Priority = 1
+	milk.Due = str("2026-10-20")
+	milk.Notes = str("2% fat\nsemi-skimmed, not  whole")
+
+	call := reminder("b", "Call Bob", "Work Stuff")
+	call.Due = str("2026-10-20T09:00:00Z")
+	call.Priority = 5
+	call.Recurrence = &models.Recurrence{Frequency: "weekly", Interval: 2, Until: str("2026-12-31")}
+
+	done := reminder("c", "Send invoice", "Work Stuff")
+	done.Completed = true
+	done.CompletionDate = str("2026-10-01")
+	done.Priority = 9
+
+	doneUndated := reminder("d", "File taxes", "Work Stuff")
+	doneUndated.Completed = true
+
+	sub := reminder("e", "Ask for a receipt", "Work Stuff")
+	sub.ParentRef = str(done.ID)
+
+	todoRoundTrip(t, []*models.Reminder{milk, call, done, doneUndated, sub})
+}
+
+func TestTodoTxtRoundTripAwkwardTitles(t *testing.T) {
+	for _, title := range awkwardTitles {
+		for _, list := range []string{"Inbox", ""} {
+			active := reminder("a", title, list)
+			active.Priority = 1
+
+			done := reminder("b", title, list)
+			done.Completed = true
+			done.CompletionDate = str("2026-10-01")
+
+			// Without a completion date a creation date cannot be told
+			// from one, so titles that need it are written as title:.
+			doneUndated := reminder("c", title, list)
+			doneUndated.Completed = true
+			doneUndated.Priority = 5
+
+			todoRoundTrip(t, []*models.Reminder{active, done, doneUndated})
+		}
+	}
+}
+
+func TestTodoTxtEncoding(t *testing.T) {
+	r := reminder("a", "Ask about due:friday", "Inbox")
+	plain := reminder("b", "Buy milk", "Inbox")
+	done := reminder("c", "x marks the spot", "")
+	done.Completed = true
+
+	var buf bytes.Buffer
+	if err := plaintext.EncodeTodoTxt(&buf, []*models.Reminder{r, plain, done}, ""); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{
+		"+Inbox title:Ask%20about%20due:friday id:" + uuid("a"),
+		"Buy milk +Inbox id:" + uuid("b"),
+		// No completion date is invented.
+		"x title:x%20marks%20the%20spot id:" + uuid("c"),
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("encoded %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i+1, lines[i], want[i])
+		}
+	}
+}
+
+func TestTodoTxtDecode(t *testing.T) {
+	tasks, err := plaintext.DecodeTodoTxt(strings.NewReader(strings.Join([]string{
+		"(A) 2026-09-30 Call mom +Family @phone due:2026-10-20",
+		"x 2026-10-01 2026-09-01 Pay rent +Home pri:B",
+		"",
+		"(B) Check +Home project and +Work, last one wins key:value",
+	}, "\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tasks) != 3 {
+		t.Fatalf("decoded %d tasks, want 3", len(tasks))
+	}
+	if got := tasks[0]; got.Title != "Call mom @phone" || got.List != "Family" || got.Priority != 1 || got.Due != "2026-10-20" {
+		t.Errorf("task 1 = %+v", got)
+	}
+	if got := tasks[1]; got.Title != "Pay rent" || !got.Completed || got.CompletionDate != "2026-10-01" || got.Priority != 5 {
+		t.Errorf("task 2 = %+v", got)
+	}
+	if got := tasks[2]; got.Title != "Check +Home project and last one wins key:value" || got.List != "Work," || got.Line != 4 {
+		t.Errorf("task 3 = %+v", got)
+	}
+
+	for _, line := range []string{
+		"Buy milk due:someday",
+		"Buy milk pri:Z",
+		"Buy milk until:2026-12-31",
+		"Buy milk title:Other",
+		"title:bad%zzencoding",
+		"+List",
+	} {
+		if _, err := plaintext.DecodeTodoTxt(strings.NewReader(line)); err == nil {
+			t.Errorf("DecodeTodoTxt(%q) succeeded, want an error", line)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+package search
+
+// Exported for search_test.
+var (
+	EditDistance = editDistance
+	AllowedTypos = allowedTypos
+)
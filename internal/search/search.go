@@ -0,0 +1,431 @@
+// Package search implements ranked full-text search over reminder titles
+// and notes.
+//
+// A query is a list of terms that must all match. Terms are separated by
+// spaces; quote a phrase to keep it together ("buy milk"). A term may be
+// scoped to one field with a prefix:
+//
+//	invoice              title or notes contain "invoice"
+//	notes:invoice        only the notes
+//	title:"tax return"   only the title
+//
+// Matching is case-insensitive. By default a term that does not occur
+// literally may still match a word within a small edit distance (one typo
+// for words of 4–7 letters, two for longer ones); Options.Exact disables
+// that. With Options.Regex every term is a regular expression.
+//
+// Results are ranked: title matches beat notes matches, literal matches
+// beat fuzzy ones, and matches at the start of a word or of the whole
+// title score extra.
+package search
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"icloud-reminders/pkg/models"
+)
+
+// Options controls how terms are matched.
+type Options struct {
+	// Regex treats every term as a regular expression.
+	Regex bool
+	// Exact disables typo-tolerant fuzzy matching.
+	Exact bool
+}
+
+// Field is the part of a reminder a term is matched against.
+type Field int
+
+const (
+	// AnyField matches the title or the notes.
+	AnyField Field = iota
+	TitleField
+	NotesField
+)
+
+// Span is a matched byte range [Start, End) within a text.
+type Span struct {
+	Start, End int
+}
+
+// Result is a reminder that matched a query.
+type Result struct {
+	Reminder *models.Reminder
+	Score    int
+	// TitleSpans and NotesSpans are the matched ranges, sorted and merged.
+	TitleSpans []Span
+	NotesSpans []Span
+}
+
+// Query is a compiled search query.
+type Query struct {
+	terms []*term
+	exact bool
+}
+
+type term struct {
+	text  string // lowercased literal text ("" in regex mode)
+	field Field
+	re    *regexp.Regexp
+}
+
+// Scores awarded per term; see package doc.
+const (
+	scoreTitle       = 10
+	scoreNotes       = 4
+	scoreWordStart   = 3
+	scoreWholeTitle  = 10
+	scoreExtraHit    = 1
+	maxExtraHits     = 3
+	fuzzyPenaltyEach = 2
+)
+
+// Parse compiles a query string.
+func Parse(q string, opts Options) (*Query, error) {
+	words, err := splitQuery(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty search query")
+	}
+	query := &Query{exact: opts.Exact || opts.Regex}
+	for _, w := range words {
+		t := &term{field: AnyField}
+		lower := strings.ToLower(w)
+		switch {
+		case strings.HasPrefix(lower, "title:"):
+			t.field, w = TitleField, w[len("title:"):]
+		case strings.HasPrefix(lower, "notes:"):
+			t.field, w = NotesField, w[len("notes:"):]
+		}
+		if w == "" {
+			return nil, fmt.Errorf("empty search term after field prefix")
+		}
+		pattern := regexp.QuoteMeta(w)
+		if opts.Regex {
+			pattern = w
+		} else {
+			t.text = strings.ToLower(w)
+		}
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %w", w, err)
+		}
+		t.re = re
+		query.terms = append(query.terms, t)
+	}
+	return query, nil
+}
+
+// splitQuery splits q on spaces, keeping double-quoted phrases together.
+// Quotes may follow a field prefix: notes:"late fee".
+func splitQuery(q string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	inQuote, have := false, false
+	for _, r := range q {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			have = true
+		case unicode.IsSpace(r) && !inQuote:
+			if have {
+				words = append(words, cur.String())
+				cur.Reset()
+				have = false
+			}
+		default:
+			cur.WriteRune(r)
+			have = true
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quote in search query")
+	}
+	if have && cur.Len() > 0 {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+// Match scores r against the query. ok is false unless every term matches.
+func (q *Query) Match(r *models.Reminder) (res *Result, ok bool) {
+	notes := ""
+	if r.Notes != nil {
+		notes = *r.Notes
+	}
+	res = &Result{Reminder: r}
+	for _, t := range q.terms {
+		matched := false
+		if t.field != NotesField {
+			if score, spans := q.score(t, r.Title, true); score > 0 {
+				res.Score += score
+				res.TitleSpans = append(res.TitleSpans, spans...)
+				matched = true
+			}
+		}
+		if t.field != TitleField {
+			if score, spans := q.score(t, notes, false); score > 0 {
+				res.Score += score
+				res.NotesSpans = append(res.NotesSpans, spans...)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, false
+		}
+	}
+	res.TitleSpans = mergeSpans(res.TitleSpans)
+	res.NotesSpans = mergeSpans(res.NotesSpans)
+	return res, true
+}
+
+// Search returns the reminders matching the query, best match first.
+// Ties go to open reminders, then to titles in alphabetical order.
+func (q *Query) Search(reminders []*models.Reminder) []*Result {
+	var results []*Result
+	for _, r := range reminders {
+		if res, ok := q.Match(r); ok {
+			results = append(results, res)
+		}
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Reminder.Completed != b.Reminder.Completed {
+			return !a.Reminder.Completed
+		}
+		return strings.ToLower(a.Reminder.Title) < strings.ToLower(b.Reminder.Title)
+	})
+	return results
+}
+
+// score matches one term against text and returns its score (0 for no
+// match) and the matched spans.
+func (q *Query) score(t *term, text string, isTitle bool) (int, []Span) {
+	if text == "" {
+		return 0, nil
+	}
+	base := scoreNotes
+	if isTitle {
+		base = scoreTitle
+	}
+
+	if locs := t.re.FindAllStringIndex(text, -1); len(locs) > 0 {
+		score := base
+		spans := make([]Span, 0, len(locs))
+		for i, loc := range locs {
+			if loc[0] == loc[1] {
+				continue // empty regex match
+			}
+			spans = append(spans, Span{loc[0], loc[1]})
+			if i == 0 && atWordStart(text, loc[0]) {
+				score += scoreWordStart
+			}
+		}
+		if len(spans) == 0 {
+			return 0, nil
+		}
+		extra := len(spans) - 1
+		if extra > maxExtraHits {
+			extra = maxExtraHits
+		}
+		score += extra * scoreExtraHit
+		if isTitle && len(spans) == 1 && spans[0].Start == 0 && spans[0].End == len(text) {
+			score += scoreWholeTitle
+		}
+		return score, spans
+	}
+
+	if q.exact || t.text == "" || strings.ContainsRune(t.text, ' ') {
+		return 0, nil
+	}
+	maxDist := allowedTypos(t.text)
+	if maxDist == 0 {
+		return 0, nil
+	}
+	best := -1
+	var spans []Span
+	for _, w := range words(text) {
+		d := editDistance(t.text, strings.ToLower(text[w.Start:w.End]), maxDist)
+		if d > maxDist {
+			continue
+		}
+		spans = append(spans, w)
+		if best < 0 || d < best {
+			best = d
+		}
+	}
+	if best < 0 {
+		return 0, nil
+	}
+	score := base/2 - best*fuzzyPenaltyEach
+	if score < 1 {
+		score = 1
+	}
+	return score, spans
+}
+
+// allowedTypos is the edit distance tolerated for a fuzzy term.
+func allowedTypos(s string) int {
+	n := utf8.RuneCountInString(s)
+	switch {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	}
+	return 0
+}
+
+// words returns the spans of the letter/digit runs in text.
+func words(text string) []Span {
+	var spans []Span
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			spans = append(spans, Span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, Span{start, len(text)})
+	}
+	return spans
+}
+
+func atWordStart(text string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b (insertions, deletions, substitutions and transpositions), or max+1 as
+// soon as it is known to exceed max.
+func editDistance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return max + 1
+	}
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			v := min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				v = min(v, prev2[j-2]+1)
+			}
+			cur[j] = v
+			rowMin = min(rowMin, v)
+		}
+		if rowMin > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// mergeSpans sorts spans and merges overlapping ones.
+func mergeSpans(spans []Span) []Span {
+	if len(spans) < 2 {
+		return spans
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].Start < spans[j].Start })
+	out := spans[:1]
+	for _, s := range spans[1:] {
+		last := &out[len(out)-1]
+		if s.Start <= last.End {
+			if s.End > last.End {
+				last.End = s.End
+			}
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// Highlight returns text with every span wrapped by mark.
+func Highlight(text string, spans []Span, mark func(string) string) string {
+	var b strings.Builder
+	pos := 0
+	for _, s := range spans {
+		b.WriteString(text[pos:s.Start])
+		b.WriteString(mark(text[s.Start:s.End]))
+		pos = s.End
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// Snippet returns a single-line excerpt of text of about width runes around
+// the first span, with the spans inside it highlighted by mark. Elided text
+// is marked with "…".
+func Snippet(text string, spans []Span, width int, mark func(string) string) string {
+	if len(spans) == 0 {
+		return ""
+	}
+	first := spans[0]
+	start := first.Start
+	for n := 0; start > 0 && n < width/3; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	end := first.End
+	for n := utf8.RuneCountInString(text[start:first.End]); end < len(text) && n < width; n++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+	// Prefer to cut at spaces rather than inside words.
+	if start > 0 {
+		if i := strings.IndexByte(text[start:first.Start], ' '); i >= 0 {
+			start += i + 1
+		}
+	}
+	if end < len(text) {
+		if i := strings.LastIndexByte(text[first.End:end], ' '); i >= 0 {
+			end = first.End + i
+		}
+	}
+
+	var inside []Span
+	for _, s := range spans {
+		if s.Start >= start && s.End <= end {
+			inside = append(inside, Span{s.Start - start, s.End - start})
+		}
+	}
+	out := Highlight(text[start:end], inside, mark)
+	out = strings.Join(strings.Fields(out), " ")
+	if start > 0 {
+		out = "…" + out
+	}
+	if end < len(text) {
+		out += "…"
+	}
+	return out
+}
@@ -0,0 +1,276 @@
+package search_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"icloud-reminders/internal/search"
+	"icloud-reminders/pkg/models"
+)
+
+func reminder(title, notes string) *models.Reminder {
+	r := &models.Reminder{Title: title}
+	if notes != "" {
+		r.Notes = &notes
+	}
+	return r
+}
+
+func brackets(s string) string { return "[" + s + "]" }
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{"milk", "milk", 2, 0},
+		{"milk", "mlik", 2, 1}, // transposition
+		{"milk", "silk", 2, 1},
+		{"milk", "mil", 2, 1},
+		{"milk", "milks", 2, 1},
+		{"invoice", "invocie", 2, 1},
+		{"receipt", "reciept", 2, 1},
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 1, 2}, // stops at max+1
+		{"ab", "abcdef", 2, 3},      // length difference alone exceeds max
+		{"café", "cafe", 2, 1},      // runes, not bytes
+		{"", "abc", 3, 3},
+	}
+	for _, tt := range tests {
+		if got := search.EditDistance(tt.a, tt.b, tt.max); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestAllowedTypos(t *testing.T) {
+	for s, want := range map[string]int{
+		"":            0,
+		"tax":         0,
+		"milk":        1,
+		"invoice":     1,
+		"receipts":    2,
+		"appointment": 2,
+		"café":        1, // 4 runes, 5 bytes
+		"日本語":         0,
+	} {
+		if got := search.AllowedTypos(s); got != want {
+			t.Errorf("allowedTypos(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+// TestScore pins the ranking constants: 10 for a title match, 4 for a notes
+// match, +3 at a word start, +10 for the whole title, +1 per extra hit (at
+// most 3), and half the field score less 2 per typo (at least 1) for fuzzy
+// matches.
+func TestScore(t *testing.T) {
+	tests := []struct {
+		query        string
+		opts         search.Options
+		title, notes string
+		want         int // 0 for no match
+	}{
+		{"milk", search.Options{}, "Milk", "", 23},
+		{"milk", search.Options{}, "Buy milk", "", 13},
+		{"ilk", search.Options{}, "Buy milk", "", 10},
+		{"milk", search.Options{}, "Groceries", "get milk", 7},
+		{"milk", search.Options{}, "Groceries", "buttermilk", 4},
+		{"milk", search.Options{}, "Milk", "milk", 30},
+		{"milk", search.Options{}, "milk, milk and milk", "", 15},
+		{"milk", search.Options{}, "milk milk milk milk milk", "", 16},
+		{"buy milk", search.Options{}, "Buy milk", "", 26}, // two terms
+		{`"buy milk"`, search.Options{}, "Buy milk", "", 23},
+		{`"buy milk"`, search.Options{}, "Buy some milk", "", 0},
+
+		// Fuzzy matches.
+		{"mlik", search.Options{}, "Buy milk", "", 3},
+		{"mlik", search.Options{}, "Groceries", "milk", 1},
+		{"appointmnet", search.Options{}, "Dentist appointment", "", 3},
+		{"apointmnet", search.Options{}, "Dentist appointment", "", 1},
+		{"mlk", search.Options{}, "Buy milk", "", 0}, // too short for a typo
+		{"mlik", search.Options{Exact: true}, "Buy milk", "", 0},
+		{`"by milk"`, search.Options{}, "Buy milk", "", 0}, // phrases are literal
+
+		// Field scopes.
+		{"title:milk", search.Options{}, "Milk", "milk", 23},
+		{"title:milk", search.Options{}, "Groceries", "milk", 0},
+		{"notes:milk", search.Options{}, "Milk", "milk", 7},
+		{"notes:milk", search.Options{}, "Milk", "", 0},
+		{`NOTES:"late fee"`, search.Options{}, "Rent", "pay the late fee", 7},
+
+		// Regular expressions.
+		{"^b.y", search.Options{Regex: true}, "Buy milk", "", 13},
+		{"m[aeiou]lk", search.Options{Regex: true}, "Buy milk", "", 13},
+		{"mlik", search.Options{Regex: true}, "Buy milk", "", 0},
+		{"x*", search.Options{Regex: true}, "Buy milk", "", 0}, // only empty matches
+		{"title:^milk$", search.Options{Regex: true}, "Milk", "", 23},
+	}
+	for _, tt := range tests {
+		q, err := search.Parse(tt.query, tt.opts)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.query, err)
+			continue
+		}
+		res, ok := q.Match(reminder(tt.title, tt.notes))
+		got := 0
+		if ok {
+			got = res.Score
+		}
+		if got != tt.want {
+			t.Errorf("%q (%+v) on %q/%q scores %d, want %d", tt.query, tt.opts, tt.title, tt.notes, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range []struct {
+		query string
+		opts  search.Options
+	}{
+		{"", search.Options{}},
+		{"   ", search.Options{}},
+		{`"buy milk`, search.Options{}},
+		{"title:", search.Options{}},
+		{"(", search.Options{Regex: true}},
+	} {
+		if _, err := search.Parse(tt.query, tt.opts); err == nil {
+			t.Errorf("Parse(%q, %+v) succeeded, want an error", tt.query, tt.opts)
+		}
+	}
+	// Regex metacharacters are literal without Options.Regex.
+	if _, err := search.Parse("(", search.Options{}); err != nil {
+		t.Errorf("Parse(%q): %v", "(", err)
+	}
+}
+
+func TestSearchOrder(t *testing.T) {
+	done := reminder("milk", "")
+	done.Completed = true
+	reminders := []*models.Reminder{
+		reminder("Mlik shake", ""),
+		reminder("Groceries", "milk, eggs"),
+		reminder("Buy milk", ""),
+		done,
+		reminder("Milk run", "milk and bread"),
+		reminder("Bakery", "buttermilk"),
+		reminder("Buy almond milk", ""),
+		reminder("Milk", ""),
+		reminder("Invoice", ""),
+		reminder("Reinvest savings", ""),
+		reminder("Send invoice", "invoice attached"),
+		reminder("Invoce Bob", ""),
+		reminder("Taxes", "the invoice is late"),
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		// Ties go to open reminders, then to titles in alphabetical order.
+		{"milk", []string{"Milk", "milk", "Milk run", "Buy almond milk", "Buy milk", "Groceries", "Bakery", "Mlik shake"}},
+		// Prefixes: a word start beats the middle of a word.
+		{"inv", []string{"Send invoice", "Invoce Bob", "Invoice", "Reinvest savings", "Taxes"}},
+		// Typos: a literal match beats a fuzzy one, a fuzzy title match a
+		// fuzzy notes match, and matching both beats either.
+		{"invoce", []string{"Invoce Bob", "Send invoice", "Invoice", "Taxes"}},
+		{"notes:milk", []string{"Groceries", "Milk run", "Bakery"}},
+		{"title:milk", []string{"Milk", "milk", "Buy almond milk", "Buy milk", "Milk run", "Mlik shake"}},
+		{"title:milk notes:bread", []string{"Milk run"}},
+		{"notes:invoice", []string{"Send invoice", "Taxes"}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		q, err := search.Parse(tt.query, search.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, res := range q.Search(reminders) {
+			got = append(got, res.Reminder.Title)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Search(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatchSpans(t *testing.T) {
+	tests := []struct {
+		query        string
+		title, notes string
+		wantTitle    string // title with the spans in brackets
+		wantNotes    string
+	}{
+		{"milk", "Buy milk", "milk, not Milk", "Buy [milk]", "[milk], not [Milk]"},
+		{"mil ilk", "Buy milk", "", "Buy [milk]", ""}, // overlapping spans merge
+		{"buy milk", "Buy milk", "", "[Buy] [milk]", ""},
+		{"mlik", "Buy milk, mlk or silk", "", "Buy [milk], [mlk] or silk", ""}, // fuzzy spans are words
+		{"title:café", "Café au lait", "café", "[Café] au lait", "café"},
+	}
+	for _, tt := range tests {
+		q, err := search.Parse(tt.query, search.Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := reminder(tt.title, tt.notes)
+		res, ok := q.Match(r)
+		if !ok {
+			t.Errorf("%q does not match %q", tt.query, tt.title)
+			continue
+		}
+		if got := search.Highlight(tt.title, res.TitleSpans, brackets); got != tt.wantTitle {
+			t.Errorf("%q: title %q, want %q", tt.query, got, tt.wantTitle)
+		}
+		if got := search.Highlight(tt.notes, res.NotesSpans, brackets); got != tt.wantNotes {
+			t.Errorf("%q: notes %q, want %q", tt.query, got, tt.wantNotes)
+		}
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		text  string
+		spans []search.Span
+		want  string
+	}{
+		{"Buy milk", nil, "Buy milk"},
+		{"Buy milk", []search.Span{{0, 3}, {4, 8}}, "[Buy] [milk]"},
+		{"Buy milk", []search.Span{{0, 8}}, "[Buy milk]"},
+		{"Grüße an Jörg", []search.Span{{0, 7}, {11, 16}}, "[Grüße] an [Jörg]"},
+	}
+	for _, tt := range tests {
+		if got := search.Highlight(tt.text, tt.spans, brackets); got != tt.want {
+			t.Errorf("Highlight(%q, %v) = %q, want %q", tt.text, tt.spans, got, tt.want)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := "aaaa bbbb cccc dddd eeee milk ffff gggg hhhh iiii jjjj"
+	at := strings.Index(long, "milk")
+	tests := []struct {
+		text  string
+		spans []search.Span
+		width int
+		want  string
+	}{
+		{"get milk", nil, 40, ""},
+		{"get milk today", []search.Span{{4, 8}}, 40, "get [milk] today"},
+		{"get\nmilk\n\ttoday", []search.Span{{4, 8}}, 40, "get [milk] today"},
+		// Cut at spaces on both sides, with ellipses.
+		{long, []search.Span{{at, at + 4}}, 12, "…[milk]…"},
+		{long, []search.Span{{at, at + 4}}, 24, "…eeee [milk] ffff gggg…"},
+		// Spans outside the excerpt are not marked.
+		{long, []search.Span{{at, at + 4}, {50, 54}}, 24, "…eeee [milk] ffff gggg…"},
+		{long, []search.Span{{at, at + 4}, {30, 34}}, 24, "…eeee [milk] [ffff] gggg…"},
+		// Runes, not bytes.
+		{"ääää öööö milk üüüü", []search.Span{{len("ääää öööö "), len("ääää öööö milk")}}, 15, "…[milk] üüüü"},
+	}
+	for _, tt := range tests {
+		if got := search.Snippet(tt.text, tt.spans, tt.width, brackets); got != tt.want {
+			t.Errorf("Snippet(%q, %v, %d) = %q, want %q", tt.text, tt.spans, tt.width, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package server
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"icloud-reminders/internal/daemon"
+	"icloud-reminders/internal/filter"
+	"icloud-reminders/internal/writer"
+	"icloud-reminders/pkg/models"
+)
+
+// listBody is a list with its number of active reminders.
+type listBody struct {
+	*models.ReminderList
+	Active int `json:"active"`
+}
+
+// getLists handles GET /lists.
+func (s *Server) getLists(r *http.Request) (int, interface{}, error) {
+	active := make(map[string]int)
+	for _, rd := range s.Engine.Cache.Reminders {
+		if rd.ListRef != nil && !rd.Completed {
+			active[*rd.ListRef]++
+		}
+	}
+	lists := s.Engine.GetLists()
+	sort.Slice(lists, func(i, j int) bool { return lists[i].Name < lists[j].Name })
+	body := make([]*listBody, len(lists))
+	for i, lst := range lists {
+		body[i] = &listBody{ReminderList: lst, Active: active[lst.ID]}
+	}
+	return http.StatusOK, body, nil
+}
+
+// listRequest is the body of POST and PATCH /lists.
+type listRequest struct {
+	Name  string `json:"name"`
+	Color string `json:"color"`
+}
+
+// createList handles POST /lists.
+func (s *Server) createList(r *http.Request) (int, interface{}, error) {
+	var req listRequest
+	if err := decodeBody(r, &req); err != nil {
+		return 0, nil, err
+	}
+	if err := s.beforeWrite(); err != nil {
+		return 0, nil, err
+	}
+	result, err := s.Writer.CreateList(req.Name, req.Color)
+	if err := daemon.WriteError(result, err); err != nil {
+		return 0, nil, err
+	}
+	id, _ := result["list_id"].(string)
+	return http.StatusCreated, s.list(id), nil
+}
+
+// updateList handles PATCH /lists/{id}: rename and/or recolor.
+func (s *Server) updateList(r *http.Request) (int, interface{}, error) {
+	id, err := s.findList(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	var req listRequest
+	if err := decodeBody(r, &req); err != nil {
+		return 0, nil, err
+	}
+	if err := s.beforeWrite(); err != nil {
+		return 0, nil, err
+	}
+	result, err := s.Writer.UpdateList(id, req.Name, req.Color)
+	if err := daemon.WriteError(result, err); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, s.list(id), nil
+}
+
+// deleteList handles DELETE /lists/{id}?move_to=<list>&force=true.
+func (s *Server) deleteList(r *http.Request) (int, interface{}, error) {
+	id, err := s.findList(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := s.beforeWrite(); err != nil {
+		return 0, nil, err
+	}
+	q := r.URL.Query()
+	result, err := s.Writer.DeleteList(id, q.Get("move_to"), q.Get("force") == "true")
+	if err := daemon.WriteError(result, err); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+// findList resolves a list name, ID or ID prefix.
+func (s *Server) findList(nameOrID string) (string, error) {
+	id := s.Engine.FindList(nameOrID)
+	if id == "" {
+		return "", errorf(http.StatusNotFound, "list '%s' not found", nameOrID)
+	}
+	return id, nil
+}
+
+// list returns the list with ID id as a response body.
+func (s *Server) list(id string) *listBody {
+	body := &listBody{ReminderList: &models.ReminderList{ID: id, Name: s.Engine.Cache.Lists[id], Color: s.Engine.Cache.ListColors[id]}}
+	for _, rd := range s.Engine.Cache.Reminders {
+		if rd.ListRef != nil && *rd.ListRef == id && !rd.Completed {
+			body.Active++
+		}
+	}
+	return body
+}
+
+// getReminders handles GET /reminders. Query parameters:
+//
+//	list       list name or ID
+//	where      filter expression (see 'reminders help filters')
+//	parent     only the subtasks of this reminder
+//	completed  true to include completed reminders (implied by a where
+//	           expression that tests completed)
+func (s *Server) getReminders(r *http.Request) (int, interface{}, error) {
+	q := r.URL.Query()
+	where, err := filter.Parse(q.Get("where"))
+	if err != nil {
+		return 0, nil, errorf(http.StatusBadRequest, "%v", err)
+	}
+	listID := ""
+	if name := q.Get("list"); name != "" {
+		if listID, err = s.findList(name); err != nil {
+			return 0, nil, err
+		}
+	}
+	parentID := ""
+	if parent := q.Get("parent"); parent != "" {
+		if parentID, err = s.findReminder(parent); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	reminders := []*models.Reminder{}
+	for _, rem := range where.Select(s.Engine.GetReminders(q.Get("completed") == "true" || where.Uses("completed"))) {
+		if listID != "" && (rem.ListRef == nil || *rem.ListRef != listID) {
+			continue
+		}
+		if parentID != "" && (rem.ParentRef == nil || *rem.ParentRef != parentID) {
+			continue
+		}
+		reminders = append(reminders, rem)
+	}
+	sortReminders(reminders)
+	return http.StatusOK, reminders, nil
+}
+
+// getReminder handles GET /reminders/{id}.
+func (s *Server) getReminder(r *http.Request) (int, interface{}, error) {
+	id, err := s.findReminder(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, s.Engine.GetReminder(id), nil
+}
+
+// addRequest is the body of POST /reminders.
+type addRequest struct {
+	Title       string `json:"title"`
+	List        string `json:"list"`
+	Due         string `json:"due"`
+	Priority    string `json:"priority"`
+	Notes       string `json:"notes"`
+	Parent      string `json:"parent"`
+	Repeat      string `json:"repeat"`
+	RepeatUntil string `json:"repeat_until"`
+}
+
+// addReminder handles POST /reminders.
+func (s *Server) addReminder(r *http.Request) (int, interface{}, error) {
+	var req addRequest
+	if err := decodeBody(r, &req); err != nil {
+		return 0, nil, err
+	}
+	if strings.TrimSpace(req.Title) == "" || req.List == "" {
+		return 0, nil, errorf(http.StatusBadRequest, "title and list are required")
+	}
+	if err := checkPriority(req.Priority); err != nil {
+		return 0, nil, err
+	}
+	if err := s.beforeWrite(); err != nil {
+		return 0, nil, err
+	}
+	parent, err := s.parentRef(req.Parent)
+	if err != nil {
+		return 0, nil, err
+	}
+	result, err := s.Writer.AddReminder(req.Title, req.List, req.Due, req.Priority, req.Notes, parent, req.Repeat, req.RepeatUntil)
+	if err := daemon.WriteError(result, err); err != nil {
+		return 0, nil, err
+	}
+	id, _ := result["reminder_id"].(string)
+	return http.StatusCreated, s.Engine.GetReminder(id), nil
+}
+
+// editRequest is the body of PATCH /reminders/{id}. Omitted fields are left
+// unchanged; see writer.EditFields. List moves the reminder (with its
+// subtasks) and Completed completes or reopens it.
+type editRequest struct {
+	Title       string `json:"title"`
+	Due         string `json:"due"`
+	Notes       string `json:"notes"`
+	Priority    string `json:"priority"`
+	Repeat      string `json:"repeat"`
+	RepeatUntil string `json:"repeat_until"`
+	Parent      string `json:"parent"`
+	ClearDue    bool   `json:"clear_due"`
+	ClearNotes  bool   `json:"clear_notes"`
+	List        string `json:"list"`
+	Completed   *bool  `json:"completed"`
+}
+
+// editReminder handles PATCH /reminders/{id}. The edit, move and
+// completion are applied in that order; on failure the earlier ones stay.
+func (s *Server) editReminder(r *http.Request) (int, interface{}, error) {
+	var req editRequest
+	if err := decodeBody(r, &req); err != nil {
+		return 0, nil, err
+	}
+	fields := writer.EditFields{
+		Title:       req.Title,
+		Due:         req.Due,
+		Notes:       req.Notes,
+		Priority:    req.Priority,
+		Repeat:      req.Repeat,
+		RepeatUntil: req.RepeatUntil,
+		Parent:      req.Parent,
+		ClearDue:    req.ClearDue,
+		ClearNotes:  req.ClearNotes,
+	}
+	if fields == (writer.EditFields{}) && req.List == "" && req.Completed == nil {
+		return 0, nil, errorf(http.StatusBadRequest, "no changes given")
+	}
+	if err := checkPriority(req.Priority); err != nil {
+		return 0, nil, err
+	}
+	if err := s.beforeWrite(); err != nil {
+		return 0, nil, err
+	}
+	id, err := s.findReminder(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	if fields.Parent != "none" {
+		if fields.Parent, err = s.parentRef(fields.Parent); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if fields != (writer.EditFields{}) {
+		result, err := s.Writer.EditReminder(uuid(id), fields)
+		if err := daemon.WriteError(result, err); err != nil {
+			return 0, nil, err
+		}
+	}
+	if req.List != "" {
+		result, err := s.Writer.MoveReminder(uuid(id), req.List)
+		if err := daemon.WriteError(result, err); err != nil {
+			return 0, nil, err
+		}
+	}
+	if req.Completed != nil {
+		rem := s.Engine.GetReminder(id)
+		var result map[string]interface{}
+		switch {
+		case *req.Completed && !rem.Completed:
+			result, err = s.Writer.CompleteReminder(uuid(id))
+		case !*req.Completed && rem.Completed:
+			result, err = s.Writer.ReopenReminder(uuid(id), false)
+		}
+		if err := daemon.WriteError(result, err); err != nil {
+			return 0, nil, err
+		}
+	}
+	return http.StatusOK, s.Engine.GetReminder(id), nil
+}
+
+// deleteReminder handles DELETE /reminders/{id}.
+func (s *Server) deleteReminder(r *http.Request) (int, interface{}, error) {
+	if err := s.beforeWrite(); err != nil {
+		return 0, nil, err
+	}
+	id, err := s.findReminder(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	result, err := s.Writer.DeleteReminder(uuid(id))
+	if err := daemon.WriteError(result, err); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusNoContent, nil, nil
+}
+
+// completeReminder handles POST /reminders/{id}/complete. A repeating
+// reminder rolls forward to its next occurrence instead.
+func (s *Server) completeReminder(r *http.Request) (int, interface{}, error) {
+	if err := s.beforeWrite(); err != nil {
+		return 0, nil, err
+	}
+	id, err := s.findReminder(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	result, err := s.Writer.CompleteReminder(uuid(id))
+	if err := daemon.WriteError(result, err); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, s.Engine.GetReminder(id), nil
+}
+
+// reopenReminder handles POST /reminders/{id}/reopen; ?subtasks=true
+// reopens completed subtasks too.
+func (s *Server) reopenReminder(r *http.Request) (int, interface{}, error) {
+	if err := s.beforeWrite(); err != nil {
+		return 0, nil, err
+	}
+	id, err := s.findReminder(r.PathValue("id"))
+	if err != nil {
+		return 0, nil, err
+	}
+	result, err := s.Writer.ReopenReminder(uuid(id), r.URL.Query().Get("subtasks") == "true")
+	if err := daemon.WriteError(result, err); err != nil {
+		return 0, nil, err
+	}
+	return http.StatusOK, s.Engine.GetReminder(id), nil
+}
+
+// findReminder resolves a reminder's record name, its ID without the
+// "Reminder/" prefix, or a prefix of at least minIDPrefix characters of the
+// ID. Shorter prefixes are refused: over HTTP a typo should not pick some
+// other reminder.
+func (s *Server) findReminder(id string) (string, error) {
+	if s.Engine.Cache.Reminders[id] != nil {
+		return id, nil
+	}
+	short := id[strings.LastIndexByte(id, '/')+1:]
+	if len(short) < minIDPrefix {
+		return "", errorf(http.StatusNotFound, "reminder '%s' not found (give at least %d characters of the ID)", id, minIDPrefix)
+	}
+	fullID := s.Engine.FindReminderByID(short)
+	if fullID == "" {
+		return "", errorf(http.StatusNotFound, "reminder '%s' not found", id)
+	}
+	return fullID, nil
+}
+
+// parentRef resolves a parent given in a request (see findReminder) to the
+// ID form the writer looks up, "" for none.
+func (s *Server) parentRef(parent string) (string, error) {
+	if parent == "" {
+		return "", nil
+	}
+	fullID, err := s.findReminder(parent)
+	if err != nil {
+		return "", errorf(http.StatusUnprocessableEntity, "parent: %v", err)
+	}
+	return uuid(fullID), nil
+}
+
+// uuid returns a record name without its "Reminder/" prefix: the writer
+// looks reminders up by UUID (sync.Engine.FindReminderByID), while the
+// cache is keyed by record name.
+func uuid(recordName string) string {
+	return recordName[strings.LastIndexByte(recordName, '/')+1:]
+}
+
+// minIDPrefix is the shortest reminder ID prefix findReminder accepts.
+const minIDPrefix = 4
+
+// checkPriority validates a priority name.
+func checkPriority(p string) error {
+	if _, ok := models.PriorityMap[p]; p != "" && !ok {
+		return errorf(http.StatusBadRequest, "invalid priority %q (use: high, medium, low, none)", p)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+// Package server exposes reminders over a local HTTP/JSON API
+// ('reminders serve').
+//
+// One sync.Engine stays warm in memory for the life of the process. A
+// background loop delta-syncs it every SyncInterval, reads are answered from
+// it, and every write delta-syncs first so change tags are current. Requests
+// are served one at a time: the engine and writer are not safe for
+// concurrent use.
+//
+// The API is described by the OpenAPI document served at /openapi.json.
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"icloud-reminders/internal/daemon"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/internal/writer"
+	"icloud-reminders/pkg/models"
+)
+
+// OpenAPI is the OpenAPI 3 description of the API.
+//
+//go:embed openapi.json
+var OpenAPI []byte
+
+// Server serves the API for one engine and writer.
+type Server struct {
+	daemon.Warm
+	// Token, if set, must be sent as "Authorization: Bearer <token>" on
+	// every request except GET /openapi.json.
+	Token string
+}
+
+// New returns a server for engine and w.
+func New(engine *sync.Engine, w *writer.Writer, syncInterval time.Duration, token string) *Server {
+	return &Server{Warm: daemon.Warm{Engine: engine, Writer: w, SyncInterval: syncInterval}, Token: token}
+}
+
+// Serve syncs once, then serves the API on ln until ctx is cancelled, when
+// it shuts down gracefully.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	return s.Warm.Serve(ctx, ln, s.Handler())
+}
+
+// Handler returns the API's HTTP handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "application/json")
+		_, _ = rw.Write(OpenAPI)
+	})
+	mux.Handle("GET /health", s.api(s.health))
+	mux.Handle("POST /sync", s.api(s.syncNow))
+	mux.Handle("GET /lists", s.api(s.getLists))
+	mux.Handle("POST /lists", s.api(s.createList))
+	mux.Handle("PATCH /lists/{id}", s.api(s.updateList))
+	mux.Handle("DELETE /lists/{id}", s.api(s.deleteList))
+	mux.Handle("GET /reminders", s.api(s.getReminders))
+	mux.Handle("POST /reminders", s.api(s.addReminder))
+	mux.Handle("GET /reminders/{id}", s.api(s.getReminder))
+	mux.Handle("PATCH /reminders/{id}", s.api(s.editReminder))
+	mux.Handle("DELETE /reminders/{id}", s.api(s.deleteReminder))
+	mux.Handle("POST /reminders/{id}/complete", s.api(s.completeReminder))
+	mux.Handle("POST /reminders/{id}/reopen", s.api(s.reopenReminder))
+	return daemon.LogRequests(s.authorize(mux))
+}
+
+// apiFunc handles an API request. It returns the HTTP status and the value
+// to send as JSON (nil for no body), or an error.
+type apiFunc func(r *http.Request) (int, interface{}, error)
+
+// apiError is an error with an HTTP status.
+type apiError struct {
+	status int
+	msg    string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+func errorf(status int, format string, args ...interface{}) error {
+	return &apiError{status: status, msg: fmt.Sprintf(format, args...)}
+}
+
+// api adapts fn to an http.Handler. Requests are served one at a time.
+// Errors are sent as {"error": "..."}: an apiError with its status, a
+// writer result's error (daemon.ResultError) as 422, others as 500.
+func (s *Server) api(fn apiFunc) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		s.Lock()
+		status, body, err := fn(r)
+		s.Unlock()
+		if err != nil {
+			status = http.StatusInternalServerError
+			var ae *apiError
+			var re *daemon.ResultError
+			if errors.As(err, &ae) {
+				status = ae.status
+			} else if errors.As(err, &re) {
+				status = http.StatusUnprocessableEntity
+			}
+			body = map[string]string{"error": err.Error()}
+		}
+		if body == nil {
+			rw.WriteHeader(status)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(status)
+		enc := json.NewEncoder(rw)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(body)
+	})
+}
+
+// authorize checks the bearer token, if one is configured.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	if s.Token == "" {
+		return next
+	}
+	want := []byte("Bearer " + s.Token)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/openapi.json" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) == 1 {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		rw.Header().Set("WWW-Authenticate", `Bearer realm="reminders"`)
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusUnauthorized)
+		_, _ = rw.Write([]byte(`{"error": "missing or invalid bearer token"}` + "\n"))
+	})
+}
+
+// decodeBody reads a JSON request body into v, rejecting unknown fields.
+func decodeBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// beforeWrite delta-syncs so the writer sees current change tags.
+func (s *Server) beforeWrite() error {
+	if err := s.Sync(false); err != nil {
+		return errorf(http.StatusBadGateway, "sync: %v", err)
+	}
+	return nil
+}
+
+// health reports the state of the cache.
+func (s *Server) health(r *http.Request) (int, interface{}, error) {
+	lastSync, syncErr := s.LastSync()
+	body := map[string]interface{}{
+		"ok":        syncErr == nil,
+		"reminders": len(s.Engine.Cache.Reminders),
+		"lists":     len(s.Engine.Cache.Lists),
+	}
+	if !lastSync.IsZero() {
+		body["last_sync"] = lastSync.UTC().Format(time.RFC3339)
+	}
+	if syncErr != nil {
+		body["sync_error"] = syncErr.Error()
+	}
+	return http.StatusOK, body, nil
+}
+
+// syncNow syncs on request; ?full=true forces a full resync.
+func (s *Server) syncNow(r *http.Request) (int, interface{}, error) {
+	if err := s.Sync(r.URL.Query().Get("full") == "true"); err != nil {
+		return 0, nil, errorf(http.StatusBadGateway, "sync: %v", err)
+	}
+	return s.health(r)
+}
+
+// sortReminders orders reminders by list, then title.
+func sortReminders(reminders []*models.Reminder) {
+	sort.SliceStable(reminders, func(i, j int) bool {
+		a, b := reminders[i], reminders[j]
+		if a.ListName != b.ListName {
+			return a.ListName < b.ListName
+		}
+		return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+	})
+}
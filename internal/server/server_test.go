@@ -0,0 +1,251 @@
+package server_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/cloudkit"
+	"icloud-reminders/internal/cloudkit/cktest"
+	"icloud-reminders/internal/server"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/internal/writer"
+	"icloud-reminders/pkg/models"
+)
+
+// newAPI serves the REST API for an engine that has synced srv. The cache
+// file goes to a temporary directory.
+func newAPI(t *testing.T, srv *cktest.Server, token string) *httptest.Server {
+	t.Helper()
+	cache.CacheFile = filepath.Join(t.TempDir(), "ck_cache.json")
+	ck, err := cloudkit.NewFromSession(srv.Session())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &sync.Engine{CK: ck, Cache: cache.NewCache()}
+	s := server.New(e, writer.New(ck, e), 0, token)
+	if err := s.Sync(false); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	api := httptest.NewServer(s.Handler())
+	t.Cleanup(api.Close)
+	return api
+}
+
+// do sends a request with an optional JSON body and decodes the response
+// into out (if not nil). It returns the status.
+func do(t *testing.T, api *httptest.Server, method, path, body string, out interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, api.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+func shortID(recordName string) string {
+	return strings.TrimPrefix(recordName, "Reminder/")
+}
+
+func serverTitle(srv *cktest.Server, recordName string) string {
+	doc, _ := srv.Fields(recordName)["TitleDocument"].(map[string]interface{})["value"].(string)
+	return utils.ExtractTitle(doc)
+}
+
+func completed(srv *cktest.Server, recordName string) bool {
+	f, _ := srv.Fields(recordName)["Completed"].(map[string]interface{})
+	return fmt.Sprint(f["value"]) == "1"
+}
+
+func TestGetReminders(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	work := srv.AddList("Work")
+	report := srv.AddReminder("Write report", work)
+	srv.AddReminder("Buy milk", srv.AddList("Groceries"))
+	api := newAPI(t, srv, "")
+
+	var all []*models.Reminder
+	if status := do(t, api, "GET", "/reminders", "", &all); status != http.StatusOK {
+		t.Fatalf("GET /reminders = %d", status)
+	}
+	if len(all) != 2 || all[0].Title != "Buy milk" || all[1].Title != "Write report" {
+		t.Errorf("GET /reminders = %v, want Buy milk, Write report", all)
+	}
+
+	var inWork []*models.Reminder
+	do(t, api, "GET", "/reminders?list=Work", "", &inWork)
+	if len(inWork) != 1 || inWork[0].ID != report {
+		t.Errorf("GET /reminders?list=Work = %v, want only %s", inWork, report)
+	}
+
+	// A reminder is found by its (escaped) record name, UUID or UUID prefix.
+	for _, id := range []string{url.PathEscape(report), shortID(report), shortID(report)[:8]} {
+		var rem models.Reminder
+		if status := do(t, api, "GET", "/reminders/"+id, "", &rem); status != http.StatusOK || rem.ID != report {
+			t.Errorf("GET /reminders/%s = %d %s, want 200 %s", id, status, rem.ID, report)
+		}
+	}
+
+	var body map[string]string
+	if status := do(t, api, "GET", "/reminders/ffffffff", "", &body); status != http.StatusNotFound {
+		t.Errorf("GET of an unknown reminder = %d %v, want 404", status, body)
+	}
+	if status := do(t, api, "GET", "/reminders/"+shortID(report)[:2], "", nil); status != http.StatusNotFound {
+		t.Errorf("GET with a 2-character prefix = %d, want 404", status)
+	}
+}
+
+func TestPostReminder(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	srv.AddList("Work")
+	api := newAPI(t, srv, "")
+
+	var rem models.Reminder
+	status := do(t, api, "POST", "/reminders", `{"title": "Call Bob", "list": "Work", "due": "2026-10-20", "priority": "high"}`, &rem)
+	if status != http.StatusCreated {
+		t.Fatalf("POST /reminders = %d", status)
+	}
+	if rem.Title != "Call Bob" || rem.ListName != "Work" || rem.Priority != 1 || rem.Due == nil || *rem.Due != "2026-10-20" {
+		t.Errorf("created %+v", rem)
+	}
+	if got := srv.RecordNames("Reminder"); len(got) != 1 || serverTitle(srv, got[0]) != "Call Bob" {
+		t.Errorf("server reminders = %v", got)
+	}
+
+	if status := do(t, api, "POST", "/reminders", `{"title": "No list"}`, nil); status != http.StatusBadRequest {
+		t.Errorf("POST without a list = %d, want 400", status)
+	}
+	var body map[string]string
+	if status := do(t, api, "POST", "/reminders", `{"title": "x", "list": "Nope"}`, &body); status != http.StatusUnprocessableEntity {
+		t.Errorf("POST to an unknown list = %d %v, want 422", status, body)
+	}
+}
+
+func TestPatchReminder(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	id := srv.AddReminder("Old title", srv.AddList("Work"))
+	home := srv.AddList("Home")
+	api := newAPI(t, srv, "")
+
+	var rem models.Reminder
+	status := do(t, api, "PATCH", "/reminders/"+shortID(id), `{"title": "New title", "notes": "by Friday", "list": "Home", "completed": true}`, &rem)
+	if status != http.StatusOK {
+		t.Fatalf("PATCH = %d %+v", status, rem)
+	}
+	if rem.Title != "New title" || rem.Notes == nil || *rem.Notes != "by Friday" || rem.ListName != "Home" || !rem.Completed {
+		t.Errorf("PATCH returned %+v", rem)
+	}
+	if got := serverTitle(srv, id); got != "New title" {
+		t.Errorf("server title = %q", got)
+	}
+	if ref, _ := srv.Fields(id)["List"].(map[string]interface{})["value"].(map[string]interface{}); ref["recordName"] != home {
+		t.Errorf("server list = %v, want %s", ref["recordName"], home)
+	}
+	if !completed(srv, id) {
+		t.Error("not completed on the server")
+	}
+
+	// The full record name works too.
+	if status := do(t, api, "PATCH", "/reminders/"+url.PathEscape(id), `{"completed": false}`, &rem); status != http.StatusOK || rem.Completed {
+		t.Errorf("PATCH completed=false = %d, completed %v", status, rem.Completed)
+	}
+	if status := do(t, api, "PATCH", "/reminders/"+shortID(id), `{}`, nil); status != http.StatusBadRequest {
+		t.Errorf("empty PATCH = %d, want 400", status)
+	}
+}
+
+func TestDeleteReminder(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	id := srv.AddReminder("Gone", srv.AddList("Work"))
+	api := newAPI(t, srv, "")
+
+	if status := do(t, api, "DELETE", "/reminders/"+shortID(id), "", nil); status != http.StatusNoContent {
+		t.Fatalf("DELETE = %d", status)
+	}
+	if srv.Fields(id) != nil {
+		t.Error("reminder still on the server")
+	}
+	if status := do(t, api, "GET", "/reminders/"+shortID(id), "", nil); status != http.StatusNotFound {
+		t.Errorf("GET after DELETE = %d, want 404", status)
+	}
+}
+
+func TestCompleteAndReopen(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	id := srv.AddReminder("Milk", srv.AddList("Groceries"))
+	api := newAPI(t, srv, "")
+
+	var rem models.Reminder
+	if status := do(t, api, "POST", "/reminders/"+shortID(id)+"/complete", "", &rem); status != http.StatusOK || !rem.Completed {
+		t.Fatalf("complete = %d, completed %v", status, rem.Completed)
+	}
+	if !completed(srv, id) {
+		t.Error("not completed on the server")
+	}
+
+	rem = models.Reminder{}
+	if status := do(t, api, "POST", "/reminders/"+shortID(id)+"/reopen", "", &rem); status != http.StatusOK || rem.Completed {
+		t.Fatalf("reopen = %d, completed %v", status, rem.Completed)
+	}
+	if completed(srv, id) {
+		t.Error("still completed on the server")
+	}
+}
+
+func TestWriteToDeletedReminder(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	id := srv.AddReminder("Milk", srv.AddList("Groceries"))
+	api := newAPI(t, srv, "")
+	srv.Remove(id)
+
+	// The write syncs first, so the reminder is gone rather than stale.
+	if status := do(t, api, "POST", "/reminders/"+shortID(id)+"/complete", "", nil); status != http.StatusNotFound {
+		t.Errorf("complete of a deleted reminder = %d, want 404", status)
+	}
+}
+
+func TestToken(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	api := newAPI(t, srv, "s3cret")
+
+	if status := do(t, api, "GET", "/lists", "", nil); status != http.StatusUnauthorized {
+		t.Errorf("GET /lists without a token = %d, want 401", status)
+	}
+	req, _ := http.NewRequest("GET", api.URL+"/lists", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /lists with the token = %d, want 200", resp.StatusCode)
+	}
+	if status := do(t, api, "GET", "/openapi.json", "", nil); status != http.StatusOK {
+		t.Errorf("GET /openapi.json without a token = %d, want 200", status)
+	}
+}
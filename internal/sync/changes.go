@@ -0,0 +1,160 @@
+package sync
+
+import (
+	"time"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// ChangeType says what happened to a reminder or list.
+type ChangeType string
+
+// Change types. A reminder that was completed is reported as
+// ChangeCompleted even if other fields changed too; reopening it is an
+// update.
+const (
+	ChangeCreated     ChangeType = "created"
+	ChangeUpdated     ChangeType = "updated"
+	ChangeCompleted   ChangeType = "completed"
+	ChangeDeleted     ChangeType = "deleted"
+	ChangeListRenamed ChangeType = "list_renamed"
+)
+
+// Change is a change to a reminder or list found by a delta sync.
+type Change struct {
+	Type ChangeType `json:"type"`
+	// Time is when the sync found the change (RFC 3339, UTC).
+	Time string `json:"time"`
+	// ID is the record name of the reminder or list.
+	ID string `json:"id"`
+	// Before and After are the record as it was and as it is now: a
+	// *models.Reminder, or a *models.ReminderList for list changes. Before
+	// is nil for created reminders and After for deleted ones.
+	Before interface{} `json:"before,omitempty"`
+	After  interface{} `json:"after,omitempty"`
+}
+
+// Reminder returns the reminder a change is about, as it is now (as it
+// was, if it was deleted), or nil for list changes.
+func (c *Change) Reminder() *models.Reminder {
+	r, _ := c.After.(*models.Reminder)
+	if r == nil {
+		r, _ = c.Before.(*models.Reminder)
+	}
+	return r
+}
+
+// Changes returns the changes the last Sync fetched, in the order CloudKit
+// sent them. A full sync has no changes: there is nothing to compare with.
+func (e *Engine) Changes() []*Change {
+	return e.changes
+}
+
+// touch remembers how a reminder was cached before the running delta sync
+// first changed it (nil if it was not cached), unless that is known.
+func (e *Engine) touch(rid string) {
+	if e.before == nil {
+		return
+	}
+	if _, ok := e.before[rid]; ok {
+		return
+	}
+	var snapshot *cache.ReminderData
+	if rd := e.Cache.Reminders[rid]; rd != nil {
+		cp := *rd
+		snapshot = &cp
+	}
+	e.before[rid] = snapshot
+	e.touched = append(e.touched, rid)
+}
+
+// touchList is touch for a list's name.
+func (e *Engine) touchList(listID string) {
+	if e.listsBefore == nil {
+		return
+	}
+	if _, ok := e.listsBefore[listID]; ok {
+		return
+	}
+	e.listsBefore[listID] = e.Cache.Lists[listID]
+	e.touched = append(e.touched, listID)
+}
+
+// diff compares what the running sync touched with the cache as it is now.
+// Records that were fetched but did not change in a way the user can see
+// (e.g. only their change tag) are left out.
+func (e *Engine) diff() []*Change {
+	now := utils.Now().UTC().Format(time.RFC3339)
+	var changes []*Change
+	for _, id := range e.touched {
+		if oldName, ok := e.listsBefore[id]; ok {
+			newName := e.Cache.Lists[id]
+			if oldName != "" && newName != "" && oldName != newName {
+				changes = append(changes, &Change{
+					Type:   ChangeListRenamed,
+					Time:   now,
+					ID:     id,
+					Before: &models.ReminderList{ID: id, Name: oldName, Color: e.Cache.ListColors[id]},
+					After:  &models.ReminderList{ID: id, Name: newName, Color: e.Cache.ListColors[id]},
+				})
+			}
+			continue
+		}
+
+		old, cur := e.before[id], e.Cache.Reminders[id]
+		c := &Change{Time: now, ID: id}
+		switch {
+		case old == nil && cur == nil:
+			continue
+		case old == nil:
+			c.Type = ChangeCreated
+		case cur == nil:
+			c.Type = ChangeDeleted
+		case sameReminder(old, cur):
+			continue
+		case cur.Completed && !old.Completed:
+			c.Type = ChangeCompleted
+		default:
+			c.Type = ChangeUpdated
+		}
+		if old != nil {
+			c.Before = e.reminder(id, old)
+		}
+		if cur != nil {
+			c.After = e.reminder(id, cur)
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
+
+// sameReminder reports whether two cached reminders look the same to the
+// user: change tags and modification times are ignored.
+func sameReminder(a, b *cache.ReminderData) bool {
+	return a.Title == b.Title &&
+		a.Completed == b.Completed &&
+		a.Priority == b.Priority &&
+		sameString(a.CompletionDate, b.CompletionDate) &&
+		sameString(a.Due, b.Due) &&
+		sameString(a.Notes, b.Notes) &&
+		sameString(a.ListRef, b.ListRef) &&
+		sameString(a.ParentRef, b.ParentRef) &&
+		sameString(a.TimeZone, b.TimeZone) &&
+		sameRule(a.Recurrence, b.Recurrence)
+}
+
+func sameRule(a, b *cache.RecurrenceData) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Frequency == b.Frequency && a.Interval == b.Interval && sameString(a.Until, b.Until)
+}
+
+func sameString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
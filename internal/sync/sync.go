@@ -2,55 +2,99 @@
 package sync
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"icloud-reminders/internal/auth"
 	"icloud-reminders/internal/cache"
 	"icloud-reminders/internal/cloudkit"
 	"icloud-reminders/internal/logger"
-	"icloud-reminders/pkg/models"
 	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
 )
 
+// ErrInvalidSyncToken is returned by ChangedSince when CloudKit rejects the
+// sync token, e.g. because it has expired.
+var ErrInvalidSyncToken = errors.New("invalid or expired sync token")
+
+// ReauthFunc builds a fresh CloudKit client after the current session has
+// been rejected (e.g. with a 503).
+type ReauthFunc func() (cloudkit.API, error)
+
 // Engine handles syncing reminders with CloudKit.
 type Engine struct {
-	CK          *cloudkit.Client
-	Cache       *cache.Cache
-	sessionFile string // used for 503 re-auth
+	CK    cloudkit.API
+	Cache *cache.Cache
+	// Reauth is called once when a sync fails with a 503; nil disables retry.
+	Reauth ReauthFunc
+
+	// pendingRules holds RecurrenceRule records whose reminder has not been
+	// seen yet during this sync, keyed by reminder record name.
+	pendingRules map[string]*cache.RecurrenceData
+
+	// During a delta sync, before and listsBefore hold the reminders and
+	// list names as they were before the sync first touched them, in the
+	// order of touched; changes are what the last sync changed.
+	before      map[string]*cache.ReminderData
+	listsBefore map[string]string
+	touched     []string
+	changes     []*Change
 }
 
-// New creates a new sync engine.
-func New(ck *cloudkit.Client, sessionFile string) *Engine {
+// New creates a new sync engine that re-authenticates via sessionFile.
+func New(ck cloudkit.API, sessionFile string) *Engine {
 	return &Engine{
-		CK:          ck,
-		Cache:       cache.Load(),
-		sessionFile: sessionFile,
+		CK:     ck,
+		Cache:  cache.Load(),
+		Reauth: SessionReauth(sessionFile),
+	}
+}
+
+// SessionReauth returns a ReauthFunc that forces a full iCloud sign-in,
+// saves the new session to sessionFile and connects a new Client.
+func SessionReauth(sessionFile string) ReauthFunc {
+	return func() (cloudkit.API, error) {
+		sess, err := auth.New().EnsureSession(sessionFile, true)
+		if err != nil {
+			return nil, fmt.Errorf("re-auth failed after 503: %w", err)
+		}
+		ck, err := cloudkit.NewFromSession(sess)
+		if err != nil {
+			return nil, fmt.Errorf("cloudkit reinit after re-auth: %w", err)
+		}
+		return ck, nil
 	}
 }
 
 // Sync performs a delta or full sync from CloudKit.
-// On a 503 response it attempts a forced full re-auth once and retries.
+// On a 503 response it calls Reauth once (if set) and retries with the new client.
 // If the 503 persists after re-auth, the call aborts — this indicates an
 // implementation bug rather than a transient server error.
 func (e *Engine) Sync(force bool) error {
+	e.changes, e.before, e.listsBefore, e.touched = nil, nil, nil, nil
+	if !force && e.Cache.SyncToken != nil && *e.Cache.SyncToken != "" {
+		e.before = make(map[string]*cache.ReminderData)
+		e.listsBefore = make(map[string]string)
+	}
+	defer func() { e.before, e.listsBefore, e.touched = nil, nil, nil }()
+
 	err := e.doSync(force)
 	if err == nil {
+		e.changes = e.diff()
 		return nil
 	}
-	if cloudkit.Is503(err) {
+	if cloudkit.Is503(err) && e.Reauth != nil {
 		logger.Warn("Got 503 from iCloud — attempting forced re-auth...")
-		sess, reAuthErr := auth.New().EnsureSession(e.sessionFile, true)
+		newCK, reAuthErr := e.Reauth()
 		if reAuthErr != nil {
-			return fmt.Errorf("re-auth failed after 503: %w", reAuthErr)
-		}
-		newCK, ckErr := cloudkit.NewFromSession(sess)
-		if ckErr != nil {
-			return fmt.Errorf("cloudkit reinit after re-auth: %w", ckErr)
+			return reAuthErr
 		}
 		e.CK = newCK
 		if retryErr := e.doSync(force); retryErr != nil {
 			return fmt.Errorf("503 persists after re-auth (implementation bug): %w", retryErr)
 		}
+		e.changes = e.diff()
 		return nil
 	}
 	return err
@@ -59,8 +103,12 @@ func (e *Engine) Sync(force bool) error {
 // doSync is the inner sync implementation used by Sync.
 func (e *Engine) doSync(force bool) error {
 	defer logger.Timer("sync")()
+	e.pendingRules = nil
 	if force {
+		// Import history is local knowledge that a resync cannot restore.
+		imported := e.Cache.ImportedUIDs
 		e.Cache = cache.NewCache()
+		e.Cache.ImportedUIDs = imported
 		logger.Info("Full sync (forced)...")
 	} else if e.Cache.SyncToken != nil && *e.Cache.SyncToken != "" {
 		logger.Info("Delta sync...")
@@ -131,7 +179,79 @@ func (e *Engine) doSync(force bool) error {
 	return nil
 }
 
-// processRecords processes CloudKit records into the local cache.
+// ChangedSince returns the record names of the reminders created, changed
+// or deleted in CloudKit since syncToken, and the token to pass next time.
+// A changed recurrence rule counts as a change to its reminder. The cache is
+// not touched; run Sync to fetch the changes.
+func (e *Engine) ChangedSince(syncToken string) ([]string, string, error) {
+	if e.Cache.OwnerID == nil || *e.Cache.OwnerID == "" {
+		return nil, "", fmt.Errorf("no owner ID — sync first")
+	}
+	ruleOwner := make(map[string]string)
+	for rid, rd := range e.Cache.Reminders {
+		if rd.Recurrence != nil {
+			ruleOwner[rd.Recurrence.RuleID] = rid
+		}
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	token := syncToken
+	for {
+		data, err := e.CK.ChangesZone(*e.Cache.OwnerID, token)
+		var apiErr *cloudkit.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == 400 {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidSyncToken, err)
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("changes/zone: %w", err)
+		}
+		zones, _ := data["zones"].([]interface{})
+		if len(zones) == 0 {
+			break
+		}
+		zoneResp, _ := zones[0].(map[string]interface{})
+		if code, _ := zoneResp["serverErrorCode"].(string); code != "" {
+			return nil, "", fmt.Errorf("%w: %s", ErrInvalidSyncToken, code)
+		}
+		records, _ := zoneResp["records"].([]interface{})
+		for _, rec := range records {
+			r, _ := rec.(map[string]interface{})
+			rname, _ := r["recordName"].(string)
+			fields, _ := r["fields"].(map[string]interface{})
+			switch rtype, _ := r["recordType"].(string); rtype {
+			case "Reminder":
+				add(rname)
+			case "RecurrenceRule":
+				add(getFieldRefName(fields, "Reminder"))
+			case "":
+				// Tombstone: a reminder, or a rule we know the owner of.
+				if owner := ruleOwner[rname]; owner != "" {
+					add(owner)
+				} else if deleted, _ := r["deleted"].(bool); deleted {
+					add(rname)
+				}
+			}
+		}
+		if newToken, _ := zoneResp["syncToken"].(string); newToken != "" {
+			token = newToken
+		}
+		if more, _ := zoneResp["moreComing"].(bool); !more {
+			break
+		}
+	}
+	return names, token, nil
+}
+
+// processRecords processes CloudKit records into the local cache. During a
+// delta sync it first remembers the cached state of each reminder and list
+// a record changes, for diff.
 func (e *Engine) processRecords(records []interface{}) {
 	for _, rec := range records {
 		r, ok := rec.(map[string]interface{})
@@ -151,10 +271,25 @@ func (e *Engine) processRecords(records []interface{}) {
 			deleted = true
 		}
 
+		// Tombstones from changes/zone carry no recordType.
+		if rtype == "" && deleted {
+			if _, ok := e.Cache.Lists[rname]; ok {
+				e.touchList(rname)
+			}
+			if e.Cache.Reminders[rname] != nil {
+				e.touch(rname)
+			}
+			e.forgetList(rname)
+			delete(e.Cache.Reminders, rname)
+			e.removeRule(rname)
+			continue
+		}
+
 		switch rtype {
 		case "ReminderList", "List":
+			e.touchList(rname)
 			if deleted {
-				delete(e.Cache.Lists, rname)
+				e.forgetList(rname)
 			} else {
 				title := getFieldString(fields, "Name")
 				if title == "" {
@@ -163,9 +298,18 @@ func (e *Engine) processRecords(records []interface{}) {
 				if title != "" {
 					e.Cache.Lists[rname] = title
 				}
+				if changeTag, _ := r["recordChangeTag"].(string); changeTag != "" {
+					e.Cache.ListChangeTags[rname] = changeTag
+				}
+				if color := decodeListColor(getFieldString(fields, "Color")); color != "" {
+					e.Cache.ListColors[rname] = color
+				} else {
+					delete(e.Cache.ListColors, rname)
+				}
 			}
 
 		case "Reminder":
+			e.touch(rname)
 			if deleted {
 				delete(e.Cache.Reminders, rname)
 			} else {
@@ -174,10 +318,14 @@ func (e *Engine) processRecords(records []interface{}) {
 					title = "(untitled)"
 				}
 
-				var dueStr, completionStr *string
+				var dueStr, completionStr, tzStr *string
 				if due := getFieldInt64(fields, "DueDate"); due != 0 {
-					s := utils.TsToStr(due)
+					tz := getFieldString(fields, "TimeZone")
+					s := utils.FormatDue(due, isAllDay(fields, due, tz), tz)
 					dueStr = &s
+					if tz != "" {
+						tzStr = &tz
+					}
 				}
 				if cd := getFieldInt64(fields, "CompletionDate"); cd != 0 {
 					s := utils.TsToStr(cd)
@@ -204,6 +352,7 @@ func (e *Engine) processRecords(records []interface{}) {
 					Due:            dueStr,
 					Priority:       priority,
 					ModifiedTS:     modTS,
+					TimeZone:       tzStr,
 				}
 				if notes != "" {
 					rd.Notes = &notes
@@ -217,8 +366,73 @@ func (e *Engine) processRecords(records []interface{}) {
 				if changeTag != "" {
 					rd.ChangeTag = &changeTag
 				}
+				// Recurrence rules are separate records; keep the one we
+				// already know about unless a newer one arrived first.
+				if old := e.Cache.Reminders[rname]; old != nil {
+					rd.Recurrence = old.Recurrence
+				}
+				if rule, ok := e.pendingRules[rname]; ok {
+					rd.Recurrence = rule
+					delete(e.pendingRules, rname)
+				}
 				e.Cache.Reminders[rname] = rd
 			}
+
+		case "RecurrenceRule":
+			if deleted {
+				e.removeRule(rname)
+				continue
+			}
+			reminderRef := getFieldRefName(fields, "Reminder")
+			if reminderRef == "" {
+				continue
+			}
+			rule := &cache.RecurrenceData{
+				RuleID:    rname,
+				Frequency: models.FrequencyName(getFieldInt(fields, "Frequency")),
+				Interval:  getFieldInt(fields, "Interval"),
+			}
+			if rule.Interval < 1 {
+				rule.Interval = 1
+			}
+			if end := getFieldInt64(fields, "EndDate"); end != 0 {
+				s := utils.TsToStr(end)
+				rule.Until = &s
+			}
+			if changeTag, _ := r["recordChangeTag"].(string); changeTag != "" {
+				rule.ChangeTag = &changeTag
+			}
+			if rd := e.Cache.Reminders[reminderRef]; rd != nil {
+				e.touch(reminderRef)
+				rd.Recurrence = rule
+			} else {
+				if e.pendingRules == nil {
+					e.pendingRules = make(map[string]*cache.RecurrenceData)
+				}
+				e.pendingRules[reminderRef] = rule
+			}
+		}
+	}
+}
+
+// forgetList drops a list and its metadata from the cache.
+func (e *Engine) forgetList(listID string) {
+	delete(e.Cache.Lists, listID)
+	delete(e.Cache.ListChangeTags, listID)
+	delete(e.Cache.ListColors, listID)
+}
+
+// removeRule detaches the recurrence rule with the given record name.
+func (e *Engine) removeRule(ruleID string) {
+	for rid, rd := range e.Cache.Reminders {
+		if rd.Recurrence != nil && rd.Recurrence.RuleID == ruleID {
+			e.touch(rid)
+			rd.Recurrence = nil
+		}
+	}
+	for ref, rule := range e.pendingRules {
+		if rule.RuleID == ruleID {
+			delete(e.pendingRules, ref)
 		}
 	}
 }
@@ -230,37 +444,65 @@ func (e *Engine) GetReminders(includeCompleted bool) []*models.Reminder {
 		if !includeCompleted && data.Completed {
 			continue
 		}
-		r := &models.Reminder{
-			ID:             rid,
-			Title:          data.Title,
-			Completed:      data.Completed,
-			CompletionDate: data.CompletionDate,
-			Due:            data.Due,
-			Priority:       data.Priority,
-			Notes:          data.Notes,
-			ListRef:        data.ListRef,
-			ParentRef:      data.ParentRef,
-			ModifiedTS:     data.ModifiedTS,
-		}
-		if data.ListRef != nil {
-			if name, ok := e.Cache.Lists[*data.ListRef]; ok {
-				r.ListName = name
-			} else {
-				r.ListName = "?"
-			}
+		result = append(result, e.reminder(rid, data))
+	}
+	return result
+}
+
+// GetReminder returns the reminder with the full record name reminderID,
+// or nil if it is not in the cache.
+func (e *Engine) GetReminder(reminderID string) *models.Reminder {
+	data := e.Cache.Reminders[reminderID]
+	if data == nil {
+		return nil
+	}
+	return e.reminder(reminderID, data)
+}
+
+// reminder converts a cached reminder into its typed form.
+func (e *Engine) reminder(rid string, data *cache.ReminderData) *models.Reminder {
+	r := &models.Reminder{
+		ID:             rid,
+		Title:          data.Title,
+		Completed:      data.Completed,
+		CompletionDate: data.CompletionDate,
+		Due:            data.Due,
+		Priority:       data.Priority,
+		Notes:          data.Notes,
+		ListRef:        data.ListRef,
+		ParentRef:      data.ParentRef,
+		ModifiedTS:     data.ModifiedTS,
+		TimeZone:       data.TimeZone,
+		ChangeTag:      data.ChangeTag,
+	}
+	if data.Due != nil {
+		local := utils.LocalDue(*data.Due)
+		r.Due = &local
+	}
+	if rule := data.Recurrence; rule != nil {
+		r.Recurrence = &models.Recurrence{
+			Frequency: rule.Frequency,
+			Interval:  rule.Interval,
+			Until:     rule.Until,
+		}
+	}
+	if data.ListRef != nil {
+		if name, ok := e.Cache.Lists[*data.ListRef]; ok {
+			r.ListName = name
 		} else {
 			r.ListName = "?"
 		}
-		result = append(result, r)
+	} else {
+		r.ListName = "?"
 	}
-	return result
+	return r
 }
 
 // GetLists returns all reminder lists.
 func (e *Engine) GetLists() []*models.ReminderList {
 	var result []*models.ReminderList
 	for id, name := range e.Cache.Lists {
-		result = append(result, &models.ReminderList{ID: id, Name: name})
+		result = append(result, &models.ReminderList{ID: id, Name: name, Color: e.Cache.ListColors[id]})
 	}
 	return result
 }
@@ -276,6 +518,24 @@ func (e *Engine) FindListByName(name string) string {
 	return ""
 }
 
+// FindList finds a list ID by full ID, ID prefix or name (case-insensitive).
+func (e *Engine) FindList(nameOrID string) string {
+	if _, ok := e.Cache.Lists[nameOrID]; ok {
+		return nameOrID
+	}
+	if id := e.FindListByName(nameOrID); id != "" {
+		return id
+	}
+	partial := toLower(nameOrID)
+	for id := range e.Cache.Lists {
+		uuidPart := toLower(shortID(id))
+		if len(partial) >= 4 && len(uuidPart) >= len(partial) && uuidPart[:len(partial)] == partial {
+			return id
+		}
+	}
+	return ""
+}
+
 // FindReminderByID finds a full reminder ID by partial prefix match.
 func (e *Engine) FindReminderByID(partialID string) string {
 	partial := toLower(partialID)
@@ -294,6 +554,32 @@ func (e *Engine) FindReminderByID(partialID string) string {
 	return ""
 }
 
+// Descendants returns the IDs of all subtasks below a reminder, at any depth.
+func (e *Engine) Descendants(reminderID string) []string {
+	children := make(map[string][]string)
+	for rid, rd := range e.Cache.Reminders {
+		if rd.ParentRef != nil && *rd.ParentRef != "" {
+			children[*rd.ParentRef] = append(children[*rd.ParentRef], rid)
+		}
+	}
+	var result []string
+	seen := map[string]bool{reminderID: true}
+	queue := []string{reminderID}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, child := range children[id] {
+			if seen[child] {
+				continue
+			}
+			seen[child] = true
+			result = append(result, child)
+			queue = append(queue, child)
+		}
+	}
+	return result
+}
+
 // --- field extraction helpers ---
 
 func getFieldString(fields map[string]interface{}, key string) string {
@@ -309,6 +595,10 @@ func getFieldInt(fields map[string]interface{}, key string) int {
 		return int(v)
 	case int:
 		return v
+	case bool:
+		if v {
+			return 1
+		}
 	}
 	return 0
 }
@@ -324,6 +614,45 @@ func getFieldInt64(fields map[string]interface{}, key string) int64 {
 	return 0
 }
 
+// decodeListColor extracts the symbolic color name (or hex string) from a
+// List record's Color field, which holds a small JSON document.
+func decodeListColor(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	var c struct {
+		Symbolic string `json:"ckSymbolicColorName"`
+		Hex      string `json:"daHexString"`
+	}
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		return raw
+	}
+	if c.Symbolic != "" && c.Symbolic != "custom" {
+		return c.Symbolic
+	}
+	return c.Hex
+}
+
+// shortID strips a "Reminder/" or "List/" prefix from a record name.
+func shortID(id string) string {
+	for i := len(id) - 1; i >= 0; i-- {
+		if id[i] == '/' {
+			return id[i+1:]
+		}
+	}
+	return id
+}
+
+// isAllDay reports whether a reminder's due date is all-day. Records without
+// an AllDay field are all-day unless they carry a time zone or a due time
+// other than UTC midnight.
+func isAllDay(fields map[string]interface{}, dueMs int64, tz string) bool {
+	if f, ok := fields["AllDay"].(map[string]interface{}); ok && f["value"] != nil {
+		return getFieldInt(fields, "AllDay") != 0
+	}
+	return tz == "" && dueMs%(24*60*60*1000) == 0
+}
+
 func getFieldRefName(fields map[string]interface{}, key string) string {
 	f, _ := fields[key].(map[string]interface{})
 	v, _ := f["value"].(map[string]interface{})
@@ -0,0 +1,333 @@
+package sync_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/cloudkit"
+	"icloud-reminders/internal/cloudkit/cktest"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// newEngine returns an engine with an empty cache talking to srv. The cache
+// file goes to a temporary directory.
+func newEngine(t *testing.T, srv *cktest.Server) *sync.Engine {
+	t.Helper()
+	cache.CacheFile = filepath.Join(t.TempDir(), "ck_cache.json")
+	ck, err := cloudkit.NewFromSession(srv.Session())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sync.Engine{CK: ck, Cache: cache.NewCache()}
+}
+
+func title(s string) map[string]interface{} {
+	encoded, _ := utils.EncodeTitle(s)
+	return map[string]interface{}{"TitleDocument": map[string]interface{}{"value": encoded}}
+}
+
+func TestFullSyncFollowsMoreComing(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	srv.PageSize = 2
+	listID := srv.AddList("Groceries")
+	for i := 0; i < 5; i++ {
+		srv.AddReminder(fmt.Sprintf("item %d", i), listID)
+	}
+
+	e := newEngine(t, srv)
+	if err := e.Sync(false); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if got := srv.Requests("changes/zone"); got != 3 {
+		t.Errorf("changes/zone requests = %d, want 3 (6 records, 2 per page)", got)
+	}
+	if got := len(e.Cache.Reminders); got != 5 {
+		t.Errorf("cached reminders = %d, want 5", got)
+	}
+	if got := e.Cache.Lists[listID]; got != "Groceries" {
+		t.Errorf("list name = %q, want Groceries", got)
+	}
+	if e.Cache.SyncToken == nil || *e.Cache.SyncToken == "" {
+		t.Error("no sync token saved")
+	}
+	if got := e.Changes(); len(got) != 0 {
+		t.Errorf("full sync reported %d changes, want none", len(got))
+	}
+}
+
+func TestDeltaSyncFetchesOnlyChanges(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	listID := srv.AddList("Work")
+	keep := srv.AddReminder("Keep", listID)
+	rename := srv.AddReminder("Old title", listID)
+	gone := srv.AddReminder("Gone", listID)
+
+	e := newEngine(t, srv)
+	if err := e.Sync(false); err != nil {
+		t.Fatalf("full sync: %v", err)
+	}
+	keepTag := *e.Cache.Reminders[keep].ChangeTag
+
+	fields := srv.Fields(rename)
+	for k, v := range title("New title") {
+		fields[k] = v
+	}
+	srv.Put(rename, "Reminder", fields)
+	added := srv.AddReminder("Added", listID)
+	srv.Remove(gone)
+
+	before := srv.Requests("changes/zone")
+	if err := e.Sync(false); err != nil {
+		t.Fatalf("delta sync: %v", err)
+	}
+	if got := srv.Requests("changes/zone") - before; got != 1 {
+		t.Errorf("delta sync made %d changes/zone requests, want 1", got)
+	}
+
+	if got := e.Cache.Reminders[rename].Title; got != "New title" {
+		t.Errorf("renamed title = %q, want New title", got)
+	}
+	if e.Cache.Reminders[added] == nil {
+		t.Error("added reminder not cached")
+	}
+	if e.Cache.Reminders[gone] != nil {
+		t.Error("deleted reminder still cached")
+	}
+	if got := *e.Cache.Reminders[keep].ChangeTag; got != keepTag {
+		t.Errorf("untouched reminder's change tag = %q, want %q", got, keepTag)
+	}
+
+	types := make(map[string]sync.ChangeType)
+	for _, c := range e.Changes() {
+		types[c.ID] = c.Type
+	}
+	want := map[string]sync.ChangeType{
+		rename: sync.ChangeUpdated,
+		added:  sync.ChangeCreated,
+		gone:   sync.ChangeDeleted,
+	}
+	if len(types) != len(want) {
+		t.Errorf("changes = %v, want %v", types, want)
+	}
+	for id, typ := range want {
+		if types[id] != typ {
+			t.Errorf("change for %s = %q, want %q", id, types[id], typ)
+		}
+	}
+}
+
+func TestSyncReauthsOnceAfter503(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	srv.AddReminder("Milk", srv.AddList("Groceries"))
+
+	e := newEngine(t, srv)
+	reauths := 0
+	e.Reauth = func() (cloudkit.API, error) {
+		reauths++
+		return cloudkit.NewFromSession(srv.Session())
+	}
+
+	srv.FailNext(503)
+	if err := e.Sync(false); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if reauths != 1 {
+		t.Errorf("reauths = %d, want 1", reauths)
+	}
+	if got := len(e.Cache.Reminders); got != 1 {
+		t.Errorf("cached reminders = %d, want 1", got)
+	}
+}
+
+func TestSyncFailsWhen503Persists(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+
+	e := newEngine(t, srv)
+	reauths := 0
+	e.Reauth = func() (cloudkit.API, error) {
+		reauths++
+		return cloudkit.NewFromSession(srv.Session())
+	}
+
+	srv.FailNext(503, 503)
+	err := e.Sync(false)
+	if err == nil || !strings.Contains(err.Error(), "503 persists") {
+		t.Fatalf("Sync error = %v, want 503 persists", err)
+	}
+	if reauths != 1 {
+		t.Errorf("reauths = %d, want 1", reauths)
+	}
+}
+
+func TestSyncDoesNotReauthOnOtherErrors(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+
+	e := newEngine(t, srv)
+	e.Reauth = func() (cloudkit.API, error) {
+		t.Error("Reauth called for a 500")
+		return nil, errors.New("unexpected")
+	}
+
+	srv.FailNext(500)
+	err := e.Sync(false)
+	var apiErr *cloudkit.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Fatalf("Sync error = %v, want API error 500", err)
+	}
+}
+
+func TestDeltaSyncReportsChanges(t *testing.T) {
+	oldNow := utils.Now
+	utils.Now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+	t.Cleanup(func() { utils.Now = oldNow })
+
+	srv := cktest.NewServer()
+	defer srv.Close()
+	work := srv.AddList("Work")
+	home := srv.AddList("Home")
+	updated := srv.AddReminder("Old title", work)
+	completed := srv.AddReminder("Finish", work)
+	completedAndRenamed := srv.AddReminder("Draft", work)
+	reopened := srv.AddReminder("Reopen me", work, map[string]interface{}{
+		"Completed":      map[string]interface{}{"value": 1},
+		"CompletionDate": map[string]interface{}{"value": time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC).UnixMilli()},
+	})
+	moved := srv.AddReminder("Move me", work)
+	editedDone := srv.AddReminder("Done before", work, map[string]interface{}{
+		"Completed": map[string]interface{}{"value": 1},
+	})
+	deleted := srv.AddReminder("Gone", work)
+	tagOnly := srv.AddReminder("Untouched", work)
+
+	e := newEngine(t, srv)
+	if err := e.Sync(false); err != nil {
+		t.Fatalf("full sync: %v", err)
+	}
+
+	edit := func(recordName string, fields map[string]interface{}) {
+		f := srv.Fields(recordName)
+		for k, v := range fields {
+			f[k] = v
+		}
+		srv.Put(recordName, "Reminder", f)
+	}
+	done := map[string]interface{}{
+		"Completed":      map[string]interface{}{"value": 1},
+		"CompletionDate": map[string]interface{}{"value": time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC).UnixMilli()},
+	}
+	edit(updated, title("New title"))
+	edit(completed, done)
+	edit(completedAndRenamed, done)
+	edit(completedAndRenamed, title("Final"))
+	edit(reopened, map[string]interface{}{
+		"Completed":      map[string]interface{}{"value": 0},
+		"CompletionDate": map[string]interface{}{"value": nil},
+	})
+	edit(moved, map[string]interface{}{
+		"List": map[string]interface{}{"value": map[string]interface{}{"recordName": home, "action": "NONE"}},
+	})
+	edit(editedDone, title("Done, renamed"))
+	srv.Remove(deleted)
+	edit(tagOnly, nil) // a new change tag, nothing the user can see
+	created := srv.AddReminder("Added", home)
+	srv.Remove(srv.AddReminder("Short-lived", home))
+	srv.Put(work, "ReminderList", map[string]interface{}{"Name": map[string]interface{}{"value": "Office"}})
+	srv.Put(home, "ReminderList", map[string]interface{}{"Name": map[string]interface{}{"value": "Home"}})
+
+	if err := e.Sync(false); err != nil {
+		t.Fatalf("delta sync: %v", err)
+	}
+	changes := make(map[string]*sync.Change)
+	for _, c := range e.Changes() {
+		if changes[c.ID] != nil {
+			t.Errorf("two changes for %s", c.ID)
+		}
+		changes[c.ID] = c
+		if c.Time != "2026-10-16T12:00:00Z" {
+			t.Errorf("change time = %q", c.Time)
+		}
+	}
+	want := map[string]sync.ChangeType{
+		updated:             sync.ChangeUpdated,
+		completed:           sync.ChangeCompleted,
+		completedAndRenamed: sync.ChangeCompleted,
+		reopened:            sync.ChangeUpdated,
+		moved:               sync.ChangeUpdated,
+		editedDone:          sync.ChangeUpdated,
+		deleted:             sync.ChangeDeleted,
+		created:             sync.ChangeCreated,
+		work:                sync.ChangeListRenamed,
+	}
+	for id, typ := range want {
+		if c := changes[id]; c == nil || c.Type != typ {
+			t.Errorf("change for %s = %+v, want %q", id, c, typ)
+		}
+	}
+	if len(changes) != len(want) {
+		for id, c := range changes {
+			if _, ok := want[id]; !ok {
+				t.Errorf("unexpected %q change for %s", c.Type, id)
+			}
+		}
+	}
+	if t.Failed() {
+		return
+	}
+
+	check := func(c *sync.Change, before, after string) {
+		t.Helper()
+		var b, a string
+		if r, ok := c.Before.(*models.Reminder); ok {
+			b = r.Title
+		}
+		if r, ok := c.After.(*models.Reminder); ok {
+			a = r.Title
+		}
+		if b != before || a != after {
+			t.Errorf("%s change: before %q, after %q; want %q, %q", c.Type, b, a, before, after)
+		}
+	}
+	check(changes[updated], "Old title", "New title")
+	check(changes[completedAndRenamed], "Draft", "Final")
+	check(changes[created], "", "Added")
+	check(changes[deleted], "Gone", "")
+	if r := changes[deleted].Reminder(); r == nil || r.Title != "Gone" {
+		t.Errorf("deleted change's Reminder() = %+v", r)
+	}
+	if r := changes[completed].Reminder(); r == nil || !r.Completed || r.CompletionDate == nil || *r.CompletionDate != "2026-10-16" {
+		t.Errorf("completed change's Reminder() = %+v", r)
+	}
+	if r := changes[moved].After.(*models.Reminder); r.ListName != "Home" {
+		t.Errorf("moved reminder is in %q", r.ListName)
+	}
+
+	c := changes[work]
+	before, _ := c.Before.(*models.ReminderList)
+	after, _ := c.After.(*models.ReminderList)
+	if before == nil || after == nil || before.Name != "Work" || after.Name != "Office" || after.ID != work {
+		t.Errorf("list change before %+v, after %+v", before, after)
+	}
+	if c.Reminder() != nil {
+		t.Error("list change has a reminder")
+	}
+
+	// Nothing changed since: a delta sync reports nothing.
+	if err := e.Sync(false); err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Changes(); len(got) != 0 {
+		t.Errorf("idle delta sync reported %d changes", len(got))
+	}
+}
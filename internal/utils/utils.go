@@ -8,10 +8,13 @@ import (
 	"encoding/base64"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"strings"
 	"time"
 	"unicode/utf8"
+
+	"icloud-reminders/internal/dateparse"
 )
 
 // EncodeVarint encodes a uint64 as a protobuf varint.
@@ -195,6 +198,175 @@ func StrToTs(dateStr string) (int64, error) {
 	return t.UTC().UnixMilli(), nil
 }
 
+// DateLayout is the format of all-day due dates and other calendar dates.
+const DateLayout = "2006-01-02"
+
+// DisplayLayout is the format used to show timed due dates.
+const DisplayLayout = "2006-01-02 15:04"
+
+// Location is the time zone used to interpret and display timed due dates.
+// It defaults to the system zone; see SetLocation.
+var Location = time.Local
+
+// Now returns the current time. Relative dates ("tomorrow", "+3d") are
+// resolved against it; tests may replace it with a fixed clock.
+var Now = time.Now
+
+// dueTimeLayouts are the accepted forms of a timed due date without an offset.
+var dueTimeLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+}
+
+// SetLocation sets Location from an IANA zone name (e.g. "Europe/Berlin").
+// An empty name keeps the current zone.
+func SetLocation(name string) error {
+	if name == "" {
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return fmt.Errorf("unknown time zone %q: %w", name, err)
+	}
+	Location = loc
+	return nil
+}
+
+// LocationName returns the IANA name of Location, resolving the system zone
+// via $TZ or /etc/localtime. Returns "" if the name cannot be determined.
+func LocationName() string {
+	if name := Location.String(); name != "Local" {
+		return name
+	}
+	if tz := strings.TrimPrefix(os.Getenv("TZ"), ":"); tz != "" {
+		return tz
+	}
+	if target, err := os.Readlink("/etc/localtime"); err == nil {
+		if i := strings.Index(target, "zoneinfo/"); i >= 0 {
+			return target[i+len("zoneinfo/"):]
+		}
+	}
+	return ""
+}
+
+// ParseDue parses a due date given on the command line.
+//
+//	2026-10-17                  all-day (stored as UTC midnight)
+//	2026-10-17 09:00            timed, in Location
+//	2026-10-17T09:00:00+02:00   timed, explicit offset (RFC 3339)
+//	tomorrow, fri 9am, +3d      relative to Now (see package dateparse)
+//
+// Returns the CloudKit millisecond timestamp and whether the date is all-day.
+func ParseDue(s string) (tsMs int64, allDay bool, err error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(DateLayout, s); err == nil {
+		return t.UTC().UnixMilli(), true, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t.UnixMilli(), false, nil
+	}
+	for _, layout := range dueTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, Location); err == nil {
+			return t.UnixMilli(), false, nil
+		}
+	}
+	t, allDay, err := dateparse.Parse(s, Now().In(Location))
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid due date %q (expected YYYY-MM-DD, \"YYYY-MM-DD HH:MM\" or e.g. tomorrow, \"fri 9am\", +3d)", s)
+	}
+	if allDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC).UnixMilli(), true, nil
+	}
+	return t.UnixMilli(), false, nil
+}
+
+// DueTimeZone returns the IANA time zone to store with a timed due date s
+// (see ParseDue). A date with an explicit RFC 3339 offset gets the zone the
+// offset implies: Location's if Location has that offset at that time,
+// otherwise "UTC" or "Etc/GMT±N" for whole hours. Other dates get
+// LocationName. Returns "" if no zone name fits.
+func DueTimeZone(s string) string {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+	if err != nil {
+		return LocationName()
+	}
+	_, offset := t.Zone()
+	if _, locOffset := t.In(Location).Zone(); locOffset == offset {
+		return LocationName()
+	}
+	switch {
+	case offset == 0:
+		return "UTC"
+	case offset%3600 != 0:
+		return ""
+	case offset > 0:
+		// Etc/GMT zones have POSIX signs: Etc/GMT-2 is UTC+2.
+		return fmt.Sprintf("Etc/GMT-%d", offset/3600)
+	default:
+		return fmt.Sprintf("Etc/GMT+%d", -offset/3600)
+	}
+}
+
+// ParseDate parses a calendar date (YYYY-MM-DD or a relative phrase such
+// as "next month" or "+2w") and returns it as YYYY-MM-DD.
+func ParseDate(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if _, err := time.Parse(DateLayout, s); err == nil {
+		return s, nil
+	}
+	t, _, err := dateparse.Parse(s, Now().In(Location))
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q (expected YYYY-MM-DD or e.g. \"next month\", +2w)", s)
+	}
+	return t.Format(DateLayout), nil
+}
+
+// FormatDue renders a CloudKit due timestamp for the cache: YYYY-MM-DD for
+// all-day dates, RFC 3339 in time zone tz (or Location) for timed ones.
+func FormatDue(tsMs int64, allDay bool, tz string) string {
+	if tsMs == 0 {
+		return ""
+	}
+	t := time.UnixMilli(tsMs)
+	if allDay {
+		return t.UTC().Format(DateLayout)
+	}
+	loc := Location
+	if tz != "" {
+		if l, err := time.LoadLocation(tz); err == nil {
+			loc = l
+		}
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// LocalDue converts a cached due string to Location; all-day dates are
+// returned unchanged.
+func LocalDue(due string) string {
+	t, err := time.Parse(time.RFC3339, due)
+	if err != nil {
+		return due
+	}
+	return t.In(Location).Format(time.RFC3339)
+}
+
+// DisplayDue formats a due string for human output: YYYY-MM-DD for all-day
+// dates, "YYYY-MM-DD HH:MM" in Location for timed ones.
+func DisplayDue(due string) string {
+	t, err := time.Parse(time.RFC3339, due)
+	if err != nil {
+		return due
+	}
+	return t.In(Location).Format(DisplayLayout)
+}
+
+// IsAllDay reports whether a due string is a date without a time.
+func IsAllDay(due string) bool {
+	return len(due) == len(DateLayout)
+}
+
 // generateUUID generates a random 16-byte UUID (v4).
 func generateUUID() []byte {
 	b := make([]byte, 16)
@@ -0,0 +1,147 @@
+package utils_test
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // the zone tests need zoneinfo on any host
+
+	"icloud-reminders/internal/utils"
+)
+
+// inZone runs a test with Location set to the IANA zone name and Now fixed
+// at Wednesday 2026-10-14 10:30 in that zone.
+func inZone(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldLoc, oldNow := utils.Location, utils.Now
+	utils.Location = loc
+	utils.Now = func() time.Time { return time.Date(2026, 10, 14, 10, 30, 0, 0, loc) }
+	t.Cleanup(func() { utils.Location, utils.Now = oldLoc, oldNow })
+	return loc
+}
+
+func TestParseDue(t *testing.T) {
+	inZone(t, "Europe/Berlin")
+	tests := []struct {
+		in     string
+		want   string // the instant in UTC, or the date for all-day dues
+		allDay bool
+	}{
+		{"2026-10-17", "2026-10-17", true},
+		{" 2026-10-17 ", "2026-10-17", true},
+		{"2026-10-17 09:00", "2026-10-17T07:00:00Z", false}, // CEST
+		{"2026-11-17 09:00", "2026-11-17T08:00:00Z", false}, // CET
+		{"2026-10-17T09:00", "2026-10-17T07:00:00Z", false},
+		{"2026-10-17 09:00:30", "2026-10-17T07:00:30Z", false},
+		{"2026-10-17T09:00:00+02:00", "2026-10-17T07:00:00Z", false},
+		{"2026-10-17T09:00:00Z", "2026-10-17T09:00:00Z", false},
+		{"2026-10-17T09:00:00-05:00", "2026-10-17T14:00:00Z", false},
+		{"tomorrow", "2026-10-15", true},
+		{"+3d", "2026-10-17", true},
+		{"fri 9am", "2026-10-16T07:00:00Z", false},
+		{"eod", "2026-10-14T15:00:00Z", false},
+	}
+	for _, tt := range tests {
+		ts, allDay, err := utils.ParseDue(tt.in)
+		if err != nil {
+			t.Errorf("ParseDue(%q): %v", tt.in, err)
+			continue
+		}
+		got := time.UnixMilli(ts).UTC().Format(time.RFC3339)
+		if tt.allDay {
+			// All-day dates are stored as UTC midnight.
+			if want := tt.want + "T00:00:00Z"; got != want {
+				t.Errorf("ParseDue(%q) = %s, want %s", tt.in, got, want)
+			}
+		} else if got != tt.want {
+			t.Errorf("ParseDue(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+		if allDay != tt.allDay {
+			t.Errorf("ParseDue(%q) all-day = %v, want %v", tt.in, allDay, tt.allDay)
+		}
+	}
+
+	for _, in := range []string{"", "2026-13-01", "2026-10-17 25:00", "someday", "17/10/2026"} {
+		if _, _, err := utils.ParseDue(in); err == nil {
+			t.Errorf("ParseDue(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestFormatDue(t *testing.T) {
+	inZone(t, "America/New_York")
+	ts := time.Date(2026, 10, 17, 7, 0, 0, 0, time.UTC).UnixMilli()
+	midnight := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC).UnixMilli()
+	tests := []struct {
+		ts     int64
+		allDay bool
+		tz     string
+		want   string
+	}{
+		{midnight, true, "", "2026-10-17"},
+		// All-day dates are UTC dates whatever the zone.
+		{midnight, true, "Asia/Tokyo", "2026-10-17"},
+		{ts, false, "", "2026-10-17T03:00:00-04:00"}, // Location
+		{ts, false, "Europe/Berlin", "2026-10-17T09:00:00+02:00"},
+		{ts, false, "UTC", "2026-10-17T07:00:00Z"},
+		{ts, false, "Etc/GMT-2", "2026-10-17T09:00:00+02:00"},
+		{ts, false, "Not/AZone", "2026-10-17T03:00:00-04:00"}, // falls back to Location
+		{0, false, "", ""},
+	}
+	for _, tt := range tests {
+		if got := utils.FormatDue(tt.ts, tt.allDay, tt.tz); got != tt.want {
+			t.Errorf("FormatDue(%d, %v, %q) = %q, want %q", tt.ts, tt.allDay, tt.tz, got, tt.want)
+		}
+	}
+}
+
+func TestIsAllDay(t *testing.T) {
+	for due, want := range map[string]bool{
+		"2026-10-17":                true,
+		"2026-10-17T09:00:00+02:00": false,
+		"2026-10-17T09:00:00Z":      false,
+		"":                          false,
+	} {
+		if got := utils.IsAllDay(due); got != want {
+			t.Errorf("IsAllDay(%q) = %v, want %v", due, got, want)
+		}
+	}
+}
+
+func TestDueTimeZone(t *testing.T) {
+	inZone(t, "Europe/Berlin")
+	tests := []struct{ in, want string }{
+		// No offset: the date is in Location.
+		{"2026-10-17 09:00", "Europe/Berlin"},
+		{"fri 9am", "Europe/Berlin"},
+		// Location's own offset, in summer and in winter.
+		{"2026-10-17T09:00:00+02:00", "Europe/Berlin"},
+		{"2026-12-17T09:00:00+01:00", "Europe/Berlin"},
+		// Other offsets.
+		{"2026-12-17T09:00:00+02:00", "Etc/GMT-2"},
+		{"2026-10-17T09:00:00Z", "UTC"},
+		{"2026-10-17T09:00:00+00:00", "UTC"},
+		{"2026-10-17T09:00:00-05:00", "Etc/GMT+5"},
+		{"2026-10-17T09:00:00+14:00", "Etc/GMT-14"},
+		{"2026-10-17T09:00:00+05:30", ""},
+	}
+	for _, tt := range tests {
+		if got := utils.DueTimeZone(tt.in); got != tt.want {
+			t.Errorf("DueTimeZone(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	// The zone keeps the due date's wall clock and offset when formatted.
+	for _, in := range []string{"2026-10-17T09:00:00-05:00", "2026-12-17T09:00:00+02:00", "2026-10-17T09:00:00Z"} {
+		ts, _, err := utils.ParseDue(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := utils.FormatDue(ts, false, utils.DueTimeZone(in)); got != in {
+			t.Errorf("FormatDue(ParseDue(%q)) = %q", in, got)
+		}
+	}
+}
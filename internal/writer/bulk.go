@@ -0,0 +1,258 @@
+package writer
+
+import (
+	"fmt"
+	"strings"
+
+	"icloud-reminders/internal/logger"
+)
+
+// BulkChunkSize is the maximum number of operations sent in one
+// records/modify request by the bulk writers.
+const BulkChunkSize = 200
+
+// change is one reminder's share of a write: the operations to send and the
+// cache update to make once CloudKit has accepted them.
+type change struct {
+	id  string // reminder record name
+	ops []map[string]interface{}
+	// extra is merged into the result (e.g. "next_due" for a rolled reminder).
+	extra map[string]interface{}
+	apply func(result map[string]interface{})
+}
+
+// commit sends a single change in one atomic request and applies it to the
+// cache.
+func (w *Writer) commit(ownerID, verb string, c *change) (map[string]interface{}, error) {
+	logger.Debugf("%s: updating record %s (%d operation(s))", verb, c.id, len(c.ops))
+	result, err := w.CK.ModifyRecords(ownerID, c.ops)
+	if err != nil {
+		return errResult(err), nil
+	}
+	if err := checkRecordErrors(result); err != nil {
+		return errResult(err), nil
+	}
+	if _, hasErr := result["error"]; hasErr {
+		return result, nil
+	}
+	c.apply(result)
+	if err := w.Sync.Cache.Save(); err != nil {
+		logger.Warnf("cache save failed: %v", err)
+	}
+	for k, v := range c.extra {
+		result[k] = v
+	}
+	return result, nil
+}
+
+// CompleteReminders completes many reminders (see CompleteReminder).
+// The result lists one entry per reminder under "results"; see bulk.
+func (w *Writer) CompleteReminders(reminderIDs []string) (map[string]interface{}, error) {
+	return w.bulk("complete", reminderIDs, w.completeChange)
+}
+
+// DeleteReminders deletes many reminders (see DeleteReminder).
+func (w *Writer) DeleteReminders(reminderIDs []string) (map[string]interface{}, error) {
+	return w.bulk("delete", reminderIDs, w.deleteChange)
+}
+
+// EditReminders applies the same edit to many reminders (see EditReminder).
+func (w *Writer) EditReminders(reminderIDs []string, f EditFields) (map[string]interface{}, error) {
+	if f.empty() {
+		return errResult(fmt.Errorf("no changes specified — use --title, --due, --notes, --priority, --repeat, --parent, --clear-due or --clear-notes")), nil
+	}
+	return w.bulk("edit", reminderIDs, func(reminderID, fullID string) (*change, error) {
+		return w.editChange(reminderID, fullID, f)
+	})
+}
+
+// bulk plans a change per reminder and sends them in atomic requests of at
+// most BulkChunkSize operations. Reminders that cannot be planned (not found,
+// invalid edit, ...) or that CloudKit rejects are reported individually and
+// do not stop the others.
+//
+// The result has "results" — one map per reminder with "id", "title", "ok"
+// and, on failure, "error" — plus "succeeded" and "failed" counts.
+func (w *Writer) bulk(verb string, reminderIDs []string, plan func(reminderID, fullID string) (*change, error)) (map[string]interface{}, error) {
+	if len(reminderIDs) == 0 {
+		return errResult(fmt.Errorf("no reminders selected")), nil
+	}
+	ownerID, err := w.ownerID()
+	if err != nil {
+		return errResult(err), nil
+	}
+
+	var entries []map[string]interface{}
+	var changes []*change
+	entryFor := make(map[*change]map[string]interface{})
+	seen := make(map[string]bool)
+	for _, reminderID := range reminderIDs {
+		fullID := w.Sync.FindReminderByID(reminderID)
+		if fullID == "" {
+			entries = append(entries, map[string]interface{}{
+				"id":    reminderID,
+				"ok":    false,
+				"error": fmt.Sprintf("reminder '%s' not found", reminderID),
+			})
+			continue
+		}
+		if seen[fullID] {
+			continue
+		}
+		seen[fullID] = true
+		entry := map[string]interface{}{"id": strings.TrimPrefix(fullID, "Reminder/"), "ok": false}
+		if rd := w.Sync.Cache.Reminders[fullID]; rd != nil {
+			entry["title"] = rd.Title
+		}
+		entries = append(entries, entry)
+		c, err := plan(reminderID, fullID)
+		if err != nil {
+			entry["error"] = err.Error()
+			continue
+		}
+		changes = append(changes, c)
+		entryFor[c] = entry
+	}
+
+	for _, chunk := range chunkChanges(changes, BulkChunkSize) {
+		logger.Debugf("%s: sending %d reminder(s) in one request", verb, len(chunk))
+		for c, err := range w.sendChunk(ownerID, chunk, true) {
+			if err != nil {
+				entryFor[c]["error"] = err.Error()
+				continue
+			}
+			entryFor[c]["ok"] = true
+			for k, v := range c.extra {
+				entryFor[c][k] = v
+			}
+		}
+	}
+	if len(changes) > 0 {
+		if err := w.Sync.Cache.Save(); err != nil {
+			logger.Warnf("cache save failed: %v", err)
+		}
+	}
+
+	succeeded := 0
+	for _, e := range entries {
+		if e["ok"] == true {
+			succeeded++
+		}
+	}
+	logger.Infof("Bulk %s: %d succeeded, %d failed", verb, succeeded, len(entries)-succeeded)
+	return map[string]interface{}{
+		"results":   entries,
+		"succeeded": succeeded,
+		"failed":    len(entries) - succeeded,
+	}, nil
+}
+
+// chunkChanges splits changes into groups of at most size operations,
+// never splitting one reminder's operations across groups.
+func chunkChanges(changes []*change, size int) [][]*change {
+	var chunks [][]*change
+	var cur []*change
+	n := 0
+	for _, c := range changes {
+		if len(cur) > 0 && n+len(c.ops) > size {
+			chunks = append(chunks, cur)
+			cur, n = nil, 0
+		}
+		cur = append(cur, c)
+		n += len(c.ops)
+	}
+	if len(cur) > 0 {
+		chunks = append(chunks, cur)
+	}
+	return chunks
+}
+
+// sendChunk sends chunk as one atomic request and applies the changes that
+// succeeded. It returns every change of the chunk mapped to its error (nil on
+// success). When CloudKit rejects some records the whole request is aborted;
+// with retry set, the remaining changes are then resent once on their own so
+// one stale reminder does not hold back the rest.
+func (w *Writer) sendChunk(ownerID string, chunk []*change, retry bool) map[*change]error {
+	var ops []map[string]interface{}
+	for _, c := range chunk {
+		ops = append(ops, c.ops...)
+	}
+	out := make(map[*change]error, len(chunk))
+	failAll := func(err error) map[*change]error {
+		for _, c := range chunk {
+			out[c] = err
+		}
+		return out
+	}
+
+	result, err := w.CK.ModifyRecords(ownerID, ops)
+	if err != nil {
+		return failAll(err)
+	}
+	if msg, ok := result["error"].(string); ok {
+		return failAll(fmt.Errorf("%s", msg))
+	}
+
+	recordErrs := recordErrors(result)
+	if len(recordErrs) == 0 {
+		if err := checkRecordErrors(result); err != nil {
+			return failAll(err)
+		}
+		for _, c := range chunk {
+			c.apply(result)
+			out[c] = nil
+		}
+		return out
+	}
+
+	var rest []*change
+	for _, c := range chunk {
+		var cerr error
+		for _, op := range c.ops {
+			if e, ok := recordErrs[opRecordName(op)]; ok {
+				cerr = e
+				break
+			}
+		}
+		if cerr != nil {
+			out[c] = cerr
+		} else {
+			rest = append(rest, c)
+		}
+	}
+	if len(rest) == 0 {
+		return out
+	}
+	if !retry || len(rest) == len(chunk) {
+		for _, c := range rest {
+			out[c] = fmt.Errorf("not applied: another record in the same request failed")
+		}
+		return out
+	}
+	for c, err := range w.sendChunk(ownerID, rest, false) {
+		out[c] = err
+	}
+	return out
+}
+
+// recordErrors maps record names to the record-level errors in a
+// records/modify response, ignoring the ATOMIC_ERROR placeholders CloudKit
+// reports for records that were only skipped because another one failed.
+func recordErrors(result map[string]interface{}) map[string]error {
+	errs := make(map[string]error)
+	records, _ := result["records"].([]interface{})
+	for _, r := range records {
+		rec, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		code, _ := rec["serverErrorCode"].(string)
+		if code == "" || code == "ATOMIC_ERROR" {
+			continue
+		}
+		name, _ := rec["recordName"].(string)
+		reason, _ := rec["reason"].(string)
+		errs[name] = fmt.Errorf("CloudKit error %s: %s", code, reason)
+	}
+	return errs
+}
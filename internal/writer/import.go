@@ -0,0 +1,516 @@
+package writer
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"icloud-reminders/internal/logger"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// ImportItem is one reminder to create with ImportReminders.
+type ImportItem struct {
+	// UID identifies the item in its source (e.g. an iCalendar UID). It is
+	// remembered in the cache so that importing the same item again is
+	// skipped; other items refer to it in Parent. May be empty.
+	UID string
+	// MatchTitle makes a reminder with the same title in the item's list
+	// count as the item when no reminder has its UID, for sources such as
+	// checklists that do not keep IDs.
+	MatchTitle     bool
+	Title          string
+	Notes          string
+	Due            string // any utils.ParseDue form
+	Priority       int    // 0 (none), 1 (high), 5 (medium) or 9 (low)
+	Completed      bool
+	CompletionDate string // RFC 3339 or YYYY-MM-DD; now if empty
+	// Parent is the UID of another item, or the ID of an existing reminder
+	// in the same list. ParentIndex, if set, takes precedence: the 1-based
+	// position of the parent in the items, for sources without UIDs.
+	// ParentTitle, if set, names the parent by title: another item in the
+	// same list, else an existing reminder there.
+	Parent      string
+	ParentIndex int
+	ParentTitle string
+	Recurrence  *models.Recurrence
+	// List is the name of the list to create the item in; the default list
+	// passed to ImportReminders if empty.
+	List string
+	// Line is the item's line or row in the source, reported as "line".
+	Line int
+}
+
+// Import statuses reported per item.
+const (
+	ImportCreated   = "created"
+	ImportCompleted = "completed" // existing reminder checked off
+	ImportPlanned   = "planned"   // dry run: would be created or completed
+	ImportSkipped   = "skipped"   // already imported
+	ImportFailed    = "failed"
+)
+
+// importPlan is an item being imported.
+type importPlan struct {
+	item   ImportItem
+	entry  map[string]interface{}
+	parent *importPlan // parent created by the same import, if any
+	listID string
+	c      *change
+}
+
+// ImportReminders creates reminders in their List, or in listName for items
+// that do not name one (or name a list that does not exist). Items whose UID
+// was already imported, or equals the ID of an existing reminder, are
+// skipped — or, if the item is completed and the reminder is still open,
+// the reminder is completed. Parents are created before their subtasks:
+// items are sent level by level in atomic requests of at most BulkChunkSize
+// operations, like the bulk writers. With dryRun nothing is sent and items
+// are reported as "planned", with "action" create or complete.
+//
+// The result has "results" — one map per item with "uid", "title", "line",
+// "list", "status" (created, completed, planned, skipped or failed), "id" and
+// "parent" (record names), "error" and "warning" — plus "created" (in a dry
+// run: planned), "completed", "skipped" and "failed" counts.
+func (w *Writer) ImportReminders(items []ImportItem, listName string, dryRun bool) (map[string]interface{}, error) {
+	if len(items) == 0 {
+		return errResult(fmt.Errorf("nothing to import")), nil
+	}
+	if listName != "" && w.Sync.FindListByName(listName) == "" {
+		return errResult(fmt.Errorf("list '%s' not found", listName)), nil
+	}
+	ownerID := ""
+	if !dryRun {
+		var err error
+		if ownerID, err = w.ownerID(); err != nil {
+			return errResult(err), nil
+		}
+	}
+
+	// Plan: skip duplicates, then resolve parents among the new items.
+	var plans []*importPlan
+	var entries []map[string]interface{}
+	byUID := make(map[string]*importPlan)
+	byIndex := make([]*importPlan, len(items))
+	existing := make(map[string]string) // UID → record name of a skipped item
+	existingAt := make([]string, len(items))
+	var completions []*change
+	completionEntry := make(map[*change]map[string]interface{})
+	for i, item := range items {
+		entry := map[string]interface{}{"uid": item.UID, "title": item.Title}
+		if item.Line > 0 {
+			entry["line"] = item.Line
+		}
+		entries = append(entries, entry)
+		list, listID, listErr := w.importList(item.List, listName)
+		id := ""
+		if item.UID != "" {
+			id = w.importedRecord(item.UID)
+		}
+		if id == "" && item.MatchTitle && listID != "" {
+			id = w.reminderTitled(listID, item.Title)
+		}
+		if id != "" {
+			entry["id"] = id
+			if item.UID != "" {
+				existing[item.UID] = id
+			}
+			existingAt[i] = id
+			c, err := w.importCompletion(item, id)
+			switch {
+			case err != nil:
+				entry["status"] = ImportFailed
+				entry["error"] = err.Error()
+			case c == nil:
+				entry["status"] = ImportSkipped
+			case dryRun:
+				entry["status"] = ImportPlanned
+				entry["action"] = "complete"
+			default:
+				completions = append(completions, c)
+				completionEntry[c] = entry
+			}
+			continue
+		}
+		if item.UID != "" && byUID[item.UID] != nil {
+			entry["status"] = ImportSkipped
+			entry["warning"] = "duplicate UID in input"
+			continue
+		}
+		if item.Title == "" {
+			entry["status"] = ImportFailed
+			entry["error"] = "missing title"
+			continue
+		}
+		entry["list"] = list
+		if listErr != nil {
+			entry["status"] = ImportFailed
+			entry["error"] = listErr.Error()
+			continue
+		}
+		if item.List != "" && list != item.List {
+			entry["warning"] = fmt.Sprintf("list '%s' not found; imported into '%s'", item.List, list)
+		}
+		p := &importPlan{item: item, entry: entry, listID: listID}
+		plans = append(plans, p)
+		byIndex[i] = p
+		if item.UID != "" {
+			byUID[item.UID] = p
+		}
+	}
+
+	for _, p := range plans {
+		if i := p.item.ParentIndex - 1; i >= 0 && i < len(items) {
+			if pp := byIndex[i]; pp != nil {
+				p.item.Parent = ""
+				if pp.listID != p.listID {
+					p.entry["warning"] = "parent is in another list; imported as top-level"
+				} else {
+					p.parent = pp
+				}
+				continue
+			}
+			p.item.Parent = existingAt[i]
+			if p.item.Parent == "" {
+				p.entry["warning"] = "parent was not imported; imported as top-level"
+				continue
+			}
+		} else if p.item.ParentTitle != "" {
+			w.resolveParentTitle(p, plans)
+			continue
+		}
+		w.resolveImportParent(p, byUID, existing)
+	}
+	var levels [][]*importPlan
+	for _, p := range plans {
+		level := importLevel(p)
+		for len(levels) <= level {
+			levels = append(levels, nil)
+		}
+		levels[level] = append(levels[level], p)
+	}
+
+	// Build each level once its parents have record names, then send it.
+	for _, level := range levels {
+		var changes []*change
+		planFor := make(map[*change]*importPlan)
+		for _, p := range level {
+			if p.parent != nil && p.parent.entry["status"] == ImportFailed {
+				p.entry["status"] = ImportFailed
+				p.entry["error"] = "parent was not created"
+				continue
+			}
+			c, err := w.importChange(p)
+			if err != nil {
+				p.entry["status"] = ImportFailed
+				p.entry["error"] = err.Error()
+				continue
+			}
+			p.c = c
+			p.entry["id"] = c.id
+			if dryRun {
+				p.entry["status"] = ImportPlanned
+				p.entry["action"] = "create"
+				continue
+			}
+			changes = append(changes, c)
+			planFor[c] = p
+		}
+		for _, chunk := range chunkChanges(changes, BulkChunkSize) {
+			logger.Debugf("import: creating %d reminder(s) in one request", len(chunk))
+			for c, err := range w.sendChunk(ownerID, chunk, true) {
+				p := planFor[c]
+				if err != nil {
+					p.entry["status"] = ImportFailed
+					p.entry["error"] = err.Error()
+					delete(p.entry, "id")
+					continue
+				}
+				p.entry["status"] = ImportCreated
+			}
+		}
+	}
+	for _, chunk := range chunkChanges(completions, BulkChunkSize) {
+		logger.Debugf("import: completing %d reminder(s) in one request", len(chunk))
+		for c, err := range w.sendChunk(ownerID, chunk, true) {
+			if err != nil {
+				completionEntry[c]["status"] = ImportFailed
+				completionEntry[c]["error"] = err.Error()
+				continue
+			}
+			completionEntry[c]["status"] = ImportCompleted
+		}
+	}
+	if !dryRun && len(plans)+len(completions) > 0 {
+		if err := w.Sync.Cache.Save(); err != nil {
+			logger.Warnf("cache save failed: %v", err)
+		}
+	}
+
+	counts := map[string]int{}
+	for _, e := range entries {
+		status, _ := e["status"].(string)
+		counts[status]++
+	}
+	planned := map[string]int{}
+	for _, e := range entries {
+		if e["status"] == ImportPlanned {
+			action, _ := e["action"].(string)
+			planned[action]++
+		}
+	}
+	logger.Infof("Import: %d created, %d completed, %d skipped, %d failed",
+		counts[ImportCreated], counts[ImportCompleted], counts[ImportSkipped], counts[ImportFailed])
+	return map[string]interface{}{
+		"results":   entries,
+		"created":   counts[ImportCreated] + planned["create"],
+		"completed": counts[ImportCompleted] + planned["complete"],
+		"skipped":   counts[ImportSkipped],
+		"failed":    counts[ImportFailed],
+	}, nil
+}
+
+// importedRecord returns the record name of the reminder already imported
+// under uid, or of an existing reminder whose ID is uid (a file exported by
+// this program), or "" if there is none.
+func (w *Writer) importedRecord(uid string) string {
+	if id := w.Sync.Cache.ImportedUIDs[uid]; id != "" && w.Sync.Cache.Reminders[id] != nil {
+		return id
+	}
+	for _, id := range []string{uid, "Reminder/" + uid} {
+		if w.Sync.Cache.Reminders[id] != nil {
+			return id
+		}
+	}
+	return ""
+}
+
+// importList picks the list for an item that names itemList: that list, or
+// defaultList if the item names none or one that does not exist. It returns
+// the list's name and ID, or an error.
+func (w *Writer) importList(itemList, defaultList string) (string, string, error) {
+	if itemList == "" {
+		if defaultList == "" {
+			return "", "", fmt.Errorf("no list given; use --list")
+		}
+		return defaultList, w.findImportList(defaultList), nil
+	}
+	if id := w.findImportList(itemList); id != "" {
+		return itemList, id, nil
+	}
+	if defaultList == "" {
+		return itemList, "", fmt.Errorf("list '%s' not found", itemList)
+	}
+	return defaultList, w.findImportList(defaultList), nil
+}
+
+// reminderTitled returns the record name of a reminder in listID titled
+// title (ignoring case), preferring open reminders, or "" if there is none.
+func (w *Writer) reminderTitled(listID, title string) string {
+	title = strings.TrimSpace(title)
+	match := ""
+	for rid, rd := range w.Sync.Cache.Reminders {
+		if rd.ListRef == nil || *rd.ListRef != listID || !strings.EqualFold(strings.TrimSpace(rd.Title), title) {
+			continue
+		}
+		// Prefer open reminders, then the lowest record name, so the
+		// choice does not depend on map order.
+		if cur := w.Sync.Cache.Reminders[match]; match == "" ||
+			(cur.Completed && !rd.Completed) || (cur.Completed == rd.Completed && rid < match) {
+			match = rid
+		}
+	}
+	return match
+}
+
+// importCompletion plans completing the existing reminder fullID for an
+// item that is completed, or returns nil if there is nothing to do. A
+// repeating reminder is only completed while its due date is the item's,
+// so importing the same file again does not roll it forward twice.
+func (w *Writer) importCompletion(item ImportItem, fullID string) (*change, error) {
+	rd := w.Sync.Cache.Reminders[fullID]
+	if !item.Completed || rd == nil || rd.Completed {
+		return nil, nil
+	}
+	if rd.Recurrence != nil && item.Due != "" && (rd.Due == nil || !sameDueDay(*rd.Due, item.Due)) {
+		return nil, nil
+	}
+	return w.completeChange(fullID, fullID)
+}
+
+// sameDueDay reports whether two due dates fall on the same local day.
+func sameDueDay(a, b string) bool {
+	day := func(due string) string {
+		if ts, allDay, err := utils.ParseDue(due); err == nil {
+			return utils.DisplayDue(utils.FormatDue(ts, allDay, ""))[:len(utils.DateLayout)]
+		}
+		return due
+	}
+	return day(a) == day(b)
+}
+
+// findImportList returns the ID of the list called name. Names from task
+// files may have spaces written as "_" (todo.txt +Project), so that form is
+// tried too.
+func (w *Writer) findImportList(name string) string {
+	if name == "" {
+		return ""
+	}
+	if id := w.Sync.FindListByName(name); id != "" {
+		return id
+	}
+	if strings.Contains(name, "_") {
+		return w.Sync.FindListByName(strings.ReplaceAll(name, "_", " "))
+	}
+	return ""
+}
+
+// resolveImportParent links p to its parent: another new item, or an
+// existing reminder in the same list. Parents that cannot be found make the
+// item top-level with a warning.
+func (w *Writer) resolveImportParent(p *importPlan, byUID map[string]*importPlan, existing map[string]string) {
+	parent := p.item.Parent
+	p.item.Parent = ""
+	if parent == "" {
+		return
+	}
+	if pp := byUID[parent]; pp != nil {
+		switch {
+		case pp == p:
+			p.entry["warning"] = "reminder is its own parent; imported as top-level"
+		case pp.listID != p.listID:
+			p.entry["warning"] = fmt.Sprintf("parent '%s' is in another list; imported as top-level", parent)
+		default:
+			p.parent = pp
+		}
+		return
+	}
+	ref := existing[parent]
+	if ref == "" && w.Sync.Cache.Reminders[parent] != nil {
+		ref = parent
+	}
+	if ref == "" {
+		ref = w.Sync.FindReminderByID(parent)
+	}
+	if ref == "" {
+		p.entry["warning"] = fmt.Sprintf("parent '%s' not found; imported as top-level", parent)
+		return
+	}
+	if rd := w.Sync.Cache.Reminders[ref]; rd == nil || rd.ListRef == nil || *rd.ListRef != p.listID {
+		p.entry["warning"] = fmt.Sprintf("parent '%s' is in another list; imported as top-level", parent)
+		return
+	}
+	p.item.Parent = ref
+}
+
+// resolveParentTitle links p to the parent named by its ParentTitle: the
+// first other new item with that title in p's list, else an existing
+// reminder there. Parents that cannot be found make the item top-level with
+// a warning.
+func (w *Writer) resolveParentTitle(p *importPlan, plans []*importPlan) {
+	title := strings.TrimSpace(p.item.ParentTitle)
+	p.item.Parent = ""
+	for _, pp := range plans {
+		if pp != p && pp.listID == p.listID && strings.EqualFold(strings.TrimSpace(pp.item.Title), title) {
+			p.parent = pp
+			return
+		}
+	}
+	if ref := w.reminderTitled(p.listID, title); ref != "" {
+		p.item.Parent = ref
+		return
+	}
+	p.entry["warning"] = fmt.Sprintf("parent '%s' not found; imported as top-level", title)
+}
+
+// importLevel returns how many new items are above p. A parent cycle is
+// cut where it closes, making that item top-level.
+func importLevel(p *importPlan) int {
+	for {
+		seen := make(map[*importPlan]bool)
+		level := 0
+		q := p
+		for ; q.parent != nil && !seen[q]; q = q.parent {
+			seen[q] = true
+			level++
+		}
+		if q.parent == nil {
+			return level
+		}
+		q.entry["warning"] = "parent cycle; imported as top-level"
+		q.parent = nil
+	}
+}
+
+// importChange plans creating p's reminder.
+func (w *Writer) importChange(p *importPlan) (*change, error) {
+	item, listID := p.item, p.listID
+	parentRef := item.Parent
+	if p.parent != nil {
+		parentRef = p.parent.c.id
+	}
+	switch item.Priority {
+	case 0, 1, 5, 9:
+	default:
+		return nil, fmt.Errorf("invalid priority %d", item.Priority)
+	}
+
+	op, recordName, rd, err := buildCreateOp(item.Title, listID, parentRef, item.Due, item.Priority, item.Notes)
+	if err != nil {
+		return nil, err
+	}
+
+	var completedAt int64
+	if item.Completed {
+		completedAt = time.Now().UnixMilli()
+		if item.CompletionDate != "" {
+			ts, _, err := utils.ParseDue(item.CompletionDate)
+			if err != nil {
+				return nil, fmt.Errorf("invalid completion date %q", item.CompletionDate)
+			}
+			completedAt = ts
+		}
+		fields := op["record"].(map[string]interface{})["fields"].(map[string]interface{})
+		fields["Completed"] = map[string]interface{}{"value": 1}
+		fields["CompletionDate"] = map[string]interface{}{"value": completedAt}
+	}
+
+	ops := []map[string]interface{}{op}
+	rule := item.Recurrence
+	if rule != nil && item.Due == "" {
+		p.entry["warning"] = "repeat rule dropped: a repeating reminder needs a due date"
+		rule = nil
+	}
+	ruleID := ""
+	if rule != nil {
+		var ruleOp map[string]interface{}
+		ruleOp, ruleID = buildRuleOp(recordName, "", nil, rule)
+		ops = append(ops, ruleOp)
+	}
+
+	apply := func(result map[string]interface{}) {
+		now := time.Now().UnixMilli()
+		rd.ModifiedTS = &now
+		if item.Completed {
+			rd.Completed = true
+			date := utils.TsToStr(completedAt)
+			rd.CompletionDate = &date
+		}
+		if ct := changeTagFor(result, recordName); ct != "" {
+			rd.ChangeTag = &ct
+		}
+		if rule != nil {
+			rd.Recurrence = ruleData(ruleID, rule, result)
+		}
+		w.Sync.Cache.Reminders[recordName] = rd
+		if item.UID != "" {
+			w.Sync.Cache.ImportedUIDs[item.UID] = recordName
+		}
+		logger.Infof("Imported reminder: %q", item.Title)
+	}
+	if parentRef != "" {
+		p.entry["parent"] = parentRef
+	}
+	return &change{id: recordName, ops: ops, apply: apply}, nil
+}
@@ -0,0 +1,356 @@
+package writer
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/logger"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// hexColorRe matches a #RRGGBB color.
+var hexColorRe = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// CreateList creates a new reminder list. color is optional.
+func (w *Writer) CreateList(name, color string) (map[string]interface{}, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errResult(fmt.Errorf("list name must not be empty")), nil
+	}
+	if w.Sync.FindListByName(name) != "" {
+		return errResult(fmt.Errorf("list '%s' already exists", name)), nil
+	}
+
+	ownerID, err := w.ownerID()
+	if err != nil {
+		return errResult(err), nil
+	}
+
+	fields := map[string]interface{}{
+		"Name": map[string]interface{}{"value": name},
+	}
+	colorName := ""
+	if color != "" {
+		encoded, normalized, err := encodeListColor(color)
+		if err != nil {
+			return errResult(err), nil
+		}
+		fields["Color"] = map[string]interface{}{"value": encoded}
+		colorName = normalized
+	}
+
+	recordName := "List/" + utils.NewUUIDString()
+	op := map[string]interface{}{
+		"operationType": "create",
+		"record": map[string]interface{}{
+			"recordType": "ReminderList",
+			"recordName": recordName,
+			"fields":     fields,
+		},
+	}
+
+	logger.Debugf("lists create: creating record %s", recordName)
+	result, err := w.CK.ModifyRecords(ownerID, []map[string]interface{}{op})
+	if err != nil {
+		return errResult(err), nil
+	}
+	if err := checkRecordErrors(result); err != nil {
+		return errResult(err), nil
+	}
+	if _, hasErr := result["error"]; hasErr {
+		return result, nil
+	}
+
+	w.Sync.Cache.Lists[recordName] = name
+	if ct := changeTagFor(result, recordName); ct != "" {
+		w.Sync.Cache.ListChangeTags[recordName] = ct
+	}
+	if colorName != "" {
+		w.Sync.Cache.ListColors[recordName] = colorName
+	}
+	if err := w.Sync.Cache.Save(); err != nil {
+		logger.Warnf("cache save failed: %v", err)
+	}
+	logger.Infof("Created list: %q", name)
+	result["list_id"] = recordName
+	return result, nil
+}
+
+// UpdateList renames and/or recolors a list. Pass empty values for fields
+// that should stay unchanged.
+func (w *Writer) UpdateList(nameOrID, newName, color string) (map[string]interface{}, error) {
+	ownerID, err := w.ownerID()
+	if err != nil {
+		return errResult(err), nil
+	}
+
+	listID := w.Sync.FindList(nameOrID)
+	if listID == "" {
+		return errResult(fmt.Errorf("list '%s' not found", nameOrID)), nil
+	}
+	changeTag := w.Sync.Cache.ListChangeTags[listID]
+	if changeTag == "" {
+		return errResult(fmt.Errorf("missing change tag for list '%s' — try running 'sync' first", nameOrID)), nil
+	}
+
+	newName = strings.TrimSpace(newName)
+	if newName == "" && color == "" {
+		return errResult(fmt.Errorf("no changes specified — give a new name or color")), nil
+	}
+	if newName != "" {
+		if other := w.Sync.FindListByName(newName); other != "" && other != listID {
+			return errResult(fmt.Errorf("list '%s' already exists", newName)), nil
+		}
+	}
+
+	fields := map[string]interface{}{}
+	if newName != "" {
+		fields["Name"] = map[string]interface{}{"value": newName}
+	}
+	colorName := ""
+	if color != "" {
+		encoded, normalized, err := encodeListColor(color)
+		if err != nil {
+			return errResult(err), nil
+		}
+		fields["Color"] = map[string]interface{}{"value": encoded}
+		colorName = normalized
+	}
+
+	op := map[string]interface{}{
+		"operationType": "update",
+		"record": map[string]interface{}{
+			"recordType":      "ReminderList",
+			"recordName":      listID,
+			"recordChangeTag": changeTag,
+			"fields":          fields,
+		},
+	}
+
+	logger.Debugf("lists update: updating record %s", listID)
+	result, err := w.CK.ModifyRecords(ownerID, []map[string]interface{}{op})
+	if err != nil {
+		return errResult(err), nil
+	}
+	if err := checkRecordErrors(result); err != nil {
+		return errResult(err), nil
+	}
+	if _, hasErr := result["error"]; hasErr {
+		return result, nil
+	}
+
+	oldName := w.Sync.Cache.Lists[listID]
+	if newName != "" {
+		w.Sync.Cache.Lists[listID] = newName
+	}
+	if colorName != "" {
+		w.Sync.Cache.ListColors[listID] = colorName
+	}
+	if ct := changeTagFor(result, listID); ct != "" {
+		w.Sync.Cache.ListChangeTags[listID] = ct
+	}
+	if err := w.Sync.Cache.Save(); err != nil {
+		logger.Warnf("cache save failed: %v", err)
+	}
+	logger.Infof("Updated list: %q → %q", oldName, w.Sync.Cache.Lists[listID])
+	return result, nil
+}
+
+// DeleteList deletes a list. Reminders in it (including completed ones) are
+// moved to moveTo when given; otherwise they are deleted with the list, which
+// requires force when the list is not empty.
+func (w *Writer) DeleteList(nameOrID, moveTo string, force bool) (map[string]interface{}, error) {
+	ownerID, err := w.ownerID()
+	if err != nil {
+		return errResult(err), nil
+	}
+
+	listID := w.Sync.FindList(nameOrID)
+	if listID == "" {
+		return errResult(fmt.Errorf("list '%s' not found", nameOrID)), nil
+	}
+	changeTag := w.Sync.Cache.ListChangeTags[listID]
+	if changeTag == "" {
+		return errResult(fmt.Errorf("missing change tag for list '%s' — try running 'sync' first", nameOrID)), nil
+	}
+
+	targetID := ""
+	if moveTo != "" {
+		targetID = w.Sync.FindList(moveTo)
+		if targetID == "" {
+			return errResult(fmt.Errorf("list '%s' not found", moveTo)), nil
+		}
+		if targetID == listID {
+			return errResult(fmt.Errorf("cannot move reminders into the list being deleted")), nil
+		}
+	}
+
+	var members []string
+	for rid, rd := range w.Sync.Cache.Reminders {
+		if rd.ListRef != nil && *rd.ListRef == listID {
+			members = append(members, rid)
+		}
+	}
+	if len(members) > 0 && targetID == "" && !force {
+		return errResult(fmt.Errorf("list '%s' contains %d reminder(s) — use --move-to <list> or --force to delete them", nameOrID, len(members))), nil
+	}
+
+	name := w.Sync.Cache.Lists[listID]
+	var changes []*change
+	for _, rid := range members {
+		rd := w.Sync.Cache.Reminders[rid]
+		if rd.ChangeTag == nil || *rd.ChangeTag == "" {
+			return errResult(fmt.Errorf("missing change tag for reminder '%s' — try running 'sync' first", rid)), nil
+		}
+		changes = append(changes, w.listMemberChange(rid, rd, targetID))
+	}
+
+	// The members are moved or deleted first, in chunks, and the list only
+	// once all of them have succeeded: a failed chunk leaves the list in
+	// place rather than leaving its reminders without one.
+	done := 0
+	var failed error
+	for _, chunk := range chunkChanges(changes, BulkChunkSize) {
+		logger.Debugf("lists delete: sending %d reminder(s) in one request", len(chunk))
+		for _, err := range w.sendChunk(ownerID, chunk, true) {
+			if err == nil {
+				done++
+			} else if failed == nil {
+				failed = err
+			}
+		}
+	}
+	if len(changes) > 0 {
+		if err := w.Sync.Cache.Save(); err != nil {
+			logger.Warnf("cache save failed: %v", err)
+		}
+	}
+	if failed != nil {
+		verb := "deleted"
+		if targetID != "" {
+			verb = "moved"
+		}
+		return errResult(fmt.Errorf("list '%s' not deleted: %d of %d reminder(s) could not be %s: %v", name, len(members)-done, len(members), verb, failed)), nil
+	}
+
+	logger.Debugf("lists delete: removing record %s", listID)
+	result, err := w.CK.ModifyRecords(ownerID, []map[string]interface{}{{
+		"operationType": "delete",
+		"record": map[string]interface{}{
+			"recordName":      listID,
+			"recordChangeTag": changeTag,
+		},
+	}})
+	if err != nil {
+		return errResult(err), nil
+	}
+	if err := checkRecordErrors(result); err != nil {
+		return errResult(err), nil
+	}
+	if _, hasErr := result["error"]; hasErr {
+		return result, nil
+	}
+
+	delete(w.Sync.Cache.Lists, listID)
+	delete(w.Sync.Cache.ListChangeTags, listID)
+	delete(w.Sync.Cache.ListColors, listID)
+	if err := w.Sync.Cache.Save(); err != nil {
+		logger.Warnf("cache save failed: %v", err)
+	}
+	result["reminder_ids"] = members
+	if targetID != "" {
+		logger.Infof("Deleted list %q; moved %d reminder(s) to %q", name, len(members), w.Sync.Cache.Lists[targetID])
+		result["moved_count"] = len(members)
+	} else {
+		logger.Infof("Deleted list %q and %d reminder(s)", name, len(members))
+		result["deleted_count"] = len(members)
+	}
+	return result, nil
+}
+
+// listMemberChange plans moving reminder rid to the list targetID or, when
+// targetID is empty, deleting it together with its repeat rule.
+func (w *Writer) listMemberChange(rid string, rd *cache.ReminderData, targetID string) *change {
+	if targetID != "" {
+		return &change{
+			id: rid,
+			ops: []map[string]interface{}{{
+				"operationType": "update",
+				"record": map[string]interface{}{
+					"recordType":      "Reminder",
+					"recordName":      rid,
+					"recordChangeTag": *rd.ChangeTag,
+					"fields": map[string]interface{}{
+						"List": map[string]interface{}{
+							"value": map[string]interface{}{
+								"recordName": targetID,
+								"action":     "NONE",
+							},
+						},
+					},
+				},
+			}},
+			apply: func(result map[string]interface{}) {
+				now := time.Now().UnixMilli()
+				target := targetID
+				rd.ListRef = &target
+				rd.ModifiedTS = &now
+				if ct := changeTagFor(result, rid); ct != "" {
+					rd.ChangeTag = &ct
+				}
+			},
+		}
+	}
+	ops := []map[string]interface{}{{
+		"operationType": "delete",
+		"record": map[string]interface{}{
+			"recordName":      rid,
+			"recordChangeTag": *rd.ChangeTag,
+		},
+	}}
+	if rule := rd.Recurrence; rule != nil && rule.ChangeTag != nil {
+		ops = append(ops, map[string]interface{}{
+			"operationType": "delete",
+			"record": map[string]interface{}{
+				"recordName":      rule.RuleID,
+				"recordChangeTag": *rule.ChangeTag,
+			},
+		})
+	}
+	return &change{
+		id:  rid,
+		ops: ops,
+		apply: func(map[string]interface{}) {
+			delete(w.Sync.Cache.Reminders, rid)
+		},
+	}
+}
+
+// encodeListColor converts a symbolic color name or #RRGGBB value into the
+// JSON document stored in a List record's Color field. It also returns the
+// normalized name kept in the cache.
+func encodeListColor(color string) (string, string, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	doc := map[string]interface{}{"daVersion": 2, "colorSpace": "1"}
+	normalized := color
+	if hex, ok := models.ListColors[color]; ok {
+		doc["ckSymbolicColorName"] = color
+		doc["daHexString"] = hex
+	} else if hexColorRe.MatchString(color) {
+		normalized = strings.ToUpper(color)
+		doc["ckSymbolicColorName"] = "custom"
+		doc["daHexString"] = normalized
+	} else {
+		return "", "", fmt.Errorf("invalid color %q (use red, orange, yellow, green, blue, purple, brown or #RRGGBB)", color)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", "", err
+	}
+	return string(data), normalized, nil
+}
@@ -0,0 +1,187 @@
+package writer_test
+
+import (
+	"strings"
+	"testing"
+
+	"icloud-reminders/internal/cloudkit/cktest"
+)
+
+// listOf returns the record name of the list a reminder is in on the server.
+func listOf(srv *cktest.Server, recordName string) string {
+	ref, _ := srv.Fields(recordName)["List"].(map[string]interface{})["value"].(map[string]interface{})
+	name, _ := ref["recordName"].(string)
+	return name
+}
+
+func stringField(srv *cktest.Server, recordName, field string) string {
+	s, _ := srv.Fields(recordName)[field].(map[string]interface{})["value"].(string)
+	return s
+}
+
+func TestCreateList(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	srv.AddList("Work")
+	w := setup(t, srv)
+
+	result, err := w.CreateList("Errands", "green")
+	if err != nil || result["error"] != nil {
+		t.Fatalf("CreateList = %v, %v", result, err)
+	}
+	id, _ := result["list_id"].(string)
+	// Same record type as lists made by the Reminders app.
+	found := false
+	for _, name := range srv.RecordNames("ReminderList") {
+		found = found || name == id
+	}
+	if !found {
+		t.Fatalf("%s is not a ReminderList record on the server", id)
+	}
+	if got := stringField(srv, id, "Name"); got != "Errands" {
+		t.Errorf("server name = %q", got)
+	}
+	if got := stringField(srv, id, "Color"); !strings.Contains(got, `"ckSymbolicColorName":"green"`) {
+		t.Errorf("server color = %q", got)
+	}
+	if w.Sync.Cache.Lists[id] != "Errands" || w.Sync.Cache.ListColors[id] != "green" {
+		t.Errorf("cached %q/%q", w.Sync.Cache.Lists[id], w.Sync.Cache.ListColors[id])
+	}
+	if got := w.Sync.Cache.ListChangeTags[id]; got != srv.ChangeTag(id) {
+		t.Errorf("cached change tag = %q, want %q", got, srv.ChangeTag(id))
+	}
+
+	// A sync reads it back unchanged.
+	if err := w.Sync.Sync(true); err != nil {
+		t.Fatal(err)
+	}
+	if w.Sync.Cache.Lists[id] != "Errands" || w.Sync.Cache.ListColors[id] != "green" {
+		t.Errorf("after sync %q/%q", w.Sync.Cache.Lists[id], w.Sync.Cache.ListColors[id])
+	}
+
+	for _, tc := range []struct{ name, color, want string }{
+		{"work", "", "already exists"},
+		{"  ", "", "must not be empty"},
+		{"Other", "mauve", "invalid color"},
+	} {
+		result, _ := w.CreateList(tc.name, tc.color)
+		if msg, _ := result["error"].(string); !strings.Contains(msg, tc.want) {
+			t.Errorf("CreateList(%q, %q) error = %q, want %q", tc.name, tc.color, msg, tc.want)
+		}
+	}
+}
+
+func TestUpdateList(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	id := srv.AddList("Work")
+	srv.AddList("Home")
+	w := setup(t, srv)
+
+	if result, err := w.UpdateList("Work", "Office", ""); err != nil || result["error"] != nil {
+		t.Fatalf("rename = %v, %v", result, err)
+	}
+	if got := stringField(srv, id, "Name"); got != "Office" {
+		t.Errorf("server name = %q", got)
+	}
+	// The cached change tag is current, so a second change needs no sync.
+	if result, err := w.UpdateList("Office", "", "#1a2B3c"); err != nil || result["error"] != nil {
+		t.Fatalf("recolor = %v, %v", result, err)
+	}
+	if got := stringField(srv, id, "Color"); !strings.Contains(got, `"daHexString":"#1A2B3C"`) {
+		t.Errorf("server color = %q", got)
+	}
+	if got := stringField(srv, id, "Name"); got != "Office" {
+		t.Errorf("recolor changed the name to %q", got)
+	}
+	if w.Sync.Cache.Lists[id] != "Office" || w.Sync.Cache.ListColors[id] != "#1A2B3C" {
+		t.Errorf("cached %q/%q", w.Sync.Cache.Lists[id], w.Sync.Cache.ListColors[id])
+	}
+
+	result, _ := w.UpdateList("Office", "home", "")
+	if msg, _ := result["error"].(string); !strings.Contains(msg, "already exists") {
+		t.Errorf("rename onto Home: error = %q", msg)
+	}
+
+	// Another device renames the list; the stale write is refused.
+	srv.Put(id, "ReminderList", map[string]interface{}{"Name": map[string]interface{}{"value": "Elsewhere"}})
+	result, _ = w.UpdateList("Office", "Mine", "")
+	if msg, _ := result["error"].(string); !strings.Contains(msg, "CONFLICT") {
+		t.Errorf("stale rename: error = %q, want a CONFLICT", msg)
+	}
+	if got := stringField(srv, id, "Name"); got != "Elsewhere" {
+		t.Errorf("stale rename applied: %q", got)
+	}
+}
+
+func TestDeleteListMovesMembers(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	old := srv.AddList("Old")
+	inbox := srv.AddList("Inbox")
+	ids := []string{srv.AddReminder("Milk", old), srv.AddReminder("Eggs", old)}
+	other := srv.AddReminder("Bread", inbox)
+	w := setup(t, srv)
+
+	result, _ := w.DeleteList("Old", "", false)
+	if msg, _ := result["error"].(string); !strings.Contains(msg, "contains 2 reminder(s)") {
+		t.Fatalf("delete without --move-to/--force: error = %q", msg)
+	}
+	if srv.Fields(old) == nil {
+		t.Fatal("non-empty list deleted without --force")
+	}
+
+	if result, err := w.DeleteList("Old", "Inbox", false); err != nil || result["error"] != nil {
+		t.Fatalf("DeleteList = %v, %v", result, err)
+	}
+	if srv.Fields(old) != nil {
+		t.Error("list not deleted on the server")
+	}
+	for _, id := range append(ids, other) {
+		if got := listOf(srv, id); got != inbox {
+			t.Errorf("%s is in %s, want Inbox", id, got)
+		}
+		if rd := w.Sync.Cache.Reminders[id]; rd == nil || rd.ListRef == nil || *rd.ListRef != inbox {
+			t.Errorf("%s not cached in Inbox", id)
+		}
+	}
+	if _, ok := w.Sync.Cache.Lists[old]; ok {
+		t.Error("deleted list still cached")
+	}
+}
+
+func TestDeleteListForced(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	old := srv.AddList("Old")
+	ids := []string{srv.AddReminder("Milk", old), srv.AddReminder("Eggs", old)}
+	keep := srv.AddReminder("Bread", srv.AddList("Inbox"))
+	w := setup(t, srv)
+
+	if result, err := w.DeleteList("Old", "", true); err != nil || result["error"] != nil {
+		t.Fatalf("DeleteList = %v, %v", result, err)
+	}
+	if srv.Fields(old) != nil {
+		t.Error("list not deleted on the server")
+	}
+	for _, id := range ids {
+		if srv.Fields(id) != nil {
+			t.Errorf("member %s not deleted", id)
+		}
+		if w.Sync.Cache.Reminders[id] != nil {
+			t.Errorf("member %s still cached", id)
+		}
+	}
+	if srv.Fields(keep) == nil {
+		t.Error("reminder of another list deleted")
+	}
+
+	// An empty list needs no --force.
+	srv.AddList("Empty")
+	if err := w.Sync.Sync(false); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := w.DeleteList("Empty", "", false); err != nil || result["error"] != nil {
+		t.Fatalf("DeleteList(Empty) = %v, %v", result, err)
+	}
+}
@@ -0,0 +1,99 @@
+package writer
+
+import (
+	"fmt"
+	"time"
+
+	"icloud-reminders/internal/logger"
+)
+
+// MoveReminder moves a reminder and all of its subtasks to another list in a
+// single atomic request. A subtask that is moved on its own is detached from
+// its parent, since parent and child must share a list. The moved record
+// names are returned under "reminder_ids".
+func (w *Writer) MoveReminder(reminderID, listName string) (map[string]interface{}, error) {
+	ownerID, err := w.ownerID()
+	if err != nil {
+		return errResult(err), nil
+	}
+
+	fullID := w.Sync.FindReminderByID(reminderID)
+	if fullID == "" {
+		return errResult(fmt.Errorf("reminder '%s' not found", reminderID)), nil
+	}
+	listID := w.Sync.FindList(listName)
+	if listID == "" {
+		return errResult(fmt.Errorf("list '%s' not found", listName)), nil
+	}
+
+	rd := w.Sync.Cache.Reminders[fullID]
+	detach := rd.ParentRef != nil && *rd.ParentRef != ""
+	ids := append([]string{fullID}, w.Sync.Descendants(fullID)...)
+
+	var ops []map[string]interface{}
+	for _, id := range ids {
+		d := w.Sync.Cache.Reminders[id]
+		if d == nil || d.ChangeTag == nil || *d.ChangeTag == "" {
+			return errResult(fmt.Errorf("missing change tag for '%s' — try running 'sync' first", id)), nil
+		}
+		if d.ListRef != nil && *d.ListRef == listID && !(id == fullID && detach) {
+			continue
+		}
+		fields := map[string]interface{}{
+			"List": map[string]interface{}{
+				"value": map[string]interface{}{
+					"recordName": listID,
+					"action":     "NONE",
+				},
+			},
+		}
+		if id == fullID && detach {
+			fields["ParentReminder"] = map[string]interface{}{"value": nil}
+		}
+		ops = append(ops, map[string]interface{}{
+			"operationType": "update",
+			"record": map[string]interface{}{
+				"recordType":      "Reminder",
+				"recordName":      id,
+				"recordChangeTag": *d.ChangeTag,
+				"fields":          fields,
+			},
+		})
+	}
+	if len(ops) == 0 {
+		return errResult(fmt.Errorf("reminder '%s' is already in list '%s'", reminderID, w.Sync.Cache.Lists[listID])), nil
+	}
+
+	logger.Debugf("move: updating %d record(s) to list %s", len(ops), listID)
+	result, err := w.CK.ModifyRecords(ownerID, ops)
+	if err != nil {
+		return errResult(err), nil
+	}
+	if err := checkRecordErrors(result); err != nil {
+		return errResult(err), nil
+	}
+	if _, hasErr := result["error"]; hasErr {
+		return result, nil
+	}
+
+	now := time.Now().UnixMilli()
+	for _, id := range ids {
+		d := w.Sync.Cache.Reminders[id]
+		target := listID
+		d.ListRef = &target
+		d.ModifiedTS = &now
+		if ct := changeTagFor(result, id); ct != "" {
+			d.ChangeTag = &ct
+		}
+	}
+	if detach {
+		rd.ParentRef = nil
+	}
+	if err := w.Sync.Cache.Save(); err != nil {
+		logger.Warnf("cache save failed: %v", err)
+	}
+	logger.Infof("Moved reminder: %q (%d subtask(s)) → %q", rd.Title, len(ids)-1, w.Sync.Cache.Lists[listID])
+	result["moved_count"] = len(ids)
+	result["reminder_ids"] = ids
+	return result, nil
+}
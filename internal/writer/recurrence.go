@@ -0,0 +1,223 @@
+package writer
+
+import (
+	"fmt"
+	"time"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/logger"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
+)
+
+// parseRepeat validates a --repeat / --repeat-until pair.
+// Returns nil when no repeat was requested or repeat is "none".
+func parseRepeat(repeat, repeatUntil string) (*models.Recurrence, error) {
+	if repeat == "" {
+		if repeatUntil != "" {
+			return nil, fmt.Errorf("--repeat-until requires --repeat")
+		}
+		return nil, nil
+	}
+	until, err := parseUntil(repeatUntil)
+	if err != nil {
+		return nil, err
+	}
+	return models.ParseRecurrence(repeat, until)
+}
+
+// parseUntil normalizes a repeat end date to YYYY-MM-DD.
+func parseUntil(repeatUntil string) (string, error) {
+	if repeatUntil == "" {
+		return "", nil
+	}
+	return utils.ParseDate(repeatUntil)
+}
+
+// buildRuleOp builds a create (ruleID == "") or update operation for the
+// RecurrenceRule record attached to reminderID. Returns the rule record name.
+func buildRuleOp(reminderID, ruleID string, changeTag *string, rule *models.Recurrence) (map[string]interface{}, string) {
+	fields := map[string]interface{}{
+		"Reminder": map[string]interface{}{
+			"value": map[string]interface{}{
+				"recordName": reminderID,
+				"action":     "NONE",
+			},
+		},
+		"Frequency": map[string]interface{}{"value": models.FrequencyMap[rule.Frequency]},
+		"Interval":  map[string]interface{}{"value": rule.Interval},
+	}
+	if rule.Until != nil {
+		if ts, err := utils.StrToTs(*rule.Until); err == nil {
+			fields["EndDate"] = map[string]interface{}{"value": ts}
+		}
+	} else if ruleID != "" {
+		fields["EndDate"] = map[string]interface{}{"value": nil}
+	}
+
+	record := map[string]interface{}{
+		"recordType": "RecurrenceRule",
+		"fields":     fields,
+	}
+	opType := "update"
+	if ruleID == "" {
+		opType = "create"
+		ruleID = utils.NewUUIDString()
+	} else if changeTag != nil {
+		record["recordChangeTag"] = *changeTag
+	}
+	record["recordName"] = ruleID
+	return map[string]interface{}{"operationType": opType, "record": record}, ruleID
+}
+
+// editRuleOp works out the RecurrenceRule operation for an edit. It returns
+// a nil op when the rule is unchanged, and a nil rule when it is removed.
+func editRuleOp(reminderID string, current *cache.RecurrenceData, repeat, repeatUntil string, hasDue bool) (map[string]interface{}, *models.Recurrence, error) {
+	if repeat == "" && repeatUntil == "" {
+		return nil, nil, nil
+	}
+	repeatUntil, err := parseUntil(repeatUntil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var rule *models.Recurrence
+	switch {
+	case repeat == "":
+		// Only the end date changes.
+		if current == nil {
+			return nil, nil, fmt.Errorf("reminder does not repeat — use --repeat to add a rule")
+		}
+		rule, err = models.ParseRecurrence(current.Frequency, repeatUntil)
+		if err != nil {
+			return nil, nil, err
+		}
+		rule.Interval = current.Interval
+	default:
+		rule, err = models.ParseRecurrence(repeat, repeatUntil)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if rule == nil {
+		if current == nil {
+			return nil, nil, fmt.Errorf("reminder does not repeat")
+		}
+		op := map[string]interface{}{
+			"operationType": "delete",
+			"record": map[string]interface{}{
+				"recordName": current.RuleID,
+			},
+		}
+		if current.ChangeTag != nil {
+			op["record"].(map[string]interface{})["recordChangeTag"] = *current.ChangeTag
+		}
+		return op, nil, nil
+	}
+
+	if !hasDue {
+		return nil, nil, fmt.Errorf("a repeating reminder needs a due date — use --due")
+	}
+	if current == nil {
+		op, _ := buildRuleOp(reminderID, "", nil, rule)
+		return op, rule, nil
+	}
+	if current.ChangeTag == nil {
+		return nil, nil, fmt.Errorf("missing change tag for recurrence rule — try running 'sync' first")
+	}
+	op, _ := buildRuleOp(reminderID, current.RuleID, current.ChangeTag, rule)
+	return op, rule, nil
+}
+
+// nextOccurrence returns the next due date for an open repeating reminder.
+func nextOccurrence(rd *cache.ReminderData) (string, bool) {
+	if rd.Completed || rd.Recurrence == nil || rd.Due == nil || *rd.Due == "" {
+		return "", false
+	}
+	rule := &models.Recurrence{
+		Frequency: rd.Recurrence.Frequency,
+		Interval:  rd.Recurrence.Interval,
+		Until:     rd.Recurrence.Until,
+	}
+	loc := utils.Location
+	if rd.TimeZone != nil {
+		if l, err := time.LoadLocation(*rd.TimeZone); err == nil {
+			loc = l
+		}
+	}
+	return rule.Next(*rd.Due, loc)
+}
+
+// rollChange plans advancing a repeating reminder to its next occurrence.
+// The result carries the new due date under "next_due".
+func rollChange(fullID string, rd *cache.ReminderData, nextDue string) (*change, error) {
+	ts, _, err := utils.ParseDue(nextDue)
+	if err != nil {
+		return nil, err
+	}
+	op := map[string]interface{}{
+		"operationType": "update",
+		"record": map[string]interface{}{
+			"recordType":      "Reminder",
+			"recordName":      fullID,
+			"recordChangeTag": *rd.ChangeTag,
+			"fields": map[string]interface{}{
+				"DueDate": map[string]interface{}{"value": ts},
+			},
+		},
+	}
+	return &change{
+		id:    fullID,
+		ops:   []map[string]interface{}{op},
+		extra: map[string]interface{}{"next_due": nextDue},
+		apply: func(result map[string]interface{}) {
+			rd.Due = &nextDue
+			if ct := changeTagFor(result, fullID); ct != "" {
+				rd.ChangeTag = &ct
+			}
+			now := time.Now().UnixMilli()
+			rd.ModifiedTS = &now
+			logger.Infof("Completed occurrence of %q; next due %s", rd.Title, nextDue)
+		},
+	}, nil
+}
+
+// ruleData converts a saved rule into its cache form, taking the change tag
+// from the modify response.
+func ruleData(ruleID string, rule *models.Recurrence, result map[string]interface{}) *cache.RecurrenceData {
+	rd := &cache.RecurrenceData{
+		RuleID:    ruleID,
+		Frequency: rule.Frequency,
+		Interval:  rule.Interval,
+		Until:     rule.Until,
+	}
+	if ct := changeTagFor(result, ruleID); ct != "" {
+		rd.ChangeTag = &ct
+	}
+	return rd
+}
+
+// opRecordName returns the record name targeted by a modify operation.
+func opRecordName(op map[string]interface{}) string {
+	rec, _ := op["record"].(map[string]interface{})
+	name, _ := rec["recordName"].(string)
+	return name
+}
+
+// changeTagFor returns the new recordChangeTag of recordName from a
+// records/modify response.
+func changeTagFor(result map[string]interface{}, recordName string) string {
+	records, _ := result["records"].([]interface{})
+	for _, r := range records {
+		rec, ok := r.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if name, _ := rec["recordName"].(string); name == recordName {
+			ct, _ := rec["recordChangeTag"].(string)
+			return ct
+		}
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package writer
+
+import (
+	"fmt"
+	"time"
+
+	"icloud-reminders/internal/logger"
+)
+
+// ReopenReminder marks a completed reminder as not completed and clears its
+// completion date. With recursive, completed subtasks are reopened as well,
+// in the same atomic request. The reopened record names are returned under
+// "reminder_ids".
+func (w *Writer) ReopenReminder(reminderID string, recursive bool) (map[string]interface{}, error) {
+	ownerID, err := w.ownerID()
+	if err != nil {
+		return errResult(err), nil
+	}
+
+	fullID := w.Sync.FindReminderByID(reminderID)
+	if fullID == "" {
+		return errResult(fmt.Errorf("reminder '%s' not found", reminderID)), nil
+	}
+
+	ids := []string{fullID}
+	if recursive {
+		ids = append(ids, w.Sync.Descendants(fullID)...)
+	}
+
+	var reopened []string
+	var ops []map[string]interface{}
+	for _, id := range ids {
+		rd := w.Sync.Cache.Reminders[id]
+		if rd == nil || !rd.Completed {
+			continue
+		}
+		if rd.ChangeTag == nil || *rd.ChangeTag == "" {
+			return errResult(fmt.Errorf("missing change tag for '%s' — try running 'sync' first", id)), nil
+		}
+		ops = append(ops, map[string]interface{}{
+			"operationType": "update",
+			"record": map[string]interface{}{
+				"recordType":      "Reminder",
+				"recordName":      id,
+				"recordChangeTag": *rd.ChangeTag,
+				"fields": map[string]interface{}{
+					"Completed":      map[string]interface{}{"value": false},
+					"CompletionDate": map[string]interface{}{"value": nil},
+				},
+			},
+		})
+		reopened = append(reopened, id)
+	}
+	if len(ops) == 0 {
+		return errResult(fmt.Errorf("reminder '%s' is not completed", reminderID)), nil
+	}
+
+	logger.Debugf("reopen: updating %d record(s)", len(ops))
+	result, err := w.CK.ModifyRecords(ownerID, ops)
+	if err != nil {
+		return errResult(err), nil
+	}
+	if err := checkRecordErrors(result); err != nil {
+		return errResult(err), nil
+	}
+	if _, hasErr := result["error"]; hasErr {
+		return result, nil
+	}
+
+	now := time.Now().UnixMilli()
+	for _, id := range reopened {
+		rd := w.Sync.Cache.Reminders[id]
+		rd.Completed = false
+		rd.CompletionDate = nil
+		rd.ModifiedTS = &now
+		if ct := changeTagFor(result, id); ct != "" {
+			rd.ChangeTag = &ct
+		}
+	}
+	if err := w.Sync.Cache.Save(); err != nil {
+		logger.Warnf("cache save failed: %v", err)
+	}
+	logger.Infof("Reopened %d reminder(s) (%s)", len(reopened), reminderID)
+	result["reopened_count"] = len(reopened)
+	result["reminder_ids"] = reopened
+	return result, nil
+}
@@ -8,19 +8,19 @@ import (
 	"icloud-reminders/internal/cache"
 	"icloud-reminders/internal/cloudkit"
 	"icloud-reminders/internal/logger"
-	"icloud-reminders/pkg/models"
 	"icloud-reminders/internal/sync"
 	"icloud-reminders/internal/utils"
+	"icloud-reminders/pkg/models"
 )
 
 // Writer handles creating and modifying reminders.
 type Writer struct {
-	CK   *cloudkit.Client
+	CK   cloudkit.API
 	Sync *sync.Engine
 }
 
 // New creates a new Writer.
-func New(ck *cloudkit.Client, engine *sync.Engine) *Writer {
+func New(ck cloudkit.API, engine *sync.Engine) *Writer {
 	return &Writer{CK: ck, Sync: engine}
 }
 
@@ -38,12 +38,22 @@ func (w *Writer) ownerID() (string, error) {
 }
 
 // AddReminder adds a single reminder.
-func (w *Writer) AddReminder(title, listName, dueDate, priority, notes, parentID string) (map[string]interface{}, error) {
+// repeat and repeatUntil are optional; see models.ParseRecurrence.
+// The new record name is returned under "reminder_id".
+func (w *Writer) AddReminder(title, listName, dueDate, priority, notes, parentID, repeat, repeatUntil string) (map[string]interface{}, error) {
 	ownerID, err := w.ownerID()
 	if err != nil {
 		return errResult(err), nil
 	}
 
+	rule, err := parseRepeat(repeat, repeatUntil)
+	if err != nil {
+		return errResult(err), nil
+	}
+	if rule != nil && dueDate == "" {
+		return errResult(fmt.Errorf("a repeating reminder needs a due date — use --due")), nil
+	}
+
 	listID := ""
 	if listName != "" {
 		listID = w.Sync.FindListByName(listName)
@@ -68,13 +78,21 @@ func (w *Writer) AddReminder(title, listName, dueDate, priority, notes, parentID
 
 	priorityVal := models.PriorityMap[priority]
 
-	op, recordName, err := buildCreateOp(title, listID, parentRef, dueDate, priorityVal, notes)
+	op, recordName, rd, err := buildCreateOp(title, listID, parentRef, dueDate, priorityVal, notes)
 	if err != nil {
 		return errResult(err), nil
 	}
 
+	ops := []map[string]interface{}{op}
+	ruleID := ""
+	if rule != nil {
+		var ruleOp map[string]interface{}
+		ruleOp, ruleID = buildRuleOp(recordName, "", nil, rule)
+		ops = append(ops, ruleOp)
+	}
+
 	logger.Debugf("add: creating record %s in list %s", recordName, listID)
-	result, err := w.CK.ModifyRecords(ownerID, []map[string]interface{}{op})
+	result, err := w.CK.ModifyRecords(ownerID, ops)
 	if err != nil {
 		return errResult(err), nil
 	}
@@ -82,25 +100,12 @@ func (w *Writer) AddReminder(title, listName, dueDate, priority, notes, parentID
 	if err := checkRecordErrors(result); err != nil {
 		return errResult(err), nil
 	}
+	if _, hasErr := result["error"]; hasErr {
+		return result, nil
+	}
 
 	logger.Infof("Created reminder: %q → %s", title, listName)
 	// Update cache
-	rd := &cache.ReminderData{
-		Title:    title,
-		Priority: priorityVal,
-	}
-	if dueDate != "" {
-		rd.Due = &dueDate
-	}
-	if notes != "" {
-		rd.Notes = &notes
-	}
-	if listID != "" {
-		rd.ListRef = &listID
-	}
-	if parentRef != "" {
-		rd.ParentRef = &parentRef
-	}
 	ts := time.Now().UnixMilli()
 	rd.ModifiedTS = &ts
 	// Extract recordChangeTag from response so the reminder can be
@@ -112,15 +117,20 @@ func (w *Writer) AddReminder(title, listName, dueDate, priority, notes, parentID
 			}
 		}
 	}
+	if rule != nil {
+		rd.Recurrence = ruleData(ruleID, rule, result)
+	}
 	w.Sync.Cache.Reminders[recordName] = rd
 	if err := w.Sync.Cache.Save(); err != nil {
 		logger.Warnf("cache save failed: %v", err)
 	}
 
+	result["reminder_id"] = recordName
 	return result, nil
 }
 
 // AddRemindersBatch adds multiple reminders in a single CloudKit request.
+// The new record names are returned under "reminder_ids", in title order.
 func (w *Writer) AddRemindersBatch(titles []string, listName, parentID string) (map[string]interface{}, error) {
 	if len(titles) == 0 {
 		return errResult(fmt.Errorf("no titles provided")), nil
@@ -155,17 +165,18 @@ func (w *Writer) AddRemindersBatch(titles []string, listName, parentID string) (
 	type created struct {
 		recordName string
 		title      string
+		rd         *cache.ReminderData
 	}
 	var ops []map[string]interface{}
 	var createdList []created
 
 	for _, title := range titles {
-		op, recordName, err := buildCreateOp(title, listID, parentRef, "", 0, "")
+		op, recordName, rd, err := buildCreateOp(title, listID, parentRef, "", 0, "")
 		if err != nil {
 			return errResult(err), nil
 		}
 		ops = append(ops, op)
-		createdList = append(createdList, created{recordName, title})
+		createdList = append(createdList, created{recordName, title, rd})
 	}
 
 	logger.Debugf("add-batch: creating %d records in list %s", len(ops), listID)
@@ -176,31 +187,31 @@ func (w *Writer) AddRemindersBatch(titles []string, listName, parentID string) (
 	if err := checkRecordErrors(result); err != nil {
 		return errResult(err), nil
 	}
+	if _, hasErr := result["error"]; hasErr {
+		return result, nil
+	}
 
 	logger.Infof("Created %d reminders in %q", len(createdList), listName)
 	now := time.Now().UnixMilli()
 	for _, c := range createdList {
-		rd := &cache.ReminderData{
-			Title:      c.title,
-			ModifiedTS: &now,
-		}
-		if listID != "" {
-			rd.ListRef = &listID
-		}
-		if parentRef != "" {
-			rd.ParentRef = &parentRef
+		rd := c.rd
+		rd.ModifiedTS = &now
+		if ct := changeTagFor(result, c.recordName); ct != "" {
+			rd.ChangeTag = &ct
 		}
 		w.Sync.Cache.Reminders[c.recordName] = rd
 	}
 	if err := w.Sync.Cache.Save(); err != nil {
 		logger.Warnf("cache save failed: %v", err)
 	}
-	var titleList []string
+	var titleList, idList []string
 	for _, c := range createdList {
 		titleList = append(titleList, c.title)
+		idList = append(idList, c.recordName)
 	}
 	result["created_count"] = len(createdList)
 	result["titles"] = titleList
+	result["reminder_ids"] = idList
 
 	return result, nil
 }
@@ -217,9 +228,24 @@ func (w *Writer) CompleteReminder(reminderID string) (map[string]interface{}, er
 		return errResult(fmt.Errorf("reminder '%s' not found", reminderID)), nil
 	}
 
+	c, err := w.completeChange(reminderID, fullID)
+	if err != nil {
+		return errResult(err), nil
+	}
+	return w.commit(ownerID, "complete", c)
+}
+
+// completeChange plans completing reminder fullID. Repeating reminders roll
+// forward to their next occurrence instead of being completed, like the
+// Reminders app does.
+func (w *Writer) completeChange(reminderID, fullID string) (*change, error) {
 	rd := w.Sync.Cache.Reminders[fullID]
 	if rd == nil || rd.ChangeTag == nil || *rd.ChangeTag == "" {
-		return errResult(fmt.Errorf("missing change tag for '%s' — try running 'sync' first", reminderID)), nil
+		return nil, fmt.Errorf("missing change tag for '%s' — try running 'sync' first", reminderID)
+	}
+
+	if nextDue, ok := nextOccurrence(rd); ok {
+		return rollChange(fullID, rd, nextDue)
 	}
 
 	now := time.Now().UnixMilli()
@@ -235,30 +261,19 @@ func (w *Writer) CompleteReminder(reminderID string) (map[string]interface{}, er
 			},
 		},
 	}
-
-	logger.Debugf("complete: updating record %s", fullID)
-	result, err := w.CK.ModifyRecords(ownerID, []map[string]interface{}{op})
-	if err != nil {
-		return errResult(err), nil
-	}
-	if _, hasErr := result["error"]; !hasErr {
-		rd.Completed = true
-		nowStr := utils.TsToStr(now)
-		rd.CompletionDate = &nowStr
-		// Update change tag from response
-		if records, ok := result["records"].([]interface{}); ok && len(records) > 0 {
-			if rec, ok := records[0].(map[string]interface{}); ok {
-				if ct, ok := rec["recordChangeTag"].(string); ok {
-					rd.ChangeTag = &ct
-				}
+	return &change{
+		id:  fullID,
+		ops: []map[string]interface{}{op},
+		apply: func(result map[string]interface{}) {
+			rd.Completed = true
+			nowStr := utils.TsToStr(now)
+			rd.CompletionDate = &nowStr
+			if ct := changeTagFor(result, fullID); ct != "" {
+				rd.ChangeTag = &ct
 			}
-		}
-		if err := w.Sync.Cache.Save(); err != nil {
-		logger.Warnf("cache save failed: %v", err)
-	}
-		logger.Infof("Completed reminder: %q (%s)", rd.Title, reminderID)
-	}
-	return result, nil
+			logger.Infof("Completed reminder: %q (%s)", rd.Title, reminderID)
+		},
+	}, nil
 }
 
 // DeleteReminder deletes a reminder.
@@ -273,41 +288,71 @@ func (w *Writer) DeleteReminder(reminderID string) (map[string]interface{}, erro
 		return errResult(fmt.Errorf("reminder '%s' not found", reminderID)), nil
 	}
 
+	c, err := w.deleteChange(reminderID, fullID)
+	if err != nil {
+		return errResult(err), nil
+	}
+	return w.commit(ownerID, "delete", c)
+}
+
+// deleteChange plans deleting reminder fullID together with its recurrence
+// rule, so no orphan rule is left behind.
+func (w *Writer) deleteChange(reminderID, fullID string) (*change, error) {
 	rd := w.Sync.Cache.Reminders[fullID]
 	if rd == nil || rd.ChangeTag == nil || *rd.ChangeTag == "" {
-		return errResult(fmt.Errorf("missing change tag for '%s' — try running 'sync' first", reminderID)), nil
+		return nil, fmt.Errorf("missing change tag for '%s' — try running 'sync' first", reminderID)
 	}
 
-	op := map[string]interface{}{
+	ops := []map[string]interface{}{{
 		"operationType": "delete",
 		"record": map[string]interface{}{
 			"recordName":      fullID,
 			"recordChangeTag": *rd.ChangeTag,
 		},
+	}}
+	if rule := rd.Recurrence; rule != nil && rule.ChangeTag != nil {
+		ops = append(ops, map[string]interface{}{
+			"operationType": "delete",
+			"record": map[string]interface{}{
+				"recordName":      rule.RuleID,
+				"recordChangeTag": *rule.ChangeTag,
+			},
+		})
 	}
 
-	title := ""
-	if rd != nil {
-		title = rd.Title
-	}
-	logger.Debugf("delete: removing record %s", fullID)
-	result, err := w.CK.ModifyRecords(ownerID, []map[string]interface{}{op})
-	if err != nil {
-		return errResult(err), nil
-	}
-	if _, hasErr := result["error"]; !hasErr {
-		delete(w.Sync.Cache.Reminders, fullID)
-		if err := w.Sync.Cache.Save(); err != nil {
-		logger.Warnf("cache save failed: %v", err)
-	}
-		logger.Infof("Deleted reminder: %q (%s)", title, reminderID)
-	}
-	return result, nil
+	return &change{
+		id:  fullID,
+		ops: ops,
+		apply: func(map[string]interface{}) {
+			delete(w.Sync.Cache.Reminders, fullID)
+			logger.Infof("Deleted reminder: %q (%s)", rd.Title, reminderID)
+		},
+	}, nil
+}
+
+// EditFields describes an edit. Empty strings leave a field unchanged;
+// Repeat "none" removes the reminder's recurrence rule and Parent "none"
+// promotes a subtask to a top-level reminder. ClearDue and ClearNotes
+// remove the due date and notes altogether.
+type EditFields struct {
+	Title       string
+	Due         string
+	Notes       string
+	Priority    string
+	Repeat      string
+	RepeatUntil string
+	Parent      string
+	ClearDue    bool
+	ClearNotes  bool
+}
+
+// empty reports whether the edit changes nothing.
+func (f EditFields) empty() bool {
+	return f == EditFields{}
 }
 
 // EditReminder updates one or more fields on an existing reminder.
-// Pass non-empty values only for fields you want to change.
-func (w *Writer) EditReminder(reminderID, title, dueDate, notes, priority string) (map[string]interface{}, error) {
+func (w *Writer) EditReminder(reminderID string, f EditFields) (map[string]interface{}, error) {
 	ownerID, err := w.ownerID()
 	if err != nil {
 		return errResult(err), nil
@@ -318,104 +363,217 @@ func (w *Writer) EditReminder(reminderID, title, dueDate, notes, priority string
 		return errResult(fmt.Errorf("reminder '%s' not found", reminderID)), nil
 	}
 
+	c, err := w.editChange(reminderID, fullID, f)
+	if err != nil {
+		return errResult(err), nil
+	}
+	return w.commit(ownerID, "edit", c)
+}
+
+// editChange plans applying f to reminder fullID.
+func (w *Writer) editChange(reminderID, fullID string, f EditFields) (*change, error) {
 	rd := w.Sync.Cache.Reminders[fullID]
 	if rd == nil || rd.ChangeTag == nil || *rd.ChangeTag == "" {
-		return errResult(fmt.Errorf("missing change tag for '%s' — try running 'sync' first", reminderID)), nil
+		return nil, fmt.Errorf("missing change tag for '%s' — try running 'sync' first", reminderID)
 	}
 
-	if title == "" && dueDate == "" && notes == "" && priority == "" {
-		return errResult(fmt.Errorf("no changes specified — use --title, --due, --notes, or --priority")), nil
+	if f.empty() {
+		return nil, fmt.Errorf("no changes specified — use --title, --due, --notes, --priority, --repeat, --parent, --clear-due or --clear-notes")
+	}
+	if f.ClearDue && f.Due != "" {
+		return nil, fmt.Errorf("cannot set and clear the due date at once")
+	}
+	if f.ClearNotes && f.Notes != "" {
+		return nil, fmt.Errorf("cannot set and clear notes at once")
+	}
+	if f.ClearDue && rd.Recurrence != nil && f.Repeat != "none" {
+		return nil, fmt.Errorf("a repeating reminder needs a due date — add --repeat none to stop it repeating")
 	}
 
 	fields := map[string]interface{}{}
 
-	if title != "" {
-		encoded, err := utils.EncodeTitle(title)
+	if f.Title != "" {
+		encoded, err := utils.EncodeTitle(f.Title)
 		if err != nil {
-			return errResult(fmt.Errorf("encode title: %w", err)), nil
+			return nil, fmt.Errorf("encode title: %w", err)
 		}
 		fields["TitleDocument"] = map[string]interface{}{"value": encoded}
 	}
 
-	if dueDate != "" {
-		ts, err := utils.StrToTs(dueDate)
-		if err == nil {
-			fields["DueDate"] = map[string]interface{}{"value": ts}
-		} else {
-			return errResult(fmt.Errorf("invalid due date %q (expected YYYY-MM-DD): %w", dueDate, err)), nil
+	var newDue string
+	var newTZ *string
+	if f.Due != "" {
+		dueF, due, tz, err := dueFields(f.Due)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range dueF {
+			fields[k] = v
 		}
+		// Switching a timed reminder to all-day drops its time zone.
+		if tz == nil && rd.TimeZone != nil {
+			fields["TimeZone"] = map[string]interface{}{"value": nil}
+		}
+		newDue, newTZ = due, tz
+	}
+	if f.ClearDue {
+		if rd.Due == nil || *rd.Due == "" {
+			return nil, fmt.Errorf("reminder has no due date")
+		}
+		fields["DueDate"] = map[string]interface{}{"value": nil}
+		fields["AllDay"] = map[string]interface{}{"value": nil}
+		fields["TimeZone"] = map[string]interface{}{"value": nil}
 	}
 
-	if notes != "" {
-		encodedNotes, err := utils.EncodeTitle(notes)
+	if f.Notes != "" {
+		encodedNotes, err := utils.EncodeTitle(f.Notes)
 		if err != nil {
-			return errResult(fmt.Errorf("encode notes: %w", err)), nil
+			return nil, fmt.Errorf("encode notes: %w", err)
 		}
 		fields["NotesDocument"] = map[string]interface{}{"value": encodedNotes}
 	}
+	if f.ClearNotes {
+		if rd.Notes == nil || *rd.Notes == "" {
+			return nil, fmt.Errorf("reminder has no notes")
+		}
+		fields["NotesDocument"] = map[string]interface{}{"value": nil}
+	}
 
-	if priority != "" {
-		priorityVal, ok := models.PriorityMap[priority]
+	if f.Priority != "" {
+		priorityVal, ok := models.PriorityMap[f.Priority]
 		if !ok {
-			return errResult(fmt.Errorf("invalid priority %q (use: high, medium, low, none)", priority)), nil
+			return nil, fmt.Errorf("invalid priority %q (use: high, medium, low, none)", f.Priority)
 		}
 		fields["Priority"] = map[string]interface{}{"value": priorityVal}
 	}
 
-	op := map[string]interface{}{
-		"operationType": "update",
-		"record": map[string]interface{}{
-			"recordType":      "Reminder",
-			"recordName":      fullID,
-			"recordChangeTag": *rd.ChangeTag,
-			"fields":          fields,
-		},
+	newParent := ""
+	if f.Parent != "" {
+		var err error
+		newParent, err = w.resolveNewParent(fullID, rd, f.Parent)
+		if err != nil {
+			return nil, err
+		}
+		if newParent == "" {
+			fields["ParentReminder"] = map[string]interface{}{"value": nil}
+		} else {
+			fields["ParentReminder"] = map[string]interface{}{
+				"value": map[string]interface{}{
+					"recordName": newParent,
+					"action":     "NONE",
+				},
+			}
+		}
+	}
+
+	var ops []map[string]interface{}
+	if len(fields) > 0 {
+		ops = append(ops, map[string]interface{}{
+			"operationType": "update",
+			"record": map[string]interface{}{
+				"recordType":      "Reminder",
+				"recordName":      fullID,
+				"recordChangeTag": *rd.ChangeTag,
+				"fields":          fields,
+			},
+		})
 	}
 
-	logger.Debugf("edit: updating record %s", fullID)
-	result, err := w.CK.ModifyRecords(ownerID, []map[string]interface{}{op})
+	hasDue := f.Due != "" || (!f.ClearDue && rd.Due != nil && *rd.Due != "")
+	ruleOp, rule, err := editRuleOp(fullID, rd.Recurrence, f.Repeat, f.RepeatUntil, hasDue)
 	if err != nil {
-		return errResult(err), nil
+		return nil, err
 	}
-	if err := checkRecordErrors(result); err != nil {
-		return errResult(err), nil
+	if ruleOp != nil {
+		ops = append(ops, ruleOp)
 	}
 
-	// Update local cache
-	if title != "" {
-		rd.Title = title
+	apply := func(result map[string]interface{}) {
+		if f.Title != "" {
+			rd.Title = f.Title
+		}
+		if f.Due != "" {
+			rd.Due = &newDue
+			rd.TimeZone = newTZ
+		}
+		if f.ClearDue {
+			rd.Due = nil
+			rd.TimeZone = nil
+		}
+		if f.Notes != "" {
+			notes := f.Notes
+			rd.Notes = &notes
+		}
+		if f.ClearNotes {
+			rd.Notes = nil
+		}
+		if f.Priority != "" {
+			rd.Priority = models.PriorityMap[f.Priority]
+		}
+		if f.Parent != "" {
+			if newParent == "" {
+				rd.ParentRef = nil
+			} else {
+				parentRef := newParent
+				rd.ParentRef = &parentRef
+			}
+		}
+		if ruleOp != nil {
+			if rule == nil {
+				rd.Recurrence = nil
+			} else {
+				rd.Recurrence = ruleData(opRecordName(ruleOp), rule, result)
+			}
+		}
+		if ct := changeTagFor(result, fullID); ct != "" {
+			rd.ChangeTag = &ct
+		}
+		now := time.Now().UnixMilli()
+		rd.ModifiedTS = &now
+		logger.Infof("Edited reminder: %q (%s)", rd.Title, reminderID)
 	}
-	if dueDate != "" {
-		rd.Due = &dueDate
+	return &change{id: fullID, ops: ops, apply: apply}, nil
+}
+
+// resolveNewParent validates a new parent for reminder fullID and returns its
+// record name ("" for parent "none"). The parent must be in the same list and
+// must not be the reminder itself or one of its subtasks.
+func (w *Writer) resolveNewParent(fullID string, rd *cache.ReminderData, parent string) (string, error) {
+	if parent == "none" {
+		if rd.ParentRef == nil || *rd.ParentRef == "" {
+			return "", fmt.Errorf("reminder is not a subtask")
+		}
+		return "", nil
 	}
-	if notes != "" {
-		rd.Notes = &notes
+	parentRef := w.Sync.FindReminderByID(parent)
+	if parentRef == "" {
+		return "", fmt.Errorf("parent reminder '%s' not found", parent)
 	}
-	if priority != "" {
-		rd.Priority = models.PriorityMap[priority]
+	if parentRef == fullID {
+		return "", fmt.Errorf("a reminder cannot be its own parent")
 	}
-	// Update change tag from response
-	if records, ok := result["records"].([]interface{}); ok && len(records) > 0 {
-		if rec, ok := records[0].(map[string]interface{}); ok {
-			if ct, ok := rec["recordChangeTag"].(string); ok && ct != "" {
-				rd.ChangeTag = &ct
-			}
+	for _, id := range w.Sync.Descendants(fullID) {
+		if id == parentRef {
+			return "", fmt.Errorf("parent '%s' is a subtask of this reminder", parent)
 		}
 	}
-	now := time.Now().UnixMilli()
-	rd.ModifiedTS = &now
-	if err := w.Sync.Cache.Save(); err != nil {
-		logger.Warnf("cache save failed: %v", err)
+	pd := w.Sync.Cache.Reminders[parentRef]
+	if pd == nil || pd.ListRef == nil || rd.ListRef == nil || *pd.ListRef != *rd.ListRef {
+		return "", fmt.Errorf("parent '%s' is in a different list — use 'move' first", parent)
 	}
-	logger.Infof("Edited reminder: %q (%s)", rd.Title, reminderID)
-	return result, nil
+	return parentRef, nil
 }
 
-// buildCreateOp builds a CloudKit create operation for a new reminder.
-func buildCreateOp(title, listID, parentRef, dueDate string, priority int, notes string) (map[string]interface{}, string, error) {
+// buildCreateOp builds a CloudKit create operation for a new reminder and
+// the cache entry the reminder will have once it is saved.
+func buildCreateOp(title, listID, parentRef, dueDate string, priority int, notes string) (map[string]interface{}, string, *cache.ReminderData, error) {
 	encoded, err := utils.EncodeTitle(title)
 	if err != nil {
-		return nil, "", fmt.Errorf("encode title: %w", err)
+		return nil, "", nil, fmt.Errorf("encode title: %w", err)
+	}
+	rd := &cache.ReminderData{
+		Title:    title,
+		Priority: priority,
 	}
 
 	recordName := utils.NewUUIDString()
@@ -431,6 +589,7 @@ func buildCreateOp(title, listID, parentRef, dueDate string, priority int, notes
 				"action":     "NONE",
 			},
 		}
+		rd.ListRef = &listID
 	}
 
 	if parentRef != "" {
@@ -440,13 +599,19 @@ func buildCreateOp(title, listID, parentRef, dueDate string, priority int, notes
 				"action":     "NONE",
 			},
 		}
+		rd.ParentRef = &parentRef
 	}
 
 	if dueDate != "" {
-		ts, err := utils.StrToTs(dueDate)
-		if err == nil {
-			fields["DueDate"] = map[string]interface{}{"value": ts}
+		dueF, due, tz, err := dueFields(dueDate)
+		if err != nil {
+			return nil, "", nil, err
 		}
+		for k, v := range dueF {
+			fields[k] = v
+		}
+		rd.Due = &due
+		rd.TimeZone = tz
 	}
 
 	if priority != 0 {
@@ -456,9 +621,10 @@ func buildCreateOp(title, listID, parentRef, dueDate string, priority int, notes
 	if notes != "" {
 		encodedNotes, err := utils.EncodeTitle(notes)
 		if err != nil {
-			return nil, "", fmt.Errorf("encode notes: %w", err)
+			return nil, "", nil, fmt.Errorf("encode notes: %w", err)
 		}
 		fields["NotesDocument"] = map[string]interface{}{"value": encodedNotes}
+		rd.Notes = &notes
 	}
 
 	op := map[string]interface{}{
@@ -469,7 +635,34 @@ func buildCreateOp(title, listID, parentRef, dueDate string, priority int, notes
 			"fields":     fields,
 		},
 	}
-	return op, recordName, nil
+	return op, recordName, rd, nil
+}
+
+// dueFields parses a due date (see utils.ParseDue) and returns the CloudKit
+// fields that store it, its cache form and the time zone of a timed date.
+func dueFields(dueDate string) (map[string]interface{}, string, *string, error) {
+	ts, allDay, err := utils.ParseDue(dueDate)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	fields := map[string]interface{}{
+		"DueDate": map[string]interface{}{"value": ts},
+		"AllDay":  map[string]interface{}{"value": 0},
+	}
+	if allDay {
+		fields["AllDay"] = map[string]interface{}{"value": 1}
+		return fields, utils.FormatDue(ts, true, ""), nil, nil
+	}
+	var tz *string
+	if name := utils.DueTimeZone(dueDate); name != "" {
+		fields["TimeZone"] = map[string]interface{}{"value": name}
+		tz = &name
+	}
+	tzName := ""
+	if tz != nil {
+		tzName = *tz
+	}
+	return fields, utils.FormatDue(ts, false, tzName), tz, nil
 }
 
 func errResult(err error) map[string]interface{} {
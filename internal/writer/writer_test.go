@@ -0,0 +1,304 @@
+package writer_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"icloud-reminders/internal/cache"
+	"icloud-reminders/internal/cloudkit"
+	"icloud-reminders/internal/cloudkit/cktest"
+	"icloud-reminders/internal/sync"
+	"icloud-reminders/internal/utils"
+	"icloud-reminders/internal/writer"
+)
+
+// setup returns a writer whose engine has synced srv. The cache file goes
+// to a temporary directory.
+func setup(t *testing.T, srv *cktest.Server) *writer.Writer {
+	t.Helper()
+	cache.CacheFile = filepath.Join(t.TempDir(), "ck_cache.json")
+	ck, err := cloudkit.NewFromSession(srv.Session())
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &sync.Engine{CK: ck, Cache: cache.NewCache()}
+	if err := e.Sync(false); err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	return writer.New(ck, e)
+}
+
+// touch changes a reminder on the server as another device would, so the
+// writer's cached change tag goes stale.
+func touch(srv *cktest.Server, recordName string) {
+	fields := srv.Fields(recordName)
+	fields["Priority"] = map[string]interface{}{"value": 1}
+	srv.Put(recordName, "Reminder", fields)
+}
+
+// shortID strips the "Reminder/" prefix cktest gives record names; the
+// writer looks reminders up by UUID.
+func shortID(recordName string) string {
+	return strings.TrimPrefix(recordName, "Reminder/")
+}
+
+func shortIDs(recordNames []string) []string {
+	ids := make([]string, len(recordNames))
+	for i, name := range recordNames {
+		ids[i] = shortID(name)
+	}
+	return ids
+}
+
+func completed(srv *cktest.Server, recordName string) bool {
+	f, _ := srv.Fields(recordName)["Completed"].(map[string]interface{})
+	return fmt.Sprint(f["value"]) == "1"
+}
+
+func TestAddReminderCachesChangeTag(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	srv.AddList("Work")
+	w := setup(t, srv)
+
+	result, err := w.AddReminder("Write report", "Work", "2026-10-20", "high", "", "", "", "")
+	if err != nil || result["error"] != nil {
+		t.Fatalf("AddReminder = %v, %v", result, err)
+	}
+	id, _ := result["reminder_id"].(string)
+	fields := srv.Fields(id)
+	if fields == nil {
+		t.Fatalf("reminder %s not created on the server", id)
+	}
+	doc, _ := fields["TitleDocument"].(map[string]interface{})["value"].(string)
+	if got := utils.ExtractTitle(doc); got != "Write report" {
+		t.Errorf("server title = %q, want Write report", got)
+	}
+	rd := w.Sync.Cache.Reminders[id]
+	if rd == nil || rd.ChangeTag == nil || *rd.ChangeTag != srv.ChangeTag(id) {
+		t.Fatalf("cached change tag does not match the server's %q", srv.ChangeTag(id))
+	}
+	if rd.Due == nil || *rd.Due != "2026-10-20" {
+		t.Errorf("cached due = %v, want 2026-10-20", rd.Due)
+	}
+
+	// The cached tag is current, so the new reminder can be changed
+	// without syncing first.
+	if result, err := w.CompleteReminder(id); err != nil || result["error"] != nil {
+		t.Fatalf("CompleteReminder = %v, %v", result, err)
+	}
+	if !completed(srv, id) {
+		t.Error("reminder not completed on the server")
+	}
+}
+
+func TestCompleteWithStaleChangeTagConflicts(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	id := srv.AddReminder("Milk", srv.AddList("Groceries"))
+	w := setup(t, srv)
+	touch(srv, id)
+
+	result, err := w.CompleteReminder(shortID(id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, _ := result["error"].(string)
+	if !strings.Contains(msg, "CONFLICT") {
+		t.Fatalf("error = %q, want a CONFLICT", msg)
+	}
+	if completed(srv, id) {
+		t.Error("stale write was applied")
+	}
+	if w.Sync.Cache.Reminders[id].Completed {
+		t.Error("cache marked completed after a rejected write")
+	}
+}
+
+func TestBulkResendsAroundConflict(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	listID := srv.AddList("Groceries")
+	var ids []string
+	for _, title := range []string{"Milk", "Eggs", "Bread"} {
+		ids = append(ids, srv.AddReminder(title, listID))
+	}
+	w := setup(t, srv)
+	touch(srv, ids[1])
+
+	result, err := w.CompleteReminders(shortIDs(ids))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["succeeded"] != 2 || result["failed"] != 1 {
+		t.Fatalf("succeeded/failed = %v/%v, want 2/1", result["succeeded"], result["failed"])
+	}
+	// One atomic request that fails on Eggs, then Milk and Bread resent.
+	if got := srv.Requests("records/modify"); got != 2 {
+		t.Errorf("records/modify requests = %d, want 2", got)
+	}
+	for i, id := range ids {
+		if want := i != 1; completed(srv, id) != want {
+			t.Errorf("%s completed = %v, want %v", id, !want, want)
+		}
+	}
+	entries, _ := result["results"].([]map[string]interface{})
+	for _, e := range entries {
+		if e["ok"] == false && !strings.Contains(fmt.Sprint(e["error"]), "CONFLICT") {
+			t.Errorf("failed entry error = %v, want a CONFLICT", e["error"])
+		}
+	}
+}
+
+func TestBulkChunksLargeWrites(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	listID := srv.AddList("Big")
+	var ids []string
+	for i := 0; i < writer.BulkChunkSize+50; i++ {
+		ids = append(ids, srv.AddReminder(fmt.Sprintf("item %d", i), listID))
+	}
+	w := setup(t, srv)
+
+	result, err := w.DeleteReminders(shortIDs(ids))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["succeeded"] != len(ids) {
+		t.Fatalf("succeeded = %v, want %d", result["succeeded"], len(ids))
+	}
+	if got := srv.Requests("records/modify"); got != 2 {
+		t.Errorf("records/modify requests = %d, want 2", got)
+	}
+	if got := len(srv.RecordNames("Reminder")); got != 0 {
+		t.Errorf("%d reminders left on the server", got)
+	}
+}
+
+func TestDeleteListKeepsListWhenAMemberFails(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	listID := srv.AddList("Old")
+	var ids []string
+	for i := 0; i < writer.BulkChunkSize+50; i++ {
+		ids = append(ids, srv.AddReminder(fmt.Sprintf("item %d", i), listID))
+	}
+	w := setup(t, srv)
+	touch(srv, ids[len(ids)-1])
+
+	result, err := w.DeleteList("Old", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, _ := result["error"].(string); !strings.Contains(msg, "not deleted") {
+		t.Fatalf("error = %q, want the list not deleted", msg)
+	}
+	if srv.Fields(listID) == nil {
+		t.Fatal("list deleted although a member failed")
+	}
+	if got := srv.RecordNames("Reminder"); len(got) != 1 || got[0] != ids[len(ids)-1] {
+		t.Errorf("reminders left = %v, want only the stale one", got)
+	}
+
+	// After a sync the tag is current and the delete goes through.
+	if err := w.Sync.Sync(false); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := w.DeleteList("Old", "", true); err != nil || result["error"] != nil {
+		t.Fatalf("DeleteList = %v, %v", result, err)
+	}
+	if srv.Fields(listID) != nil {
+		t.Error("list not deleted")
+	}
+}
+
+func TestWriteFailsOnServerError(t *testing.T) {
+	srv := cktest.NewServer()
+	defer srv.Close()
+	srv.AddList("Work")
+	w := setup(t, srv)
+
+	srv.FailNext(503)
+	result, err := w.AddReminder("Call Bob", "Work", "", "", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, _ := result["error"].(string); !strings.Contains(msg, "503") {
+		t.Fatalf("error = %q, want a 503", msg)
+	}
+	if got := len(srv.RecordNames("Reminder")); got != 0 {
+		t.Errorf("%d reminders created", got)
+	}
+	if got := len(w.Sync.Cache.Reminders); got != 0 {
+		t.Errorf("%d reminders cached", got)
+	}
+
+	// The failure was used up; the retry succeeds.
+	result, err = w.AddReminder("Call Bob", "Work", "", "", "", "", "", "")
+	if err != nil || result["error"] != nil {
+		t.Fatalf("AddReminder = %v, %v", result, err)
+	}
+	if got := len(srv.RecordNames("Reminder")); got != 1 {
+		t.Errorf("%d reminders on the server, want 1", got)
+	}
+}
+
+func TestEditReminderKeepsDue(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldLoc := utils.Location
+	utils.Location = loc
+	t.Cleanup(func() { utils.Location = oldLoc })
+
+	srv := cktest.NewServer()
+	defer srv.Close()
+	id := shortID(srv.AddReminder("Call Bob", srv.AddList("Work")))
+	w := setup(t, srv)
+	due := func() string {
+		if d := w.Sync.Cache.Reminders["Reminder/"+id].Due; d != nil {
+			return *d
+		}
+		return ""
+	}
+
+	for _, tc := range []struct{ in, want, zone string }{
+		{"2026-10-20", "2026-10-20", ""},
+		{"2026-10-20 09:00", "2026-10-20T09:00:00-04:00", "America/New_York"},
+		{"2026-12-20T09:00:00-05:00", "2026-12-20T09:00:00-05:00", "America/New_York"},
+		// An offset that is not New York's keeps its own zone.
+		{"2026-10-20T09:00:00+02:00", "2026-10-20T09:00:00+02:00", "Etc/GMT-2"},
+		{"2026-10-20T09:00:00Z", "2026-10-20T09:00:00Z", "UTC"},
+	} {
+		if result, err := w.EditReminder(id, writer.EditFields{Due: tc.in}); err != nil || result["error"] != nil {
+			t.Fatalf("EditReminder(due %q) = %v, %v", tc.in, result, err)
+		}
+		if got := due(); got != tc.want {
+			t.Errorf("due %q cached as %q, want %q", tc.in, got, tc.want)
+		}
+		if tc.zone != "" {
+			if got := stringField(srv, "Reminder/"+id, "TimeZone"); got != tc.zone {
+				t.Errorf("due %q: server TimeZone = %q, want %q", tc.in, got, tc.zone)
+			}
+		}
+
+		// Editing something else leaves the due date alone...
+		if result, err := w.EditReminder(id, writer.EditFields{Title: "Call Bob " + tc.in}); err != nil || result["error"] != nil {
+			t.Fatalf("EditReminder(title) = %v, %v", result, err)
+		}
+		if got := due(); got != tc.want {
+			t.Errorf("title edit changed due %q to %q", tc.want, got)
+		}
+		// ...and a full sync reads back the same string.
+		if err := w.Sync.Sync(true); err != nil {
+			t.Fatal(err)
+		}
+		if got := due(); got != tc.want {
+			t.Errorf("due %q reads back as %q after sync", tc.want, got)
+		}
+	}
+}
@@ -1,19 +1,29 @@
 // Package models contains data types for iCloud Reminders.
 package models
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Reminder represents a single iCloud Reminder.
 type Reminder struct {
-	ID             string  `json:"id"`
-	Title          string  `json:"title"`
-	Completed      bool    `json:"completed"`
-	CompletionDate *string `json:"completion_date,omitempty"`
-	Due            *string `json:"due,omitempty"`
-	Priority       int     `json:"priority"` // 0=none, 1=high, 5=medium, 9=low
-	Notes          *string `json:"notes,omitempty"`
-	ListRef        *string `json:"list_ref,omitempty"`
-	ListName       string  `json:"list_name"`
-	ParentRef      *string `json:"parent_ref,omitempty"`
-	ModifiedTS     *int64  `json:"modified_ts,omitempty"`
+	ID             string      `json:"id"`
+	Title          string      `json:"title"`
+	Completed      bool        `json:"completed"`
+	CompletionDate *string     `json:"completion_date,omitempty"`
+	Due            *string     `json:"due,omitempty"` // YYYY-MM-DD (all-day) or RFC 3339 (timed)
+	Priority       int         `json:"priority"`      // 0=none, 1=high, 5=medium, 9=low
+	Notes          *string     `json:"notes,omitempty"`
+	ListRef        *string     `json:"list_ref,omitempty"`
+	ListName       string      `json:"list_name"`
+	ParentRef      *string     `json:"parent_ref,omitempty"`
+	ModifiedTS     *int64      `json:"modified_ts,omitempty"`
+	Recurrence     *Recurrence `json:"recurrence,omitempty"`
+	TimeZone       *string     `json:"time_zone,omitempty"`  // IANA zone of a timed due date
+	ChangeTag      *string     `json:"change_tag,omitempty"` // CloudKit recordChangeTag
 }
 
 // PriorityLabel returns a human-readable priority string.
@@ -47,8 +57,20 @@ func (r *Reminder) ShortID() string {
 
 // ReminderList represents an iCloud Reminders list.
 type ReminderList struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Color string `json:"color,omitempty"` // symbolic name (e.g. "blue") or hex
+}
+
+// ListColors maps the Reminders app's symbolic list colors to hex values.
+var ListColors = map[string]string{
+	"red":    "#FF3B30",
+	"orange": "#FF9500",
+	"yellow": "#FFCC00",
+	"green":  "#34C759",
+	"blue":   "#007AFF",
+	"purple": "#AF52DE",
+	"brown":  "#A2845E",
 }
 
 // PriorityMap maps string priority names to CloudKit integer values.
@@ -58,3 +80,147 @@ var PriorityMap = map[string]int{
 	"low":    9,
 	"none":   0,
 }
+
+// Recurrence describes how a reminder repeats.
+type Recurrence struct {
+	Frequency string  `json:"frequency"` // daily, weekly, monthly, yearly
+	Interval  int     `json:"interval"`  // every N frequency units (>= 1)
+	Until     *string `json:"until,omitempty"`
+}
+
+// FrequencyMap maps recurrence frequency names to CloudKit integer values.
+var FrequencyMap = map[string]int{
+	"daily":   0,
+	"weekly":  1,
+	"monthly": 2,
+	"yearly":  3,
+}
+
+// frequencyUnits maps unit words accepted by ParseRecurrence to frequencies.
+var frequencyUnits = map[string]string{
+	"day": "daily", "days": "daily",
+	"week": "weekly", "weeks": "weekly",
+	"month": "monthly", "months": "monthly",
+	"year": "yearly", "years": "yearly",
+}
+
+// FrequencyName returns the frequency name for a CloudKit value.
+func FrequencyName(v int) string {
+	for name, n := range FrequencyMap {
+		if n == v {
+			return name
+		}
+	}
+	return ""
+}
+
+// ParseRecurrence parses a repeat spec such as "daily", "weekly",
+// "every 2 weeks" or "every 3 months". until is an optional YYYY-MM-DD end
+// date. It returns nil for "none".
+func ParseRecurrence(spec, until string) (*Recurrence, error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "none" {
+		return nil, nil
+	}
+	rec := &Recurrence{Interval: 1}
+	if _, ok := FrequencyMap[spec]; ok {
+		rec.Frequency = spec
+	} else {
+		parts := strings.Fields(spec)
+		if len(parts) == 2 && parts[0] == "every" {
+			parts = []string{"every", "1", parts[1]}
+		}
+		if len(parts) != 3 || parts[0] != "every" {
+			return nil, fmt.Errorf("invalid repeat %q (use: daily, weekly, monthly, yearly, \"every N days|weeks|months|years\", none)", spec)
+		}
+		n, err := strconv.Atoi(parts[1])
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid repeat interval %q", parts[1])
+		}
+		freq, ok := frequencyUnits[parts[2]]
+		if !ok {
+			return nil, fmt.Errorf("invalid repeat unit %q (use: days, weeks, months, years)", parts[2])
+		}
+		rec.Frequency = freq
+		rec.Interval = n
+	}
+	if until != "" {
+		if _, err := time.Parse("2006-01-02", until); err != nil {
+			return nil, fmt.Errorf("invalid repeat end date %q (expected YYYY-MM-DD)", until)
+		}
+		rec.Until = &until
+	}
+	return rec, nil
+}
+
+// String returns a short human-readable description, e.g. "every 2 weeks until 2026-12-31".
+func (r *Recurrence) String() string {
+	if r == nil {
+		return ""
+	}
+	var s string
+	if r.Interval <= 1 {
+		s = r.Frequency
+	} else {
+		unit := map[string]string{"daily": "days", "weekly": "weeks", "monthly": "months", "yearly": "years"}[r.Frequency]
+		s = fmt.Sprintf("every %d %s", r.Interval, unit)
+	}
+	if r.Until != nil && *r.Until != "" {
+		s += " until " + *r.Until
+	}
+	return s
+}
+
+// Next returns the first occurrence after due, in the same format as due
+// (YYYY-MM-DD for all-day dates, RFC 3339 for timed ones). Timed dates are
+// stepped in loc so the wall-clock time survives DST changes; nil means the
+// offset in due. ok is false when the rule has ended or due cannot be parsed.
+func (r *Recurrence) Next(due string, loc *time.Location) (next string, ok bool) {
+	layout := "2006-01-02"
+	t, err := time.Parse(layout, due)
+	if err != nil {
+		layout = time.RFC3339
+		if t, err = time.Parse(layout, due); err != nil {
+			return "", false
+		}
+		if loc != nil {
+			t = t.In(loc)
+		}
+	}
+	n := r.Interval
+	if n < 1 {
+		n = 1
+	}
+	switch r.Frequency {
+	case "daily":
+		t = t.AddDate(0, 0, n)
+	case "weekly":
+		t = t.AddDate(0, 0, 7*n)
+	case "monthly":
+		t = addMonthsClamped(t, n)
+	case "yearly":
+		t = addMonthsClamped(t, 12*n)
+	default:
+		return "", false
+	}
+	next = t.Format(layout)
+	if r.Until != nil && *r.Until != "" && t.Format("2006-01-02") > *r.Until {
+		return "", false
+	}
+	return next, true
+}
+
+// addMonthsClamped adds n months, clamping to the last day of the target
+// month (Jan 31 + 1 month = Feb 28/29). Each step starts from the current
+// due date and the rule does not record the day it started on, so a
+// clamped date keeps the earlier day: Jan 31, Feb 28, Mar 28. (The
+// Reminders app keeps the original day of the month.)
+func addMonthsClamped(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).AddDate(0, n, 0)
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+}
@@ -0,0 +1,174 @@
+package models_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" // the DST tests need zoneinfo on any host
+
+	"icloud-reminders/pkg/models"
+)
+
+func str(s string) *string { return &s }
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		spec, until string
+		want        string // String() of the rule, "" for nil
+	}{
+		{"daily", "", "daily"},
+		{"Weekly", "", "weekly"},
+		{" monthly ", "", "monthly"},
+		{"yearly", "2027-01-01", "yearly until 2027-01-01"},
+		{"every day", "", "daily"},
+		{"every 1 week", "", "weekly"},
+		{"every 2 weeks", "", "every 2 weeks"},
+		{"every 3 months", "2026-12-31", "every 3 months until 2026-12-31"},
+		{"every 10 years", "", "every 10 years"},
+		{"EVERY 4 DAYS", "", "every 4 days"},
+		{"none", "", ""},
+	}
+	for _, tt := range tests {
+		rule, err := models.ParseRecurrence(tt.spec, tt.until)
+		if err != nil {
+			t.Errorf("ParseRecurrence(%q, %q): %v", tt.spec, tt.until, err)
+			continue
+		}
+		if got := rule.String(); got != tt.want {
+			t.Errorf("ParseRecurrence(%q, %q) = %q, want %q", tt.spec, tt.until, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct{ spec, until string }{
+		{"", ""},
+		{"hourly", ""},
+		{"every", ""},
+		{"every 0 days", ""},
+		{"every -1 weeks", ""},
+		{"every two weeks", ""},
+		{"every 2 fortnights", ""},
+		{"every 2 weeks on monday", ""},
+		{"weekly", "2026-13-01"},
+		{"weekly", "next year"},
+	} {
+		if rule, err := models.ParseRecurrence(tt.spec, tt.until); err == nil {
+			t.Errorf("ParseRecurrence(%q, %q) = %v, want an error", tt.spec, tt.until, rule)
+		}
+	}
+}
+
+func TestRecurrenceString(t *testing.T) {
+	tests := []struct {
+		rule *models.Recurrence
+		want string
+	}{
+		{nil, ""},
+		{&models.Recurrence{Frequency: "daily", Interval: 1}, "daily"},
+		{&models.Recurrence{Frequency: "weekly", Interval: 0}, "weekly"},
+		{&models.Recurrence{Frequency: "daily", Interval: 3}, "every 3 days"},
+		{&models.Recurrence{Frequency: "yearly", Interval: 2, Until: str("2030-01-01")}, "every 2 years until 2030-01-01"},
+		{&models.Recurrence{Frequency: "monthly", Interval: 1, Until: str("")}, "monthly"},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.rule, got, tt.want)
+		}
+		// What String writes, ParseRecurrence reads back.
+		if tt.rule == nil || tt.want == "" {
+			continue
+		}
+		spec, until, _ := strings.Cut(tt.want, " until ")
+		back, err := models.ParseRecurrence(spec, until)
+		if err != nil || back.String() != tt.want {
+			t.Errorf("ParseRecurrence(%q, %q) = %v, %v; want %q", spec, until, back, err, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceNext(t *testing.T) {
+	rule := func(freq string, n int, until string) *models.Recurrence {
+		r := &models.Recurrence{Frequency: freq, Interval: n}
+		if until != "" {
+			r.Until = &until
+		}
+		return r
+	}
+	tests := []struct {
+		rule *models.Recurrence
+		due  string
+		want string // "" when there is no next occurrence
+	}{
+		{rule("daily", 1, ""), "2026-10-16", "2026-10-17"},
+		{rule("daily", 3, ""), "2026-12-30", "2027-01-02"},
+		{rule("weekly", 1, ""), "2026-10-16", "2026-10-23"},
+		{rule("weekly", 2, ""), "2026-10-16", "2026-10-30"},
+		{rule("weekly", 0, ""), "2026-10-16", "2026-10-23"}, // interval 0 counts as 1
+		{rule("monthly", 1, ""), "2026-10-16", "2026-11-16"},
+		{rule("monthly", 3, ""), "2026-11-15", "2027-02-15"},
+		{rule("yearly", 1, ""), "2026-10-16", "2027-10-16"},
+
+		// Month ends clamp to the last day of the shorter month.
+		{rule("monthly", 1, ""), "2026-01-31", "2026-02-28"},
+		{rule("monthly", 1, ""), "2028-01-31", "2028-02-29"},
+		{rule("monthly", 1, ""), "2026-03-31", "2026-04-30"},
+		{rule("yearly", 1, ""), "2028-02-29", "2029-02-28"},
+		// The next step starts from the clamped date; the original day is
+		// not known (see addMonthsClamped).
+		{rule("monthly", 1, ""), "2026-02-28", "2026-03-28"},
+
+		// Timed dates keep their format and offset.
+		{rule("daily", 1, ""), "2026-10-16T09:00:00Z", "2026-10-17T09:00:00Z"},
+		{rule("weekly", 1, ""), "2026-10-16T09:00:00+02:00", "2026-10-23T09:00:00+02:00"},
+
+		// until is inclusive.
+		{rule("weekly", 1, "2026-10-23"), "2026-10-16", "2026-10-23"},
+		{rule("weekly", 1, "2026-10-22"), "2026-10-16", ""},
+		{rule("daily", 1, "2026-10-16"), "2026-10-16T23:00:00Z", ""},
+		{rule("daily", 1, "2026-10-17"), "2026-10-16T23:00:00Z", "2026-10-17T23:00:00Z"},
+
+		{rule("hourly", 1, ""), "2026-10-16", ""},
+		{rule("daily", 1, ""), "someday", ""},
+		{rule("daily", 1, ""), "2026-10-16 09:00", ""},
+	}
+	for _, tt := range tests {
+		got, ok := tt.rule.Next(tt.due, nil)
+		if ok != (tt.want != "") || got != tt.want {
+			t.Errorf("%v.Next(%q) = %q, %v; want %q", tt.rule, tt.due, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRecurrenceNextAcrossDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		rule *models.Recurrence
+		due  string
+		loc  *time.Location
+		want string
+	}{
+		// 09:00 EDT stays 09:00 local once New York is on EST (2026-11-01).
+		{&models.Recurrence{Frequency: "weekly", Interval: 1}, "2026-10-30T09:00:00-04:00", ny, "2026-11-06T09:00:00-05:00"},
+		{&models.Recurrence{Frequency: "daily", Interval: 1}, "2026-10-31T09:00:00-04:00", ny, "2026-11-01T09:00:00-05:00"},
+		// A due time given in UTC is stepped in loc, then written in loc.
+		{&models.Recurrence{Frequency: "daily", Interval: 1}, "2026-10-31T13:00:00Z", ny, "2026-11-01T09:00:00-05:00"},
+		// Berlin moves to CEST on 2026-03-29.
+		{&models.Recurrence{Frequency: "monthly", Interval: 1}, "2026-03-15T08:30:00+01:00", berlin, "2026-04-15T08:30:00+02:00"},
+		// Without a location the offset in due is kept, so the wall clock moves.
+		{&models.Recurrence{Frequency: "weekly", Interval: 1}, "2026-10-30T09:00:00-04:00", nil, "2026-11-06T09:00:00-04:00"},
+		// All-day dates are not affected by loc.
+		{&models.Recurrence{Frequency: "daily", Interval: 1}, "2026-10-31", ny, "2026-11-01"},
+	}
+	for _, tt := range tests {
+		got, ok := tt.rule.Next(tt.due, tt.loc)
+		if !ok || got != tt.want {
+			t.Errorf("%v.Next(%q, %v) = %q, %v; want %q", tt.rule, tt.due, tt.loc, got, ok, tt.want)
+		}
+	}
+}